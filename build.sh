@@ -8,6 +8,9 @@
 #
 # Production build (omits test plugin binaries to minimize image size and builds for container)
 # PRODUCTION_BUILD=true ./build.sh
+#
+# Static build (single statically linked sg-core binary with all built-in plugins compiled in)
+# STATIC_BUILD=true ./build.sh
 
 base=$(pwd)
 
@@ -15,6 +18,7 @@ GOCMD=${GOCMD:-"go"}
 PLUGIN_DIR=${PLUGIN_DIR:-"/tmp/plugins/"}
 CONTAINER_BUILD=${CONTAINER_BUILD:-false}
 BUILD_ARGS=${BUILD_ARGS:-''}
+STATIC_BUILD=${STATIC_BUILD:-false}
 
 PRODUCTION_BUILD=${PRODUCTION_BUILD:-false}
 if $PRODUCTION_BUILD; then
@@ -37,6 +41,9 @@ if $PRODUCTION_BUILD; then
 
   OMIT_APPLICATIONS=(
   )
+
+  OMIT_PROCESSORS=(
+  )
 fi
 
 
@@ -86,6 +93,64 @@ build_plugins() {
       $GOCMD build $BUILD_ARGS -o "$PLUGIN_DIR$(basename $i).so" -buildmode=plugin
     fi
   done
+
+  # build processors
+  cd "$base"
+  for i in plugins/processor/*; do
+    cd "$base/$i"
+    search_list "$(basename $i)" OMIT_PROCESSORS
+    if [ $? -ne 1 ]; then
+      echo "building $(basename $i).so"
+      $GOCMD build $BUILD_ARGS -o "$PLUGIN_DIR$(basename $i).so" -buildmode=plugin
+    fi
+  done
+}
+
+build_static() {
+  # plugin sources are declared as "package main" so that they can be built
+  # with -buildmode=plugin. For static build they are turned into importable
+  # packages through build overlay, so the source tree stays untouched.
+  # Plugins then add themselves to pkg/registry from their init() functions.
+  cd "$base"
+  overlay=$(mktemp -d)
+  replace=""
+  imports=""
+  for i in plugins/transport/* plugins/handler/* plugins/application/* plugins/processor/*; do
+    kind=$(basename "$(dirname $i)")
+    case $kind in
+      transport) omit=OMIT_TRANSPORTS ;;
+      handler) omit=OMIT_HANDLERS ;;
+      application) omit=OMIT_APPLICATIONS ;;
+      processor) omit=OMIT_PROCESSORS ;;
+    esac
+    search_list "$(basename $i)" $omit
+    if [ $? -eq 1 ]; then
+      continue
+    fi
+    echo "linking $(basename $i)"
+    pkg=$(echo "${kind}_$(basename $i)" | tr -- '-' '_')
+    mkdir -p "$overlay/$i"
+    for src in $i/*.go; do
+      sed "s/^package main$/package $pkg/" "$src" > "$overlay/$src"
+      replace="$replace\"$base/$src\": \"$overlay/$src\","
+    done
+    imports="$imports\t_ \"github.com/infrawatch/sg-core/$i\"\n"
+  done
+
+  mkdir -p "$overlay/cmd"
+  printf "package main\n\nimport (\n$imports)\n" > "$overlay/cmd/plugins.go"
+  replace="$replace\"$base/cmd/plugins.go\": \"$overlay/cmd/plugins.go\""
+  echo "{\"Replace\": {$replace}}" > "$overlay/overlay.json"
+
+  output="sg-core"
+  if $CONTAINER_BUILD; then
+      echo "building static sg-core for container"
+      output="/tmp/sg-core"
+  fi
+  CGO_ENABLED=0 $GOCMD build $BUILD_ARGS -overlay "$overlay/overlay.json" -o "$output" ./cmd
+  ret=$?
+  rm -rf "$overlay"
+  return $ret
 }
 
 build_core() {
@@ -99,5 +164,9 @@ build_core() {
   fi
 }
 
-build_plugins
-build_core
+if $STATIC_BUILD; then
+  build_static
+else
+  build_plugins
+  build_core
+fi
@@ -1,26 +1,61 @@
 package main
 
 import (
+	"bytes"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/infrawatch/sg-core/cmd/manager"
+	"github.com/infrawatch/sg-core/pkg/bus"
+	"github.com/infrawatch/sg-core/pkg/config"
+	"github.com/infrawatch/sg-core/pkg/deadletter"
+	"github.com/infrawatch/sg-core/pkg/filter"
+	"github.com/pkg/errors"
 	"gopkg.in/yaml.v3"
 )
 
 type configT struct {
-	PluginDir     string `yaml:"pluginDir"`
-	LogLevel      string `yaml:"logLevel" validate:"oneof=error warn info debug"`
-	HandlerErrors bool   `yaml:"handleErrors"`
-	BlockEventBus bool   `yaml:"blockEventBus"`
-	Transports    []struct {
-		Name     string `validate:"required"`
-		Handlers []struct {
-			Name   string `validate:"required"`
-			Config interface{}
-		} `validate:"dive"`
-		Config interface{}
-	} `validate:"dive"`
-	Applications []struct {
-		Name   string `validate:"required"`
-		Config interface{}
-	} `validate:"dive"`
+	PluginDir       string          `yaml:"pluginDir"`
+	LogLevel        string          `yaml:"logLevel" validate:"oneof=error warn info debug"`
+	LogFormat       string          `yaml:"logFormat" validate:"oneof=console json"`
+	LogOutput       string          `yaml:"logOutput" validate:"required"` // console or path of log file
+	HandlerErrors   bool            `yaml:"handleErrors"`
+	BlockEventBus   bool            `yaml:"blockEventBus"` // deprecated, same as eventBus.overflow: block
+	EventBus        bus.QueueConfig `yaml:"eventBus"`
+	MetricBus       bus.QueueConfig `yaml:"metricBus"`
+	ShutdownTimeout time.Duration   `yaml:"shutdownTimeout"` // time given to applications to receive and flush remaining data on shutdown
+	HTTP            struct {
+		Address string `yaml:"address"` // core HTTP listener serving health and status endpoints, disabled when empty
+	} `yaml:"http"`
+	Debug struct {
+		Address            string        `yaml:"address"`            // debug HTTP listener serving pprof profiles, disabled when empty
+		CPUProfileDuration time.Duration `yaml:"cpuProfileDuration"` // duration of CPU profile captured when request does not give seconds
+	} `yaml:"debug"`
+	Transports   []transportConfig     `validate:"dive"`
+	Processors   []manager.PluginBlock `yaml:"processors" validate:"dive"` // applied to all data before they reach applications
+	Applications []applicationConfig   `validate:"dive"`
+}
+
+// transportConfig configuration block of transport together with its handlers
+type transportConfig struct {
+	Name       string                `validate:"required"`
+	Instance   string                `yaml:"instance"`
+	Handlers   []manager.PluginBlock `validate:"dive"`
+	Processors []manager.PluginBlock `yaml:"processors" validate:"dive"` // applied to data published by handlers of the transport
+	Config     interface{}
+	Restart    manager.RestartPolicy `yaml:"restart"`
+	DeadLetter deadletter.Config     `yaml:"deadLetter"` // messages handlers fail to handle are stored when configured
+	WorkerPool manager.WorkerPool    `yaml:"workerPool"` // messages are handled in transport goroutine when not configured
+}
+
+// applicationConfig configuration block of application
+type applicationConfig struct {
+	Name     string `validate:"required"`
+	Instance string `yaml:"instance"`
+	Config   interface{}
+	Filter   filter.Config         `yaml:"filter"`
+	Restart  manager.RestartPolicy `yaml:"restart"`
 }
 
 func (ct *configT) Bytes() []byte {
@@ -28,9 +63,124 @@ func (ct *configT) Bytes() []byte {
 	return res
 }
 
-var configuration = configT{
-	PluginDir:     "/usr/lib64/sg-core/",
-	LogLevel:      "info",
-	HandlerErrors: false,
-	BlockEventBus: false,
+// redacted returns copy of configuration with secrets in plugin configuration blocks redacted
+func (ct configT) redacted() configT {
+	redactBlocks := func(blocks []manager.PluginBlock) []manager.PluginBlock {
+		res := make([]manager.PluginBlock, len(blocks))
+		for i, block := range blocks {
+			block.Config = manager.Redact(block.Config)
+			res[i] = block
+		}
+		return res
+	}
+
+	res := ct
+	res.Transports = append(res.Transports[:0:0], ct.Transports...)
+	for i := range res.Transports {
+		res.Transports[i].Config = manager.Redact(res.Transports[i].Config)
+		res.Transports[i].Handlers = redactBlocks(res.Transports[i].Handlers)
+		res.Transports[i].Processors = redactBlocks(res.Transports[i].Processors)
+	}
+	res.Processors = redactBlocks(ct.Processors)
+	res.Applications = append(res.Applications[:0:0], ct.Applications...)
+	for i := range res.Applications {
+		res.Applications[i].Config = manager.Redact(res.Applications[i].Config)
+	}
+	return res
+}
+
+func defaultConfiguration() configT {
+	return configT{
+		PluginDir:       "/usr/lib64/sg-core/",
+		LogLevel:        "info",
+		LogFormat:       logFormatConsole,
+		LogOutput:       logOutputConsole,
+		HandlerErrors:   false,
+		BlockEventBus:   false,
+		ShutdownTimeout: 10 * time.Second,
+		Debug: struct {
+			Address            string        `yaml:"address"`
+			CPUProfileDuration time.Duration `yaml:"cpuProfileDuration"`
+		}{
+			CPUProfileDuration: 30 * time.Second,
+		},
+		EventBus: bus.QueueConfig{
+			QueueSize: bus.DefaultQueueSize,
+			Workers:   bus.DefaultWorkers,
+			Overflow:  bus.Block.String(),
+		},
+		MetricBus: bus.QueueConfig{
+			QueueSize: bus.DefaultQueueSize,
+			Workers:   bus.DefaultWorkers,
+			Overflow:  bus.Block.String(),
+		},
+	}
+}
+
+// readConfiguration parses configuration file or directory on top of default configuration.
+// Environment variable and secret file references are resolved and files are merged before
+// parsing, so that plugins receive configuration with actual values
+func readConfiguration(path string) (configT, error) {
+	conf := defaultConfiguration()
+	blob, err := config.Load(path, "transports", "applications", "processors")
+	if err != nil {
+		return conf, errors.Wrap(err, "failed loading config file")
+	}
+
+	err = config.ParseConfig(bytes.NewReader(blob), &conf)
+	if err != nil {
+		return conf, errors.Wrap(err, "failed parsing config file")
+	}
+	if conf.BlockEventBus {
+		conf.EventBus.Overflow = bus.Block.String()
+	}
+	return conf, checkInstances(conf)
+}
+
+// checkInstances verifies that instance names given to plugins are unique among plugins of the same type
+func checkInstances(conf configT) error {
+	used := map[string]bool{}
+	check := func(typ string, instance string) error {
+		if instance == "" {
+			return nil
+		}
+		if used[typ+"/"+instance] {
+			return fmt.Errorf("%s instance name '%s' is not unique", typ, instance)
+		}
+		used[typ+"/"+instance] = true
+		return nil
+	}
+
+	errs := []string{}
+	for _, tConfig := range conf.Transports {
+		if err := check("transport", tConfig.Instance); err != nil {
+			errs = append(errs, err.Error())
+		}
+		for _, hConfig := range tConfig.Handlers {
+			if err := check("handler", hConfig.Instance); err != nil {
+				errs = append(errs, err.Error())
+			}
+		}
+		for _, pConfig := range tConfig.Processors {
+			if err := check("processor", pConfig.Instance); err != nil {
+				errs = append(errs, err.Error())
+			}
+		}
+	}
+	for _, pConfig := range conf.Processors {
+		if err := check("processor", pConfig.Instance); err != nil {
+			errs = append(errs, err.Error())
+		}
+	}
+	for _, aConfig := range conf.Applications {
+		if err := check("application", aConfig.Instance); err != nil {
+			errs = append(errs, err.Error())
+		}
+	}
+	if len(errs) > 0 {
+		return errors.New(strings.Join(errs, ", "))
+	}
+	return nil
 }
+
+var configuration = defaultConfiguration()
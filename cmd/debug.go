@@ -0,0 +1,46 @@
+package main
+
+import (
+	"net/http"
+	"net/http/pprof"
+	"strconv"
+	"time"
+
+	"github.com/infrawatch/sg-core/pkg/pluginlog"
+)
+
+// opt-in debug HTTP listener serving runtime profiles of running sg-core. It is separate from
+// the core HTTP listener, since profiles and goroutine dumps can reveal sensitive data
+
+const debugListener = "debug HTTP listener"
+
+func newDebugMux(cpuProfileDuration time.Duration) *http.ServeMux {
+	mux := http.NewServeMux()
+	// index links goroutine dumps (?debug=2) and heap, allocs, threadcreate, block and mutex profiles
+	mux.HandleFunc("/debug/pprof/", pprof.Index)
+	mux.HandleFunc("/debug/pprof/cmdline", pprof.Cmdline)
+	seconds := int(cpuProfileDuration / time.Second)
+	if seconds < 1 {
+		seconds = 1
+	}
+	mux.HandleFunc("/debug/pprof/profile", func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("seconds") == "" {
+			q := r.URL.Query()
+			q.Set("seconds", strconv.Itoa(seconds))
+			r.URL.RawQuery = q.Encode()
+		}
+		pprof.Profile(w, r)
+	})
+	mux.HandleFunc("/debug/pprof/symbol", pprof.Symbol)
+	mux.HandleFunc("/debug/pprof/trace", pprof.Trace)
+	return mux
+}
+
+// startDebugServer starts debug HTTP listener on given address
+func startDebugServer(address string, cpuProfileDuration time.Duration, logger *pluginlog.Logger) *http.Server {
+	return listen(address, newDebugMux(cpuProfileDuration), debugListener, logger)
+}
+
+func stopDebugServer(srv *http.Server, logger *pluginlog.Logger) {
+	shutdown(srv, debugListener, logger)
+}
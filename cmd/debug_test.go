@@ -0,0 +1,63 @@
+package main
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path"
+	"testing"
+	"time"
+
+	"github.com/infrawatch/apputils/logging"
+	"github.com/infrawatch/sg-core/pkg/pluginlog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDebugMux(t *testing.T) {
+	srv := httptest.NewServer(newDebugMux(time.Second))
+	defer srv.Close()
+
+	get := func(path string) (int, string) {
+		resp, err := http.Get(srv.URL + path)
+		require.NoError(t, err)
+		defer resp.Body.Close()
+		body, err := io.ReadAll(resp.Body)
+		require.NoError(t, err)
+		return resp.StatusCode, string(body)
+	}
+
+	t.Run("goroutine dump", func(t *testing.T) {
+		status, body := get("/debug/pprof/goroutine?debug=2")
+		assert.Equal(t, http.StatusOK, status)
+		assert.Contains(t, body, "goroutine ")
+		assert.Contains(t, body, "TestDebugMux")
+	})
+
+	t.Run("heap profile", func(t *testing.T) {
+		status, body := get("/debug/pprof/heap")
+		assert.Equal(t, http.StatusOK, status)
+		assert.NotEmpty(t, body)
+	})
+
+	t.Run("cpu profile with configured duration", func(t *testing.T) {
+		start := time.Now()
+		status, body := get("/debug/pprof/profile")
+		assert.Equal(t, http.StatusOK, status)
+		assert.NotEmpty(t, body)
+		assert.GreaterOrEqual(t, int64(time.Since(start)), int64(time.Second))
+	})
+}
+
+func TestWriteMemProfile(t *testing.T) {
+	dir := t.TempDir()
+	logger, err := logging.NewLogger(logging.DEBUG, path.Join(dir, "test.log"))
+	require.NoError(t, err)
+
+	file := path.Join(dir, "mem.prof")
+	writeMemProfile(file, pluginlog.NewCore(logger))
+	info, err := os.Stat(file)
+	require.NoError(t, err)
+	assert.NotZero(t, info.Size())
+}
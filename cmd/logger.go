@@ -0,0 +1,59 @@
+package main
+
+import (
+	"io"
+	"os"
+
+	"github.com/infrawatch/apputils/logging"
+	"github.com/infrawatch/sg-core/pkg/pluginlog"
+)
+
+// log formats and outputs
+const (
+	logFormatConsole = "console"
+	logFormatJSON    = "json"
+	logOutputConsole = "console"
+)
+
+var logLevels = map[string]logging.LogLevel{
+	"error": logging.ERROR,
+	"warn":  logging.WARN,
+	"info":  logging.INFO,
+	"debug": logging.DEBUG,
+}
+
+func setLogLevel(logger *pluginlog.Logger, level string) {
+	logger.SetLevel(logLevels[level])
+	pluginlog.SetLevel(logLevels[level])
+}
+
+// newLogger creates sg-core logger writing records in given format to output, which is either
+// console or path of log file. Records of loggers from pkg/pluginlog are encoded to JSON directly
+// from their metadata when format is json. Returned function closes the logger
+func newLogger(format string, output string) (*logging.Logger, func(), error) {
+	logger, err := logging.NewLogger(logging.DEBUG, output)
+	if err != nil {
+		return nil, nil, err
+	}
+	logger.Timestamp = true
+	if format != logFormatJSON {
+		return logger, func() { _ = logger.Destroy() }, nil
+	}
+
+	var out io.WriteCloser = os.Stdout
+	if output != logOutputConsole {
+		out, err = os.OpenFile(output, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0666)
+		if err != nil {
+			_ = logger.Destroy()
+			return nil, nil, err
+		}
+	}
+	pluginlog.SetWriter(pluginlog.NewJSONWriter(out))
+	return logger, func() {
+		pluginlog.SetWriter(nil)
+		if out != os.Stdout {
+			out.Close()
+		}
+		_ = logger.Destroy()
+	}, nil
+}
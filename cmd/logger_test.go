@@ -0,0 +1,60 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"os"
+	"path"
+	"testing"
+
+	"github.com/infrawatch/apputils/logging"
+	"github.com/infrawatch/sg-core/pkg/pluginlog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestJSONLogger(t *testing.T) {
+	logpath := path.Join(t.TempDir(), "sg-core.log")
+	l, closeLogger, err := newLogger(logFormatJSON, logpath)
+	require.NoError(t, err)
+
+	logger := pluginlog.NewCore(l)
+	setLogLevel(logger, "info")
+	logger.Error("failed to bind socket\nretrying", logging.Metadata{"error": errors.New("connection refused"), "retries": 3})
+	logger.Info("loaded [transport]", logging.Metadata{"transport": "socket0, handler: events"})
+	logger.Debug("debug record")
+	pluginlog.New(l, "transport", "socket").Warn("plugin record")
+	closeLogger()
+
+	f, err := os.Open(logpath)
+	require.NoError(t, err)
+	defer f.Close()
+	records := []map[string]interface{}{}
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		record := map[string]interface{}{}
+		require.NoError(t, json.Unmarshal(scanner.Bytes(), &record))
+		assert.Contains(t, record, "time")
+		delete(record, "time")
+		records = append(records, record)
+	}
+	assert.Equal(t, []map[string]interface{}{
+		{"level": "error", "message": "failed to bind socket\nretrying", "error": "connection refused", "retries": float64(3)},
+		{"level": "info", "message": "loaded [transport]", "transport": "socket0, handler: events"},
+		{"level": "warn", "message": "plugin record", "pluginType": "transport", "plugin": "socket", "instance": "socket"},
+	}, records)
+}
+
+func TestConsoleLogger(t *testing.T) {
+	logpath := path.Join(t.TempDir(), "sg-core.log")
+	l, closeLogger, err := newLogger(logFormatConsole, logpath)
+	require.NoError(t, err)
+
+	pluginlog.NewCore(l).Info("loaded transport", logging.Metadata{"transport": "socket0"})
+	closeLogger()
+
+	out, err := os.ReadFile(logpath)
+	require.NoError(t, err)
+	assert.Contains(t, string(out), "[INFO] loaded transport [transport: socket0]\n")
+}
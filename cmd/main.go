@@ -5,107 +5,103 @@ import (
 	"flag"
 	"fmt"
 	"os"
+	"os/signal"
+	"runtime"
 	"runtime/pprof"
 	"sync"
 	"syscall"
 
 	"github.com/infrawatch/apputils/logging"
-	"github.com/infrawatch/apputils/system"
 	"github.com/infrawatch/sg-core/cmd/manager"
-	"github.com/infrawatch/sg-core/pkg/config"
+	"github.com/infrawatch/sg-core/pkg/pluginlog"
 )
 
 func main() {
-	configPath := flag.String("config", "/etc/sg-core.conf.yaml", "configuration file path")
+	if len(os.Args) > 1 && os.Args[1] == "validate" {
+		os.Exit(runValidate(os.Args[2:]))
+	}
+
+	configPath := flag.String("config", "/etc/sg-core.conf.yaml", "configuration file or directory path")
+	printConfig := flag.Bool("print-config", false, "print effective configuration with secrets redacted and exit")
+	printSecrets := flag.Bool("print-secrets", false, "do not redact secrets printed by -print-config")
 	cpuprofile := flag.String("cpuprofile", "", "write cpu profile to file")
-	// memprofile := flag.String("memprofile", "", "write cpu profile to file")
+	memprofile := flag.String("memprofile", "", "write heap profile to file on exit")
 	flag.Usage = func() {
-		fmt.Printf("Usage: %s [OPTIONS]\n\nAvailable options:\n", os.Args[0])
+		fmt.Printf("Usage: %s [OPTIONS]\n       %s validate [OPTIONS]\n\nAvailable options:\n", os.Args[0], os.Args[0])
 		flag.PrintDefaults()
 
 		fmt.Printf("\n\nDefault configurations:\n\n%s", string(configuration.Bytes()))
 	}
 	flag.Parse()
 
-	logger, err := logging.NewLogger(logging.DEBUG, "console")
+	if *printConfig {
+		conf, err := readConfiguration(*configPath)
+		if err != nil {
+			fmt.Printf("failed loading configuration: %s\n", err)
+			os.Exit(1)
+		}
+		if !*printSecrets {
+			conf = conf.redacted()
+		}
+		fmt.Print(string(conf.Bytes()))
+		return
+	}
+
+	consoleLogger, err := logging.NewLogger(logging.DEBUG, "console")
 	if err != nil {
 		fmt.Printf("failed initializing logger: %s", err)
 		return
 	}
-	logger.Timestamp = true
+	consoleLogger.Timestamp = true
+	logger := pluginlog.NewCore(consoleLogger)
 
 	if *cpuprofile != "" {
 		f, err := os.Create(*cpuprofile)
 		if err != nil {
-			logger.Metadata(logging.Metadata{"error": err})
-			logger.Error("failed to start cpu profile")
+			logger.Error("failed to start cpu profile", logging.Metadata{"error": err})
 		}
 		err = pprof.StartCPUProfile(f)
 		if err != nil {
-			logger.Metadata(logging.Metadata{"error": err})
-			logger.Error("failed to start cpu profile")
+			logger.Error("failed to start cpu profile", logging.Metadata{"error": err})
 		}
 		defer pprof.StopCPUProfile()
 	}
 
-	file, err := os.Open(*configPath)
+	configuration, err = readConfiguration(*configPath)
 	if err != nil {
-		logger.Metadata(logging.Metadata{"error": err})
-		logger.Error("failed opening configuration file")
+		logger.Error("failed loading configuration", logging.Metadata{"error": err})
 		return
 	}
 
-	err = config.ParseConfig(file, &configuration)
+	configuredLogger, closeLogger, err := newLogger(configuration.LogFormat, configuration.LogOutput)
 	if err != nil {
-		logger.Metadata(logging.Metadata{"error": err})
-		logger.Error("failed parsing config file")
+		logger.Error("failed initializing logger", logging.Metadata{"error": err, "output": configuration.LogOutput})
 		return
 	}
+	defer closeLogger()
+	logger = pluginlog.NewCore(configuredLogger)
+	setLogLevel(logger, configuration.LogLevel)
+	if *memprofile != "" {
+		defer writeMemProfile(*memprofile, logger)
+	}
 
-	logger.SetLogLevel(map[string]logging.LogLevel{
-		"error": logging.ERROR,
-		"warn":  logging.WARN,
-		"info":  logging.INFO,
-		"debug": logging.DEBUG,
-	}[configuration.LogLevel])
-
-	manager.SetLogger(logger)
+	manager.SetLogger(configuredLogger)
 	manager.SetPluginDir(configuration.PluginDir)
-	manager.SetEventBusBlocking(configuration.BlockEventBus)
+	manager.SetBusQueues(configuration.EventBus, configuration.MetricBus)
 
-	for _, tConfig := range configuration.Transports {
-		tName, err := manager.InitTransport(tConfig.Name, tConfig.Config)
-		if err != nil {
-			logger.Metadata(logging.Metadata{"transport": tConfig.Name, "error": err})
-			logger.Error("failed configuring transport")
-			continue
-		}
-		err = manager.SetTransportHandlers(tName, tConfig.Handlers)
-		if err != nil {
-			logger.Metadata(logging.Metadata{"transport": tName, "error": err})
-			logger.Error("transport handlers failed to load")
-			continue
-		}
-		logger.Metadata(logging.Metadata{"transport": tName})
-		logger.Info("loaded transport")
+	if configuration.HTTP.Address != "" {
+		srv := startServer(configuration.HTTP.Address, logger)
+		defer stopServer(srv, logger)
 	}
-
-	for _, aConfig := range configuration.Applications {
-		err = manager.InitApplication(aConfig.Name, aConfig.Config)
-		if err != nil {
-			if err == manager.ErrAppNotReceiver {
-				logger.Metadata(logging.Metadata{"application": aConfig.Name})
-				logger.Warn(err.Error())
-			} else {
-				logger.Metadata(logging.Metadata{"application": aConfig.Name, "error": err})
-				logger.Error("failed configuring application")
-				continue
-			}
-		}
-		logger.Metadata(logging.Metadata{"application": aConfig.Name})
-		logger.Info("loaded application plugin")
+	if configuration.Debug.Address != "" {
+		srv := startDebugServer(configuration.Debug.Address, configuration.Debug.CPUProfileDuration, logger)
+		defer stopDebugServer(srv, logger)
 	}
 
+	loadProcessors(logger, configuration)
+	loadTransports(logger, configuration)
+	err = loadApplications(logger, configuration)
+
 	// NOTE(mmagr): so if err will be just the warning from above, do we still need to end execution?
 	if err != nil {
 		return
@@ -115,23 +111,57 @@ func main() {
 	wg := new(sync.WaitGroup)
 	// run main processes
 
-	pluginDone := make(chan bool) // notified if a plugin stops execution before main or interrupt Received
-	interrupt := make(chan bool)
+	pluginDone := make(chan bool) // notified if a plugin fails and its restart policy demands exit
+	interrupt := make(chan os.Signal, 1)
+	signal.Notify(interrupt, syscall.SIGINT, syscall.SIGKILL)
+	manager.RunProcessors(ctx, wg)
 	manager.RunTransports(ctx, wg, pluginDone, configuration.HandlerErrors)
 	manager.RunApplications(ctx, wg, pluginDone)
-	system.SpawnSignalHandler(interrupt, logger, syscall.SIGINT, syscall.SIGKILL)
+	manager.RunSupervisorMetrics(ctx, wg)
+	started.Store(true)
+
+	// configuration is reloaded on SIGHUP
+	hangup := make(chan os.Signal, 1)
+	signal.Notify(hangup, syscall.SIGHUP)
 
 	for {
 		select {
 		case <-pluginDone:
 			goto done
-		case <-interrupt:
+		case sig := <-interrupt:
+			logger.Info("stopping on caught signal", logging.Metadata{"signal": sig.String()})
 			goto done
+		case <-hangup:
+			logger.Info("reloading configuration")
+			if reloadConfiguration(*configPath, logger) {
+				manager.RunProcessors(ctx, wg)
+				manager.RunTransports(ctx, wg, pluginDone, configuration.HandlerErrors)
+				manager.RunApplications(ctx, wg, pluginDone)
+				logger.Info("configuration reloaded")
+			}
 		}
 	}
 
 done:
+	started.Store(false)
+	logger.Info("shutting down")
+	manager.Shutdown(configuration.ShutdownTimeout)
 	cancelCtx()
 	wg.Wait()
 	logger.Info("sg-core exited cleanly")
 }
+
+// writeMemProfile writes heap profile to given file
+func writeMemProfile(path string, logger *pluginlog.Logger) {
+	f, err := os.Create(path)
+	if err != nil {
+		logger.Error("failed to write memory profile", logging.Metadata{"error": err})
+		return
+	}
+	defer f.Close()
+	// up-to-date statistics of allocations
+	runtime.GC()
+	if err := pprof.WriteHeapProfile(f); err != nil {
+		logger.Error("failed to write memory profile", logging.Metadata{"error": err})
+	}
+}
@@ -0,0 +1,154 @@
+package manager
+
+import (
+	"path/filepath"
+	"sort"
+	"sync"
+
+	"github.com/infrawatch/apputils/logging"
+	"github.com/infrawatch/sg-core/pkg/deadletter"
+	"github.com/pkg/errors"
+)
+
+// messages which handlers of a transport fail to handle can be stored in dead-letter store
+// of the transport. Stored messages are listed and re-injected through admin API
+
+// ErrTransportNotRunning returned when messages are re-injected to transport which is not running
+var ErrTransportNotRunning = errors.New("transport is not running")
+
+var (
+	deadLetterStores = map[string]*deadletter.Store{} // transport -> store
+	pipelines        = map[string]*pipeline{}         // transport -> pipeline of running transport
+	pipelinesLock    sync.RWMutex
+)
+
+// DeadLetters stored messages of a transport
+type DeadLetters struct {
+	Transport string             `json:"transport"`
+	Directory string             `json:"directory"`
+	Entries   []deadletter.Entry `json:"entries"`
+}
+
+// ReinjectResult result of re-injection of stored message. Successfully handled messages are removed from the store
+type ReinjectResult struct {
+	ID      string `json:"id"`
+	Handler string `json:"handler,omitempty"`
+	Error   string `json:"error,omitempty"`
+}
+
+// SetTransportDeadLetters opens dead-letter store of transport in subdirectory named by the transport instance
+func SetTransportDeadLetters(name string, conf deadletter.Config) error {
+	if conf.Directory == "" {
+		return nil
+	}
+	store, err := deadletter.New(filepath.Join(conf.Directory, name), conf.MaxEntries)
+	if err != nil {
+		return err
+	}
+	pipelinesLock.Lock()
+	defer pipelinesLock.Unlock()
+	deadLetterStores[name] = store
+	return nil
+}
+
+func deadLetterStore(name string) *deadletter.Store {
+	pipelinesLock.RLock()
+	defer pipelinesLock.RUnlock()
+	return deadLetterStores[name]
+}
+
+func deleteDeadLetterStore(name string) {
+	pipelinesLock.Lock()
+	defer pipelinesLock.Unlock()
+	delete(deadLetterStores, name)
+}
+
+func setPipeline(name string, p *pipeline) {
+	pipelinesLock.Lock()
+	defer pipelinesLock.Unlock()
+	if p == nil {
+		delete(pipelines, name)
+		return
+	}
+	pipelines[name] = p
+}
+
+// ListDeadLetters returns messages stored in dead-letter stores of all transports sorted by transport name
+func ListDeadLetters() ([]DeadLetters, error) {
+	pipelinesLock.RLock()
+	stores := make(map[string]*deadletter.Store, len(deadLetterStores))
+	for name, store := range deadLetterStores {
+		stores[name] = store
+	}
+	pipelinesLock.RUnlock()
+
+	res := []DeadLetters{}
+	for name, store := range stores {
+		entries, err := store.List()
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed listing dead letters of transport '%s'", name)
+		}
+		res = append(res, DeadLetters{Transport: name, Directory: store.Dir(), Entries: entries})
+	}
+	sort.Slice(res, func(i, j int) bool { return res[i].Transport < res[j].Transport })
+	return res, nil
+}
+
+// ReinjectDeadLetters passes stored messages again to the handlers which failed to handle them.
+// All stored messages of the transport are re-injected when no ids are given
+func ReinjectDeadLetters(transportName string, ids []string) ([]ReinjectResult, error) {
+	pipelinesLock.RLock()
+	store := deadLetterStores[transportName]
+	p := pipelines[transportName]
+	pipelinesLock.RUnlock()
+	if store == nil {
+		return nil, errors.Wrapf(deadletter.ErrNotFound, "transport '%s' has no dead-letter store", transportName)
+	}
+	if p == nil {
+		return nil, errors.Wrapf(ErrTransportNotRunning, "transport '%s'", transportName)
+	}
+
+	if len(ids) == 0 {
+		entries, err := store.List()
+		if err != nil {
+			return nil, err
+		}
+		for _, entry := range entries {
+			ids = append(ids, entry.ID)
+		}
+	}
+
+	results := make([]ReinjectResult, 0, len(ids))
+	for _, id := range ids {
+		res := ReinjectResult{ID: id}
+		if err := p.reinject(store, id, &res); err != nil {
+			res.Error = err.Error()
+		}
+		results = append(results, res)
+	}
+	return results, nil
+}
+
+// reinject passes stored message to the handler which failed to handle it
+func (p *pipeline) reinject(store *deadletter.Store, id string, res *ReinjectResult) error {
+	entry, blob, err := store.Get(id)
+	if err != nil {
+		return err
+	}
+	res.Handler = entry.Handler
+
+	for i, name := range p.names {
+		if name != entry.Handler {
+			continue
+		}
+		err = handle(p.handlers[i], blob, p.report, p.pubs[i])
+		if err != nil {
+			if uerr := store.Update(id, err); uerr != nil {
+				log.Warn("failed updating dead letter", logging.Metadata{"error": uerr, "transport": p.transport, "id": id})
+			}
+			return err
+		}
+		return store.Remove(id)
+	}
+	return errors.Errorf("handler '%s' is not loaded", entry.Handler)
+}
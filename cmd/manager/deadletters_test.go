@@ -0,0 +1,101 @@
+package manager
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/infrawatch/sg-core/pkg/bus"
+	"github.com/infrawatch/sg-core/pkg/deadletter"
+	"github.com/infrawatch/sg-core/pkg/handler"
+	"github.com/infrawatch/sg-core/pkg/transport"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fixableHandler fails to handle messages until it is fixed
+type fixableHandler struct {
+	registeredHandler
+	fixed   atomic.Bool
+	handled chan string
+}
+
+func (fh *fixableHandler) Handle(msg []byte, _ bool, _ bus.MetricPublishFunc, _ bus.EventPublishFunc) error {
+	if !fh.fixed.Load() {
+		return errors.New("parser bug")
+	}
+	fh.handled <- string(msg)
+	return nil
+}
+
+func TestDeadLetters(t *testing.T) {
+	originalTransports := transports
+	originalHandlers := handlers
+	defer func() {
+		transports = originalTransports
+		handlers = originalHandlers
+	}()
+	SetLogger(newTestLogger(t))
+
+	h := &fixableHandler{handled: make(chan string, 10)}
+	transports = map[string]transport.Transport{"writing0": &writingTransport{messages: []string{"first", "second"}}}
+	handlers = map[string][]handler.Handler{"writing0": {h}}
+	dir := t.TempDir()
+	require.NoError(t, SetTransportDeadLetters("writing0", deadletter.Config{Directory: dir}))
+	defer StopTransport("writing0")
+
+	_, err := ReinjectDeadLetters("writing0", nil)
+	assert.True(t, errors.Is(err, ErrTransportNotRunning))
+	_, err = ReinjectDeadLetters("unknown", nil)
+	assert.True(t, errors.Is(err, deadletter.ErrNotFound))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	wg := &sync.WaitGroup{}
+	RunTransports(ctx, wg, make(chan bool), false)
+	defer func() {
+		cancel()
+		wg.Wait()
+	}()
+
+	var dls []DeadLetters
+	require.Eventually(t, func() bool {
+		dls, err = ListDeadLetters()
+		return err == nil && len(dls) == 1 && len(dls[0].Entries) == 2
+	}, time.Second, 10*time.Millisecond)
+	assert.Equal(t, "writing0", dls[0].Transport)
+	assert.Equal(t, dir+"/writing0", dls[0].Directory)
+	entry := dls[0].Entries[0]
+	assert.Equal(t, "registered[writing0]", entry.Handler)
+	assert.Equal(t, "parser bug", entry.Error)
+
+	t.Run("failed re-injection keeps messages", func(t *testing.T) {
+		results, err := ReinjectDeadLetters("writing0", []string{entry.ID})
+		require.NoError(t, err)
+		assert.Equal(t, []ReinjectResult{{ID: entry.ID, Handler: "registered[writing0]", Error: "parser bug"}}, results)
+		dls, err := ListDeadLetters()
+		require.NoError(t, err)
+		assert.Len(t, dls[0].Entries, 2)
+	})
+
+	t.Run("messages are re-injected once handler is fixed", func(t *testing.T) {
+		h.fixed.Store(true)
+		results, err := ReinjectDeadLetters("writing0", nil)
+		require.NoError(t, err)
+		require.Len(t, results, 2)
+		for _, res := range results {
+			assert.Empty(t, res.Error)
+		}
+		assert.Equal(t, []string{"first", "second"}, []string{<-h.handled, <-h.handled})
+
+		dls, err := ListDeadLetters()
+		require.NoError(t, err)
+		assert.Empty(t, dls[0].Entries)
+
+		results, err = ReinjectDeadLetters("writing0", []string{entry.ID})
+		require.NoError(t, err)
+		assert.Equal(t, deadletter.ErrNotFound.Error(), results[0].Error)
+	})
+}
@@ -3,16 +3,24 @@ package manager
 import (
 	"context"
 	"fmt"
+	"maps"
 	"path/filepath"
 	"plugin"
 	"strconv"
 	"strings"
 	"sync"
+	"sync/atomic"
+	"time"
 
 	"github.com/infrawatch/apputils/logging"
 	"github.com/infrawatch/sg-core/pkg/application"
 	"github.com/infrawatch/sg-core/pkg/bus"
+	"github.com/infrawatch/sg-core/pkg/data"
+	"github.com/infrawatch/sg-core/pkg/deadletter"
+	"github.com/infrawatch/sg-core/pkg/filter"
 	"github.com/infrawatch/sg-core/pkg/handler"
+	"github.com/infrawatch/sg-core/pkg/pluginlog"
+	"github.com/infrawatch/sg-core/pkg/registry"
 	"github.com/infrawatch/sg-core/pkg/transport"
 	"github.com/pkg/errors"
 	"gopkg.in/yaml.v2"
@@ -22,26 +30,51 @@ import (
 var (
 	// ErrAppNotReceiver return if application plugin does not implement any receiver. In this case, it will receive no messages from the internal buses
 	ErrAppNotReceiver = errors.New("application plugin does not implement either application.MetricReceiver or application.EventReceiver")
+	// ErrPluginExited recorded for plugin whose Run returned without being stopped
+	ErrPluginExited = errors.New("plugin exited unexpectedly")
+	// ErrPluginSignaled recorded for plugin which signaled failure to sg-core
+	ErrPluginSignaled = errors.New("plugin signaled failure")
+	// ErrInstanceExists returned if plugin instance name is already used by another plugin of the same type
+	ErrInstanceExists = errors.New("plugin instance name already in use")
 )
+
+// instanceLabel names label of internal metrics and error events holding plugin instance name
+const instanceLabel = "instance"
+
+// PluginBlock configuration block of handler or processor plugin
+type PluginBlock struct {
+	Name     string `validate:"required"`
+	Instance string `yaml:"instance"` // identifies the plugin in logs, status and internal metrics, generated when empty
+	Config   interface{}
+}
+
 var (
-	transports        map[string]transport.Transport
-	handlers          map[string][]handler.Handler
-	applications      map[string]application.Application
-	eventBus          bus.EventBus
-	metricBus         bus.MetricBus
-	pluginPath        string
-	logger            *logging.Logger
-	eventPublishFunc  bus.EventPublishFunc
-	metricPublishFunc bus.MetricPublishFunc
+	transports      map[string]transport.Transport
+	handlers        map[string][]handler.Handler
+	handlerNames    map[string][]string
+	applications    map[string]application.Application
+	pluginsLock     sync.RWMutex // guards transports, handlers, handlerNames and applications, reload changes them while status is read
+	transportRuns   map[string]*runState
+	applicationRuns map[string]*runState
+	subscriptions   map[string][]subscription
+	pluginLoggers   map[string]*logging.Logger
+	eventBus        bus.EventBus
+	metricBus       bus.MetricBus
+	pluginPath      string
+	logger          *logging.Logger
+	log             *pluginlog.Logger // records of sg-core itself
 )
 
 func init() {
 	transports = map[string]transport.Transport{}
 	handlers = map[string][]handler.Handler{}
+	handlerNames = map[string][]string{}
 	applications = map[string]application.Application{}
+	transportRuns = map[string]*runState{}
+	applicationRuns = map[string]*runState{}
+	subscriptions = map[string][]subscription{}
+	pluginLoggers = map[string]*logging.Logger{}
 	pluginPath = "/usr/lib64/sg-core"
-	eventPublishFunc = eventBus.Publish
-	metricPublishFunc = metricBus.Publish
 }
 
 // SetPluginDir set directory path containing plugin binaries
@@ -52,167 +85,693 @@ func SetPluginDir(path string) {
 // SetLogger set logger
 func SetLogger(l *logging.Logger) {
 	logger = l
+	log = pluginlog.NewCore(l)
 }
 
-// SetBlockingEventBus set the correct event bus publish function
-func SetEventBusBlocking(block bool) {
-	if block {
-		eventPublishFunc = eventBus.PublishBlocking
+// SetBusQueues set configuration of subscriber queues of event and metric bus.
+// Applies only to applications initialized afterwards
+func SetBusQueues(events bus.QueueConfig, metrics bus.QueueConfig) {
+	eventBus.SetQueueConfig(events)
+	metricBus.SetQueueConfig(metrics)
+}
+
+// subscription of an application or sending transport to one of the buses
+type subscription struct {
+	bus   string
+	id    int
+	tasks *taskQueue // sending transports only
+}
+
+func (s subscription) unsubscribe() {
+	if s.bus == eventBusName {
+		eventBus.Unsubscribe(s.id)
 	} else {
-		eventPublishFunc = eventBus.Publish
+		metricBus.Unsubscribe(s.id)
+	}
+	if s.tasks != nil {
+		s.tasks.close()
 	}
 }
 
-// InitTransport load tranpsort binary and initialize with config
-func InitTransport(name string, config interface{}) (string, error) {
-	n, err := initPlugin(name)
-	if err != nil {
-		return "", errors.Wrap(err, "failed initializing transport")
+func (s subscription) stats() (bus.SubscriberStats, bool) {
+	all := metricBus.Stats()
+	if s.bus == eventBusName {
+		all = eventBus.Stats()
 	}
-
-	new, ok := n.(func(*logging.Logger) transport.Transport)
-	if !ok {
-		return "", fmt.Errorf("plugin %s constructor 'New' did not return type 'transport.Transport'", name)
+	for _, st := range all {
+		if st.ID == s.id {
+			// tasks waiting for sending transport are counted together with its bus queue
+			if s.tasks != nil {
+				st.Depth += len(s.tasks.tasks)
+				st.Dropped += s.tasks.dropped.Load()
+			}
+			return st, true
+		}
 	}
+	return bus.SubscriberStats{}, false
+}
 
-	// Append the current length of transports
-	// to make each name unique
-	uniqueName := name + strconv.Itoa(len(transports))
-	transports[uniqueName] = new(logger)
-
-	c, err := yaml.Marshal(config)
-	if err != nil {
-		return "", errors.Wrapf(err, "failed parsing transport config for '%s'", name)
+// InitTransport load tranpsort binary and initialize with config. Transport is identified by given
+// instance name, unique name is generated when it is empty. Returns instance name of the transport
+func InitTransport(name string, instance string, config interface{}) (string, error) {
+	pluginsLock.RLock()
+	uniqueName := instance
+	if uniqueName == "" {
+		// Append the current length of transports
+		// to make each name unique. Transports might have been unloaded
+		// on reload, so skip indexes which are still in use
+		index := len(transports)
+		uniqueName = name + strconv.Itoa(index)
+		for _, ok := transports[uniqueName]; ok; _, ok = transports[uniqueName] {
+			index++
+			uniqueName = name + strconv.Itoa(index)
+		}
+	} else if _, ok := transports[uniqueName]; ok {
+		pluginsLock.RUnlock()
+		return "", errors.Wrapf(ErrInstanceExists, "transport '%s'", uniqueName)
 	}
+	pluginsLock.RUnlock()
 
-	err = transports[uniqueName].Config(c)
+	t, err := newTransport(name, config, pluginLogger(transportType, name, uniqueName))
 	if err != nil {
+		releasePluginLogger(transportType, uniqueName)
 		return "", err
 	}
+	pluginsLock.Lock()
+	transports[uniqueName] = t
+	pluginsLock.Unlock()
+	trackPlugin(transportType, name, uniqueName, t)
+	trackConfig(transportType, uniqueName, config)
 	return uniqueName, nil
 }
 
-// InitApplication initialize application plugin with configuration
-func InitApplication(name string, config interface{}) error {
-	n, err := initPlugin(name)
-	if err != nil {
-		return errors.Wrap(err, "failed initializing application plugin")
+// handle passes message to handler, handlers parsing multiple metrics from message publish them in single batch
+func handle(h handler.Handler, blob []byte, report bool, pub publishers) error {
+	if bh, ok := h.(handler.BatchHandler); ok {
+		return bh.HandleBatch(blob, report, pub.metrics, pub.event)
 	}
+	return h.Handle(blob, report, pub.metric, pub.event)
+}
 
-	new, ok := n.(func(*logging.Logger, bus.EventPublishFunc) application.Application)
-	if !ok {
-		return fmt.Errorf("plugin %s constructor 'New' did not return type 'application.Application'", name)
+// InitApplication initialize application plugin with configuration. Only metrics and events
+// passing given filter are delivered to the application. Application is identified by given instance
+// name. When it is empty, name of the plugin is used for its first instance and further instances
+// get index appended. Returns instance name of the application
+func InitApplication(name string, instance string, config interface{}, filterConf filter.Config) (string, error) {
+	pluginsLock.RLock()
+	if instance == "" {
+		instance = name
+		for index := 1; applications[instance] != nil; index++ {
+			instance = name + strconv.Itoa(index)
+		}
+	} else if _, ok := applications[instance]; ok {
+		pluginsLock.RUnlock()
+		return "", errors.Wrapf(ErrInstanceExists, "application '%s'", instance)
 	}
-
-	app := new(logger, eventBus.Publish)
-
-	c, err := yaml.Marshal(config)
+	pluginsLock.RUnlock()
+	f, err := filter.New(filterConf)
 	if err != nil {
-		return errors.Wrapf(err, "failed parsing application plugin config for '%s'", name)
+		return "", errors.Wrapf(err, "failed parsing filter of application '%s'", instance)
 	}
 
-	err = app.Config(c)
+	app, err := newApplication(name, config, pluginLogger(applicationType, name, instance), labelErrors(publishEvent, instance))
 	if err != nil {
-		return err
+		releasePluginLogger(applicationType, instance)
+		return "", err
 	}
 
-	// does it implement MetricReceiver?
+	// does it implement MetricBatchReceiver or MetricReceiver?
 	// does it implement EventReceiver?
 	var mReceiver bool
 	var eReceiver bool
 	var itf interface{} = app
-	if r, ok := itf.(application.MetricReceiver); ok {
+	if r, ok := itf.(application.MetricBatchReceiver); ok {
+		mReceiver = true
+		id := metricBus.SubscribeBatch(filterMetrics(f, r.ReceiveMetrics))
+		subscriptions[instance] = append(subscriptions[instance], subscription{bus: metricBusName, id: id})
+	} else if r, ok := itf.(application.MetricReceiver); ok {
 		mReceiver = true
-		metricBus.Subscribe(r.ReceiveMetric)
+		id := metricBus.SubscribeBatch(filterMetrics(f, func(metrics []data.Metric) {
+			for _, m := range metrics {
+				r.ReceiveMetric(m.Name, m.Time, m.Type, m.Interval, m.Value, m.LabelKeys, m.LabelVals)
+			}
+		}))
+		subscriptions[instance] = append(subscriptions[instance], subscription{bus: metricBusName, id: id})
 	}
 
 	if r, ok := itf.(application.EventReceiver); ok {
 		eReceiver = true
-		eventBus.Subscribe(r.ReceiveEvent)
+		id := eventBus.Subscribe(filterEvents(f, r.ReceiveEvent))
+		subscriptions[instance] = append(subscriptions[instance], subscription{bus: eventBusName, id: id})
 	}
 
 	if !(mReceiver || eReceiver) {
-		return ErrAppNotReceiver
+		releasePluginLogger(applicationType, instance)
+		return instance, ErrAppNotReceiver
 	}
 
-	applications[name] = app
-	return nil
+	pluginsLock.Lock()
+	applications[instance] = app
+	pluginsLock.Unlock()
+	trackPlugin(applicationType, name, instance, app)
+	trackConfig(applicationType, instance, config)
+	trackSubscriptions(applicationType, instance, subscriptions[instance])
+	return instance, nil
 }
 
-// SetTransportHandlers load handlers binaries for transport
-func SetTransportHandlers(name string, handlerBlocks []struct {
-	Name   string `validate:"required"`
-	Config interface{}
-}) error {
-	for _, block := range handlerBlocks {
-		n, err := initPlugin(block.Name)
-		if err != nil {
-			return errors.Wrap(err, "failed initializing handler")
+// filterMetrics wraps receive function so that only metrics passing the filter are received
+func filterMetrics(f *filter.Filter, rf bus.MetricBatchReceiveFunc) bus.MetricBatchReceiveFunc {
+	if f == nil {
+		return rf
+	}
+	return func(metrics []data.Metric) {
+		if metrics = f.Metrics(metrics); len(metrics) > 0 {
+			rf(metrics)
 		}
+	}
+}
 
-		new, ok := n.(func() handler.Handler)
-		if !ok {
-			return fmt.Errorf("handler %s constructor did not return type handler.Handler", block.Name)
+// filterEvents wraps receive function so that only events passing the filter are received
+func filterEvents(f *filter.Filter, rf bus.EventReceiveFunc) bus.EventReceiveFunc {
+	if f == nil {
+		return rf
+	}
+	return func(e data.Event) {
+		if f.Event(e) {
+			rf(e)
 		}
-		h := new()
+	}
+}
 
-		configBlob, err := yaml.Marshal(block.Config)
-		if err != nil {
-			return errors.Wrapf(err, "failed parsing handler plugin config for '%s'", block.Name)
+// SetTransportHandlers load handlers binaries for transport
+func SetTransportHandlers(name string, handlerBlocks []PluginBlock) error {
+	for _, block := range handlerBlocks {
+		if block.Instance != "" && tracked(handlerType, block.Instance) {
+			return errors.Wrapf(ErrInstanceExists, "handler '%s'", block.Instance)
 		}
-
-		err = h.Config(configBlob)
+		h, err := newHandler(block.Name, block.Config)
 		if err != nil {
-			return errors.Wrapf(err, "failed configuring handler plugin '%s'", block.Name)
+			return err
 		}
 
+		hName := block.Instance
+		if hName == "" {
+			hName = handlerInstance(h, name)
+		}
+		pluginsLock.Lock()
 		handlers[name] = append(handlers[name], h)
+		handlerNames[name] = append(handlerNames[name], hName)
+		pluginsLock.Unlock()
+		trackPlugin(handlerType, block.Name, hName, h)
+		trackConfig(handlerType, hName, block.Config)
 
-		logger.Metadata(logging.Metadata{"transport pair": name, "handler": block.Name})
-		logger.Info("initialized handler")
+		log.Info("initialized handler", logging.Metadata{"transport pair": name, "handler": hName})
 	}
 	return nil
 }
 
-// RunTransports spins off tranpsort + handler processes
+// handlerName returns instance name of i-th handler of transport
+func handlerName(transportName string, i int) string {
+	pluginsLock.RLock()
+	defer pluginsLock.RUnlock()
+	if names := handlerNames[transportName]; i < len(names) {
+		return names[i]
+	}
+	return handlerInstance(handlers[transportName][i], transportName)
+}
+
+// labelMetrics adds instance label to metrics published by plugin on its own, eg. its internal metrics
+func labelMetrics(mpf bus.MetricPublishFunc, instance string) bus.MetricPublishFunc {
+	return func(name string, t float64, typ data.MetricType, interval time.Duration, value float64, labelKeys []string, labelVals []string) {
+		keys := append(labelKeys[:len(labelKeys):len(labelKeys)], instanceLabel)
+		vals := append(labelVals[:len(labelVals):len(labelVals)], instance)
+		mpf(name, t, typ, interval, value, keys, vals)
+	}
+}
+
+// labelErrors adds instance label to error events published by plugin
+func labelErrors(epf bus.EventPublishFunc, instance string) bus.EventPublishFunc {
+	return func(e data.Event) {
+		if e.Type == data.ERROR {
+			labels := make(map[string]interface{}, len(e.Labels)+1)
+			for k, v := range e.Labels {
+				labels[k] = v
+			}
+			labels[instanceLabel] = instance
+			e.Labels = labels
+		}
+		epf(e)
+	}
+}
+
+// RunTransports spins off tranpsort + handler processes. Each transport runs under its own
+// context, so that it can be stopped separately. Transports which are already running are skipped
 func RunTransports(ctx context.Context, wg *sync.WaitGroup, done chan bool, report bool) {
-	for name, t := range transports {
-		for _, h := range handlers[name] {
-			wg.Add(1)
-			go func(wg *sync.WaitGroup, h handler.Handler) {
-				defer wg.Done()
-				h.Run(ctx, metricPublishFunc, eventPublishFunc)
-			}(wg, h)
+	pluginsLock.RLock()
+	ts := maps.Clone(transports)
+	hss := maps.Clone(handlers)
+	pluginsLock.RUnlock()
+	for name, t := range ts {
+		if _, ok := transportRuns[name]; ok {
+			continue
 		}
+		rs := newRunState(ctx)
+		transportRuns[name] = rs
 
-		wg.Add(1)
-		go func(wg *sync.WaitGroup, t transport.Transport, name string) {
-			defer wg.Done()
-			t.Run(ctx, func(blob []byte) {
-				for _, h := range handlers[name] {
-					err := h.Handle(blob, report, metricPublishFunc, eventPublishFunc)
-					if err != nil {
-						logger.Metadata(logging.Metadata{"error": err, "handler": fmt.Sprintf("%s[%s]", h.Identify(), name)})
-						logger.Debug("failed handling message")
-					}
-				}
-			}, done)
-		}(wg, t, name)
+		hs := hss[name]
+		c := transportChains[name]
+		if c != nil {
+			c.start(rs.ctx, wg)
+		}
+		pub := newPublishers(c)
+		p := newPipeline(name, hs, pub, report)
+		for i, h := range hs {
+			setState(handlerType, p.names[i], StateRunning, nil)
+			rs.spawn(wg, func(ctx context.Context) {
+				h.Run(ctx, labelMetrics(pub.metric, p.names[i]), p.pubs[i].event)
+			})
+		}
+		setPipeline(name, p)
+
+		// messages are passed to handlers by workers when transport has worker pool
+		write := p.handle
+		var q *messageQueue
+		if pool := transportWorkerPool(name); pool.Workers > 0 {
+			q = newMessageQueue(pool, p.handle)
+			write = q.push
+		}
+		setWorkerQueue(name, q)
+
+		gate := &writeGate{transport: name, write: write}
+
+		setState(transportType, name, StateRunning, nil)
+		subscribeSender(name, t)
+		rs.spawn(wg, func(context.Context) {
+			st := rs.supervise(transportType, name, done, func(ctx context.Context, pluginDone chan bool) {
+				t.Run(ctx, gate.Write, pluginDone)
+			})
+			gate.stop()
+			if q != nil {
+				q.close()
+			}
+			// handlers and processors are fed by the transport, so they share its state
+			for _, hName := range p.names {
+				setState(handlerType, hName, st, nil)
+			}
+			if c != nil {
+				c.setState(st)
+			}
+		})
 	}
 }
 
-// RunApplications spins off application processes
+// writeGate passes messages written by transport to its pipeline until the transport stops. Stopping
+// waits for writes in progress, so that no data of the transport reach handlers and buses afterwards
+type writeGate struct {
+	transport string
+	write     transport.WriteFn
+	lock      sync.RWMutex
+	stopped   bool
+	late      atomic.Uint64
+}
+
+// Write implements transport.WriteFn. Messages written after Run of the transport returned are dropped
+func (g *writeGate) Write(msg []byte) {
+	g.lock.RLock()
+	defer g.lock.RUnlock()
+	if g.stopped {
+		if g.late.Add(1) == 1 {
+			log.Warn("transport wrote data after it stopped, data dropped", logging.Metadata{"transport": g.transport})
+		}
+		return
+	}
+	g.write(msg)
+}
+
+// stop waits for writes in progress and stops passing messages
+func (g *writeGate) stop() {
+	g.lock.Lock()
+	defer g.lock.Unlock()
+	g.stopped = true
+}
+
+// pipeline passes messages received by transport to its handlers
+type pipeline struct {
+	transport   string
+	handlers    []handler.Handler
+	names       []string
+	pubs        []publishers
+	counters    []*messageCounters
+	report      bool
+	deadLetters *deadletter.Store
+}
+
+func newPipeline(transportName string, hs []handler.Handler, pub publishers, report bool) *pipeline {
+	p := &pipeline{
+		transport:   transportName,
+		handlers:    hs,
+		names:       make([]string, len(hs)),
+		pubs:        make([]publishers, len(hs)),
+		counters:    make([]*messageCounters, len(hs)),
+		report:      report,
+		deadLetters: deadLetterStore(transportName),
+	}
+	for i := range hs {
+		p.names[i] = handlerName(transportName, i)
+		p.pubs[i] = pub
+		p.pubs[i].event = labelErrors(pub.event, p.names[i])
+		p.counters[i] = handlerCounters(p.names[i])
+	}
+	return p
+}
+
+// handle passes message to all handlers, messages which handlers fail to handle are stored in dead-letter store.
+// It is called concurrently by transports receiving messages on multiple connections and by workers
+func (p *pipeline) handle(blob []byte) {
+	for i, h := range p.handlers {
+		p.counters[i].messages.Add(1)
+		err := handle(h, blob, p.report, p.pubs[i])
+		if err != nil {
+			p.counters[i].errors.Add(1)
+			log.Debug("failed handling message", logging.Metadata{"error": err, "handler": p.names[i]})
+			setLastError(handlerType, p.names[i], err)
+			p.deadLetter(p.names[i], blob, err)
+		}
+	}
+}
+
+func (p *pipeline) deadLetter(handlerName string, blob []byte, err error) {
+	if p.deadLetters == nil {
+		return
+	}
+	if _, err := p.deadLetters.Add(handlerName, blob, err); err != nil {
+		log.Warn("failed storing dead letter", logging.Metadata{"error": err, "transport": p.transport})
+	}
+}
+
+// RunApplications spins off application processes. Applications which are already running are skipped
 func RunApplications(ctx context.Context, wg *sync.WaitGroup, done chan bool) {
-	for _, a := range applications {
+	pluginsLock.RLock()
+	apps := maps.Clone(applications)
+	pluginsLock.RUnlock()
+	for name, a := range apps {
+		if _, ok := applicationRuns[name]; ok {
+			continue
+		}
+		rs := newRunState(ctx)
+		applicationRuns[name] = rs
+
+		setState(applicationType, name, StateRunning, nil)
+		rs.spawn(wg, func(context.Context) {
+			rs.supervise(applicationType, name, done, a.Run)
+		})
+	}
+}
+
+// StopTransport stops transport and its handlers, waits for them to exit and unloads them
+func StopTransport(name string) {
+	// tasks queued for the transport are sent while it is still running
+	unsubscribeSender(name)
+	if rs, ok := transportRuns[name]; ok {
+		rs.stop()
+		delete(transportRuns, name)
+	}
+	pluginsLock.RLock()
+	n := len(handlers[name])
+	pluginsLock.RUnlock()
+	for i := 0; i < n; i++ {
+		untrackPlugin(handlerType, handlerName(name, i))
+	}
+	if c, ok := transportChains[name]; ok {
+		c.stop()
+		c.release()
+		delete(transportChains, name)
+	}
+	setPipeline(name, nil)
+	deleteDeadLetterStore(name)
+	deleteWorkerPool(name)
+	untrackPlugin(transportType, name)
+	deleteRestartPolicy(transportType, name)
+	releasePluginLogger(transportType, name)
+	pluginsLock.Lock()
+	delete(transports, name)
+	delete(handlers, name)
+	delete(handlerNames, name)
+	pluginsLock.Unlock()
+}
+
+// StopApplication unsubscribes application from buses, stops it, waits for it to exit and unloads it
+func StopApplication(name string) {
+	for _, sub := range subscriptions[name] {
+		sub.unsubscribe()
+	}
+	delete(subscriptions, name)
+	if rs, ok := applicationRuns[name]; ok {
+		rs.stop()
+		delete(applicationRuns, name)
+	}
+	untrackPlugin(applicationType, name)
+	deleteRestartPolicy(applicationType, name)
+	releasePluginLogger(applicationType, name)
+	pluginsLock.Lock()
+	delete(applications, name)
+	pluginsLock.Unlock()
+}
+
+// helper functions
+
+// Shutdown stops loaded plugins in phases. Sending transports are unsubscribed from buses first,
+// tasks already queued for them are sent. Then transports are stopped, so that no new data are
+// received, together with their handlers. Phase ends once writes of transports in progress are
+// handled, later writes are dropped. Then buses are drained and applications implementing
+// application.Flusher are flushed. Applications are stopped last. Draining and flushing together
+// are limited by timeout
+func Shutdown(timeout time.Duration) {
+	// tasks queued for transports are sent while they are still running
+	for name := range senderSubscriptions {
+		unsubscribeSender(name)
+	}
+	for _, rs := range transportRuns {
+		rs.cancel()
+	}
+	for _, rs := range transportRuns {
+		rs.wg.Wait()
+	}
+	log.Debug("transports stopped")
+
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	if err := metricBus.Drain(ctx); err != nil {
+		log.Warn("failed to drain metric bus", logging.Metadata{"error": err})
+	}
+	if err := eventBus.Drain(ctx); err != nil {
+		log.Warn("failed to drain event bus", logging.Metadata{"error": err})
+	}
+	log.Debug("buses drained")
+
+	pluginsLock.RLock()
+	apps := maps.Clone(applications)
+	pluginsLock.RUnlock()
+	wg := sync.WaitGroup{}
+	for name, app := range apps {
+		f, ok := app.(application.Flusher)
+		if !ok {
+			continue
+		}
+		if _, running := applicationRuns[name]; !running {
+			continue
+		}
 		wg.Add(1)
-		go func(wg *sync.WaitGroup, a application.Application) {
+		go func() {
 			defer wg.Done()
-			a.Run(ctx, done)
-		}(wg, a)
+			if err := f.Flush(ctx); err != nil {
+				log.Warn("failed to flush application", logging.Metadata{"application": name, "error": err})
+			}
+		}()
+	}
+	flushed := make(chan struct{})
+	go func() {
+		wg.Wait()
+		close(flushed)
+	}()
+	select {
+	case <-flushed:
+	case <-ctx.Done():
+		log.Warn("applications did not flush before shutdown timeout")
 	}
+
+	for _, rs := range applicationRuns {
+		rs.cancel()
+	}
+	for _, rs := range applicationRuns {
+		rs.wg.Wait()
+	}
+	log.Debug("applications stopped")
+
+	// applications can publish events, so global processors are stopped last
+	stopProcessors()
 }
 
-// helper functions
+// runState tracks goroutines of one plugin so that the plugin can be stopped without
+// affecting the rest of the pipelines
+type runState struct {
+	ctx    context.Context
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+func newRunState(parent context.Context) *runState {
+	ctx, cancel := context.WithCancel(parent)
+	return &runState{ctx: ctx, cancel: cancel}
+}
+
+func (rs *runState) spawn(wg *sync.WaitGroup, fn func(context.Context)) {
+	wg.Add(1)
+	rs.wg.Add(1)
+	go func() {
+		defer wg.Done()
+		defer rs.wg.Done()
+		fn(rs.ctx)
+	}()
+}
+
+func (rs *runState) stop() {
+	rs.cancel()
+	rs.wg.Wait()
+}
+
+// pluginLogger returns logger given to plugin instance. It is a copy of sg-core logger registered
+// with identity of the plugin, so that pkg/pluginlog can attach it to records of the plugin
+func pluginLogger(typ string, name string, instance string) *logging.Logger {
+	l := pluginlog.Copy(logger)
+	pluginlog.Register(l, pluginlog.Identity{Type: typ, Name: name, Instance: instance})
+	pluginLoggers[statusKey(typ, instance)] = l
+	return l
+}
+
+func releasePluginLogger(typ string, instance string) {
+	if l, ok := pluginLoggers[statusKey(typ, instance)]; ok {
+		pluginlog.Unregister(l)
+		delete(pluginLoggers, statusKey(typ, instance))
+	}
+}
+
+// newTransport creates transport plugin and configures it
+func newTransport(name string, config interface{}, l *logging.Logger) (transport.Transport, error) {
+	new, err := transportConstructor(name)
+	if err != nil {
+		return nil, err
+	}
+	t := new(l)
+
+	c, err := yaml.Marshal(config)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed parsing transport config for '%s'", name)
+	}
+
+	err = t.Config(c)
+	if err != nil {
+		return nil, err
+	}
+	return t, nil
+}
+
+// newHandler creates handler plugin and configures it
+func newHandler(name string, config interface{}) (handler.Handler, error) {
+	new, err := handlerConstructor(name)
+	if err != nil {
+		return nil, err
+	}
+	h := new()
+
+	configBlob, err := yaml.Marshal(config)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed parsing handler plugin config for '%s'", name)
+	}
+
+	err = h.Config(configBlob)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed configuring handler plugin '%s'", name)
+	}
+	return h, nil
+}
+
+// newApplication creates application plugin and configures it. Events of the application are published with epf
+func newApplication(name string, config interface{}, l *logging.Logger, epf bus.EventPublishFunc) (application.Application, error) {
+	new, err := applicationConstructor(name)
+	if err != nil {
+		return nil, err
+	}
+	app := new(l, epf)
+
+	c, err := yaml.Marshal(config)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed parsing application plugin config for '%s'", name)
+	}
+
+	err = app.Config(c)
+	if err != nil {
+		return nil, err
+	}
+	return app, nil
+}
+
+// constructors of plugins compiled into sg-core take precedence over plugin binaries in plugin directory
+
+func transportConstructor(name string) (func(*logging.Logger) transport.Transport, error) {
+	if new, ok := registry.Transport(name); ok {
+		return new, nil
+	}
+
+	n, err := initPlugin(name)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed initializing transport")
+	}
+
+	new, ok := n.(func(*logging.Logger) transport.Transport)
+	if !ok {
+		return nil, fmt.Errorf("plugin %s constructor 'New' did not return type 'transport.Transport'", name)
+	}
+	return new, nil
+}
+
+func handlerConstructor(name string) (func() handler.Handler, error) {
+	if new, ok := registry.Handler(name); ok {
+		return new, nil
+	}
+
+	n, err := initPlugin(name)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed initializing handler")
+	}
+
+	new, ok := n.(func() handler.Handler)
+	if !ok {
+		return nil, fmt.Errorf("handler %s constructor did not return type handler.Handler", name)
+	}
+	return new, nil
+}
+
+func applicationConstructor(name string) (func(*logging.Logger, bus.EventPublishFunc) application.Application, error) {
+	if new, ok := registry.Application(name); ok {
+		return new, nil
+	}
+
+	n, err := initPlugin(name)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed initializing application plugin")
+	}
+
+	new, ok := n.(func(*logging.Logger, bus.EventPublishFunc) application.Application)
+	if !ok {
+		return nil, fmt.Errorf("plugin %s constructor 'New' did not return type 'application.Application'", name)
+	}
+	return new, nil
+}
+
+func handlerInstance(h handler.Handler, transportName string) string {
+	return fmt.Sprintf("%s[%s]", h.Identify(), transportName)
+}
 
 func initPlugin(name string) (plugin.Symbol, error) {
 	bin := strings.Join([]string{name, "so"}, ".")
@@ -2,18 +2,25 @@ package manager
 
 import (
 	"context"
+	"errors"
 	"os"
 	"path"
 	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
 
 	"github.com/infrawatch/apputils/logging"
 	"github.com/infrawatch/sg-core/pkg/application"
+	"github.com/infrawatch/sg-core/pkg/bus"
+	"github.com/infrawatch/sg-core/pkg/data"
+	"github.com/infrawatch/sg-core/pkg/filter"
 	"github.com/infrawatch/sg-core/pkg/handler"
+	"github.com/infrawatch/sg-core/pkg/registry"
 	"github.com/infrawatch/sg-core/pkg/transport"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v2"
 )
 
 func TestSetPluginDir(t *testing.T) {
@@ -61,41 +68,32 @@ func TestSetLogger(t *testing.T) {
 	})
 }
 
-func TestSetEventBusBlocking(t *testing.T) {
-	t.Run("set blocking event bus", func(t *testing.T) {
-		// Save original function pointer
-		originalFunc := eventPublishFunc
-		defer func() { eventPublishFunc = originalFunc }()
+func TestSetBusQueues(t *testing.T) {
+	defer SetBusQueues(bus.QueueConfig{}, bus.QueueConfig{})
 
-		SetEventBusBlocking(true)
-		// We can't directly compare function pointers, but we can verify it changed
-		assert.NotNil(t, eventPublishFunc)
+	SetBusQueues(bus.QueueConfig{QueueSize: 1, Overflow: "dropOldest"}, bus.QueueConfig{QueueSize: 1})
+	release := make(chan struct{})
+	received := make(chan string, 3)
+	id := eventBus.Subscribe(func(e data.Event) {
+		<-release
+		received <- e.Message
 	})
 
-	t.Run("set non-blocking event bus", func(t *testing.T) {
-		// Save original function pointer
-		originalFunc := eventPublishFunc
-		defer func() { eventPublishFunc = originalFunc }()
-
-		SetEventBusBlocking(false)
-		// We can't directly compare function pointers, but we can verify it changed
-		assert.NotNil(t, eventPublishFunc)
-	})
-
-	t.Run("toggle between blocking and non-blocking", func(t *testing.T) {
-		originalFunc := eventPublishFunc
-		defer func() { eventPublishFunc = originalFunc }()
-
-		SetEventBusBlocking(true)
-		assert.NotNil(t, eventPublishFunc)
-
-		SetEventBusBlocking(false)
-		assert.NotNil(t, eventPublishFunc)
-
-		// Toggle back to blocking
-		SetEventBusBlocking(true)
-		assert.NotNil(t, eventPublishFunc)
-	})
+	eventBus.Publish(data.Event{Message: "first"})
+	assert.Eventually(t, func() bool { return eventBus.Stats()[0].Depth == 0 }, time.Second, time.Millisecond)
+	eventBus.Publish(data.Event{Message: "second"})
+	eventBus.Publish(data.Event{Message: "third"})
+	st, ok := subscription{bus: eventBusName, id: id}.stats()
+	assert.True(t, ok)
+	assert.Equal(t, 1, st.Depth)
+	assert.Equal(t, uint64(1), st.Dropped)
+
+	close(release)
+	eventBus.Unsubscribe(id)
+	assert.Equal(t, "first", <-received)
+	assert.Equal(t, "third", <-received)
+	_, ok = subscription{bus: eventBusName, id: id}.stats()
+	assert.False(t, ok)
 }
 
 func TestInitTransport(t *testing.T) {
@@ -120,7 +118,7 @@ func TestInitTransport(t *testing.T) {
 		transports = map[string]transport.Transport{}
 
 		SetPluginDir(tmpdir)
-		_, err := InitTransport("nonexistent", map[string]interface{}{})
+		_, err := InitTransport("nonexistent", "", map[string]interface{}{})
 		require.Error(t, err)
 		assert.Contains(t, err.Error(), "failed initializing transport")
 	})
@@ -142,7 +140,7 @@ func TestInitTransport(t *testing.T) {
 
 		// Try to use the directory itself as the plugin path
 		pluginPath = invalidPluginDir
-		_, err = InitTransport(invalidPluginDir, map[string]interface{}{})
+		_, err = InitTransport(invalidPluginDir, "", map[string]interface{}{})
 		require.Error(t, err)
 		assert.Contains(t, err.Error(), "failed initializing transport")
 	})
@@ -169,7 +167,7 @@ func TestInitApplication(t *testing.T) {
 		applications = map[string]application.Application{}
 
 		SetPluginDir(tmpdir)
-		err := InitApplication("nonexistent", map[string]interface{}{})
+		_, err := InitApplication("nonexistent", "", map[string]interface{}{}, filter.Config{})
 		require.Error(t, err)
 		assert.Contains(t, err.Error(), "failed initializing application plugin")
 	})
@@ -185,7 +183,7 @@ func TestInitApplication(t *testing.T) {
 		applications = map[string]application.Application{}
 
 		SetPluginDir("/nonexistent/path")
-		err := InitApplication("test", map[string]interface{}{})
+		_, err := InitApplication("test", "", map[string]interface{}{}, filter.Config{})
 		require.Error(t, err)
 		assert.Contains(t, err.Error(), "failed initializing application plugin")
 	})
@@ -212,10 +210,7 @@ func TestSetTransportHandlers(t *testing.T) {
 		handlers = map[string][]handler.Handler{}
 
 		SetPluginDir(tmpdir)
-		handlerBlocks := []struct {
-			Name   string `validate:"required"`
-			Config interface{}
-		}{
+		handlerBlocks := []PluginBlock{
 			{
 				Name:   "nonexistent",
 				Config: map[string]interface{}{},
@@ -235,10 +230,7 @@ func TestSetTransportHandlers(t *testing.T) {
 
 		handlers = map[string][]handler.Handler{}
 
-		handlerBlocks := []struct {
-			Name   string `validate:"required"`
-			Config interface{}
-		}{}
+		handlerBlocks := []PluginBlock{}
 
 		err := SetTransportHandlers("test-transport", handlerBlocks)
 		require.NoError(t, err)
@@ -379,6 +371,253 @@ func TestRunApplications(t *testing.T) {
 	})
 }
 
+type registeredTransport struct {
+	config []byte
+}
+
+func (rt *registeredTransport) Config(c []byte) error {
+	rt.config = c
+	return nil
+}
+
+func (rt *registeredTransport) Run(context.Context, transport.WriteFn, chan bool) {}
+
+type registeredHandler struct{}
+
+func (rh *registeredHandler) Run(context.Context, bus.MetricPublishFunc, bus.EventPublishFunc) {}
+func (rh *registeredHandler) Identify() string                                                 { return "registered" }
+func (rh *registeredHandler) Handle([]byte, bool, bus.MetricPublishFunc, bus.EventPublishFunc) error {
+	return nil
+}
+func (rh *registeredHandler) Config([]byte) error { return nil }
+
+type registeredApplication struct{}
+
+func (ra *registeredApplication) Config([]byte) error            { return nil }
+func (ra *registeredApplication) Run(context.Context, chan bool) {}
+func (ra *registeredApplication) ReceiveEvent(data.Event)        {}
+
+func TestRegistryPlugins(t *testing.T) {
+	tmpdir, err := os.MkdirTemp(".", "manager_test_tmp")
+	require.NoError(t, err)
+	defer os.RemoveAll(tmpdir)
+
+	logpath := path.Join(tmpdir, "test.log")
+	testLogger, err := logging.NewLogger(logging.DEBUG, logpath)
+	require.NoError(t, err)
+	SetLogger(testLogger)
+
+	// plugin directory is empty, so plugins can be resolved only from registry
+	SetPluginDir(tmpdir)
+	registry.RegisterTransport("registered-transport", func(*logging.Logger) transport.Transport { return &registeredTransport{} })
+	registry.RegisterHandler("registered-handler", func() handler.Handler { return &registeredHandler{} })
+	registry.RegisterApplication("registered-application", func(*logging.Logger, bus.EventPublishFunc) application.Application {
+		return &registeredApplication{}
+	})
+
+	t.Run("transport and handler from registry", func(t *testing.T) {
+		originalTransports := transports
+		originalHandlers := handlers
+		defer func() {
+			transports = originalTransports
+			handlers = originalHandlers
+		}()
+		transports = map[string]transport.Transport{}
+		handlers = map[string][]handler.Handler{}
+
+		name, err := InitTransport("registered-transport", "", map[string]interface{}{"key": "value"})
+		require.NoError(t, err)
+		assert.Equal(t, "registered-transport0", name)
+		assert.Equal(t, "key: value\n", string(transports[name].(*registeredTransport).config))
+
+		err = SetTransportHandlers(name, []PluginBlock{{Name: "registered-handler"}})
+		require.NoError(t, err)
+		assert.Len(t, handlers[name], 1)
+	})
+
+	t.Run("application from registry", func(t *testing.T) {
+		originalApplications := applications
+		defer func() { applications = originalApplications }()
+		applications = map[string]application.Application{}
+
+		_, err := InitApplication("registered-application", "", nil, filter.Config{})
+		require.NoError(t, err)
+		assert.Contains(t, applications, "registered-application")
+	})
+}
+
+type batchHandler struct {
+	registeredHandler
+}
+
+func (bh *batchHandler) HandleBatch(_ []byte, _ bool, mpf bus.MetricBatchPublishFunc, _ bus.EventPublishFunc) error {
+	mpf([]data.Metric{{Name: "first"}, {Name: "second"}})
+	return nil
+}
+
+type singleHandler struct {
+	registeredHandler
+}
+
+func (sh *singleHandler) Handle(_ []byte, _ bool, mpf bus.MetricPublishFunc, _ bus.EventPublishFunc) error {
+	mpf("third", 0, data.GAUGE, 0, 0, nil, nil)
+	return nil
+}
+
+type batchApplication struct {
+	registeredApplication
+	batches chan []data.Metric
+}
+
+func (ba *batchApplication) ReceiveMetrics(metrics []data.Metric) { ba.batches <- metrics }
+func (ba *batchApplication) ReceiveMetric(string, float64, data.MetricType, time.Duration, float64, []string, []string) {
+	panic("batch receiver should not receive single metrics")
+}
+
+func TestMetricBatches(t *testing.T) {
+	originalApplications := applications
+	defer func() { applications = originalApplications }()
+	applications = map[string]application.Application{}
+
+	app := &batchApplication{batches: make(chan []data.Metric, 2)}
+	registry.RegisterApplication("batch-application", func(*logging.Logger, bus.EventPublishFunc) application.Application {
+		return app
+	})
+	_, err := InitApplication("batch-application", "", nil, filter.Config{})
+	require.NoError(t, err)
+	defer StopApplication("batch-application")
+
+	require.NoError(t, handle(&batchHandler{}, nil, false, newPublishers(nil)))
+	require.NoError(t, handle(&singleHandler{}, nil, false, newPublishers(nil)))
+	assert.Equal(t, []data.Metric{{Name: "first"}, {Name: "second"}}, <-app.batches)
+	assert.Equal(t, []data.Metric{{Name: "third", Type: data.GAUGE}}, <-app.batches)
+}
+
+type eventApplication struct {
+	registeredApplication
+	events chan data.Event
+}
+
+func (ea *eventApplication) ReceiveEvent(e data.Event) { ea.events <- e }
+
+func TestApplicationFilter(t *testing.T) {
+	originalApplications := applications
+	defer func() { applications = originalApplications }()
+	applications = map[string]application.Application{}
+
+	app := &eventApplication{events: make(chan data.Event, 2)}
+	registry.RegisterApplication("filtered-application", func(*logging.Logger, bus.EventPublishFunc) application.Application {
+		return app
+	})
+
+	conf := filter.Config{}
+	conf.Events.Type.Allow = []string{"log"}
+	_, err := InitApplication("filtered-application", "", nil, conf)
+	require.NoError(t, err)
+	eventBus.Publish(data.Event{Type: data.EVENT, Message: "event"})
+	eventBus.Publish(data.Event{Type: data.LOG, Message: "log"})
+	StopApplication("filtered-application")
+	assert.Equal(t, "log", (<-app.events).Message)
+	assert.Empty(t, app.events)
+
+	conf.Events.Type.Allow = []string{"invalid"}
+	_, err = InitApplication("filtered-application", "", nil, conf)
+	assert.Error(t, err)
+}
+
+type blockingPlugin struct {
+	exited chan struct{}
+}
+
+func (bp *blockingPlugin) Config([]byte) error { return nil }
+func (bp *blockingPlugin) Run(ctx context.Context, _ transport.WriteFn, _ chan bool) {
+	<-ctx.Done()
+	close(bp.exited)
+}
+
+type blockingApplication struct {
+	blockingPlugin
+}
+
+func (ba *blockingApplication) Run(ctx context.Context, _ chan bool) {
+	ba.blockingPlugin.Run(ctx, nil, nil)
+}
+func (ba *blockingApplication) ReceiveEvent(data.Event) {}
+
+func TestStopPlugins(t *testing.T) {
+	tmpdir, err := os.MkdirTemp(".", "manager_test_tmp")
+	require.NoError(t, err)
+	defer os.RemoveAll(tmpdir)
+
+	logpath := path.Join(tmpdir, "test.log")
+	testLogger, err := logging.NewLogger(logging.DEBUG, logpath)
+	require.NoError(t, err)
+	SetLogger(testLogger)
+
+	t.Run("stop single transport", func(t *testing.T) {
+		originalTransports := transports
+		originalHandlers := handlers
+		defer func() {
+			transports = originalTransports
+			handlers = originalHandlers
+		}()
+
+		first := &blockingPlugin{exited: make(chan struct{})}
+		second := &blockingPlugin{exited: make(chan struct{})}
+		transports = map[string]transport.Transport{"first0": first, "second1": second}
+		handlers = map[string][]handler.Handler{"first0": {&registeredHandler{}}}
+
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		wg := &sync.WaitGroup{}
+		RunTransports(ctx, wg, make(chan bool), false)
+
+		StopTransport("first0")
+		<-first.exited
+		assert.NotContains(t, transports, "first0")
+		assert.NotContains(t, handlers, "first0")
+		assert.NotContains(t, transportRuns, "first0")
+
+		select {
+		case <-second.exited:
+			t.Fatal("transport which was not stopped exited")
+		default:
+		}
+
+		// already running transport is not started again
+		RunTransports(ctx, wg, make(chan bool), false)
+		cancel()
+		wg.Wait()
+		<-second.exited
+		delete(transportRuns, "second1")
+	})
+
+	t.Run("stop single application", func(t *testing.T) {
+		originalApplications := applications
+		defer func() { applications = originalApplications }()
+		applications = map[string]application.Application{}
+
+		registry.RegisterApplication("blocking-application", func(*logging.Logger, bus.EventPublishFunc) application.Application {
+			return &blockingApplication{blockingPlugin{exited: make(chan struct{})}}
+		})
+		_, err := InitApplication("blocking-application", "", nil, filter.Config{})
+		require.NoError(t, err)
+		app := applications["blocking-application"].(*blockingApplication)
+		assert.Len(t, subscriptions["blocking-application"], 1)
+
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		wg := &sync.WaitGroup{}
+		RunApplications(ctx, wg, make(chan bool))
+
+		StopApplication("blocking-application")
+		<-app.exited
+		assert.NotContains(t, applications, "blocking-application")
+		assert.NotContains(t, applicationRuns, "blocking-application")
+		assert.NotContains(t, subscriptions, "blocking-application")
+	})
+}
+
 func TestErrAppNotReceiver(t *testing.T) {
 	t.Run("error message is correct", func(t *testing.T) {
 		assert.Equal(t, "application plugin does not implement either application.MetricReceiver or application.EventReceiver", ErrAppNotReceiver.Error())
@@ -420,3 +659,288 @@ func TestPackageInitialization(t *testing.T) {
 		assert.Empty(t, applications)
 	})
 }
+
+// flushingApplication records order of shutdown phases
+type flushingApplication struct {
+	registeredApplication
+	phases chan string
+}
+
+func (fa *flushingApplication) ReceiveEvent(e data.Event) {
+	time.Sleep(10 * time.Millisecond)
+	fa.phases <- "received " + e.Message
+}
+func (fa *flushingApplication) Flush(context.Context) error {
+	fa.phases <- "flushed"
+	return nil
+}
+func (fa *flushingApplication) Run(ctx context.Context, _ chan bool) {
+	<-ctx.Done()
+	fa.phases <- "application stopped"
+}
+
+type publishingTransport struct {
+	phases chan string
+}
+
+func (pt *publishingTransport) Config([]byte) error { return nil }
+func (pt *publishingTransport) Run(ctx context.Context, _ transport.WriteFn, _ chan bool) {
+	<-ctx.Done()
+	eventBus.Publish(data.Event{Message: "last"})
+	pt.phases <- "transport stopped"
+}
+
+func TestShutdown(t *testing.T) {
+	originalTransports := transports
+	originalApplications := applications
+	defer func() {
+		transports = originalTransports
+		applications = originalApplications
+	}()
+	SetLogger(newTestLogger(t))
+
+	phases := make(chan string, 4)
+	app := &flushingApplication{phases: phases}
+	registry.RegisterApplication("flushing-application", func(*logging.Logger, bus.EventPublishFunc) application.Application {
+		return app
+	})
+	applications = map[string]application.Application{}
+	_, err := InitApplication("flushing-application", "", nil, filter.Config{})
+	require.NoError(t, err)
+	defer StopApplication("flushing-application")
+	transports = map[string]transport.Transport{"publishing0": &publishingTransport{phases: phases}}
+	defer StopTransport("publishing0")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	wg := &sync.WaitGroup{}
+	RunTransports(ctx, wg, make(chan bool), false)
+	RunApplications(ctx, wg, make(chan bool))
+
+	Shutdown(time.Second)
+	close(phases)
+	result := []string{}
+	for phase := range phases {
+		result = append(result, phase)
+	}
+	assert.Equal(t, []string{"transport stopped", "received last", "flushed", "application stopped"}, result)
+}
+
+// leakingTransport keeps writing from goroutine which outlives Run
+type leakingTransport struct {
+	registeredTransport
+	stop chan struct{}
+}
+
+func (lt *leakingTransport) Run(ctx context.Context, w transport.WriteFn, _ chan bool) {
+	go func() {
+		for {
+			select {
+			case <-lt.stop:
+				return
+			default:
+				w([]byte("message"))
+			}
+		}
+	}()
+	<-ctx.Done()
+}
+
+// countingHandler counts handled messages, handling takes a while
+type countingHandler struct {
+	registeredHandler
+	handled atomic.Int64
+}
+
+func (ch *countingHandler) Handle([]byte, bool, bus.MetricPublishFunc, bus.EventPublishFunc) error {
+	time.Sleep(time.Millisecond)
+	ch.handled.Add(1)
+	return nil
+}
+
+func TestShutdownWaitsForWriters(t *testing.T) {
+	originalTransports := transports
+	originalHandlers := handlers
+	defer func() {
+		transports = originalTransports
+		handlers = originalHandlers
+	}()
+	SetLogger(newTestLogger(t))
+
+	lt := &leakingTransport{stop: make(chan struct{})}
+	defer close(lt.stop)
+	h := &countingHandler{}
+	transports = map[string]transport.Transport{"leaking0": lt}
+	handlers = map[string][]handler.Handler{"leaking0": {h}}
+	defer StopTransport("leaking0")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	wg := &sync.WaitGroup{}
+	RunTransports(ctx, wg, make(chan bool), false)
+	require.Eventually(t, func() bool { return h.handled.Load() > 10 }, time.Second, time.Millisecond)
+
+	Shutdown(time.Second)
+	handled := h.handled.Load()
+	time.Sleep(50 * time.Millisecond)
+	assert.Equal(t, handled, h.handled.Load(), "no messages are handled after transports are stopped")
+}
+
+// reportingHandler publishes internal metric from Run and error event for each message
+type reportingHandler struct {
+	registeredHandler
+}
+
+func (rh *reportingHandler) Run(ctx context.Context, mpf bus.MetricPublishFunc, _ bus.EventPublishFunc) {
+	mpf("sg_total_reporting_msg_received_count", 0, data.COUNTER, 0, 1, []string{"source"}, []string{"SG"})
+}
+
+func (rh *reportingHandler) Handle(msg []byte, _ bool, _ bus.MetricPublishFunc, epf bus.EventPublishFunc) error {
+	epf(data.Event{Index: "reporting", Type: data.ERROR, Labels: map[string]interface{}{"error": string(msg)}})
+	return nil
+}
+
+func TestInstanceNames(t *testing.T) {
+	originalTransports := transports
+	originalHandlers := handlers
+	originalApplications := applications
+	originalStatuses := statuses
+	defer func() {
+		transports = originalTransports
+		handlers = originalHandlers
+		applications = originalApplications
+		statuses = originalStatuses
+	}()
+	transports = map[string]transport.Transport{}
+	handlers = map[string][]handler.Handler{}
+	applications = map[string]application.Application{}
+	statuses = map[string]*statusEntry{}
+	SetLogger(newTestLogger(t))
+
+	registry.RegisterTransport("named-transport", func(*logging.Logger) transport.Transport {
+		return &writingTransport{messages: []string{"failure"}}
+	})
+	registry.RegisterHandler("named-handler", func() handler.Handler { return &reportingHandler{} })
+	app := &receivingApplication{metrics: make(chan []data.Metric, 10), events: make(chan data.Event, 10)}
+	registry.RegisterApplication("named-application", func(*logging.Logger, bus.EventPublishFunc) application.Application {
+		return app
+	})
+
+	t.Run("instance names are used", func(t *testing.T) {
+		name, err := InitTransport("named-transport", "edge", nil)
+		require.NoError(t, err)
+		assert.Equal(t, "edge", name)
+		defer StopTransport(name)
+		require.NoError(t, SetTransportHandlers(name, []PluginBlock{{Name: "named-handler", Instance: "edge-events"}}))
+
+		aName, err := InitApplication("named-application", "storage", nil, filter.Config{})
+		require.NoError(t, err)
+		assert.Equal(t, "storage", aName)
+		defer StopApplication(aName)
+
+		instances := map[string]string{}
+		for _, st := range Status() {
+			instances[st.Instance] = st.Type
+		}
+		assert.Equal(t, map[string]string{"edge": transportType, "edge-events": handlerType, "storage": applicationType}, instances)
+	})
+
+	t.Run("duplicate instance names", func(t *testing.T) {
+		_, err := InitTransport("named-transport", "edge", nil)
+		require.NoError(t, err)
+		defer StopTransport("edge")
+		require.NoError(t, SetTransportHandlers("edge", []PluginBlock{{Name: "named-handler", Instance: "edge-events"}}))
+
+		_, err = InitTransport("named-transport", "edge", nil)
+		assert.True(t, errors.Is(err, ErrInstanceExists))
+		err = SetTransportHandlers("edge", []PluginBlock{{Name: "named-handler", Instance: "edge-events"}})
+		assert.True(t, errors.Is(err, ErrInstanceExists))
+
+		_, err = InitApplication("named-application", "storage", nil, filter.Config{})
+		require.NoError(t, err)
+		defer StopApplication("storage")
+		_, err = InitApplication("named-application", "storage", nil, filter.Config{})
+		assert.True(t, errors.Is(err, ErrInstanceExists))
+	})
+
+	t.Run("internal metrics and error events are labeled", func(t *testing.T) {
+		_, err := InitTransport("named-transport", "edge", nil)
+		require.NoError(t, err)
+		defer StopTransport("edge")
+		require.NoError(t, SetTransportHandlers("edge", []PluginBlock{{Name: "named-handler", Instance: "edge-events"}}))
+		_, err = InitApplication("named-application", "storage", nil, filter.Config{})
+		require.NoError(t, err)
+		defer StopApplication("storage")
+
+		ctx, cancel := context.WithCancel(context.Background())
+		wg := &sync.WaitGroup{}
+		RunTransports(ctx, wg, make(chan bool), true)
+		defer func() {
+			cancel()
+			wg.Wait()
+		}()
+
+		metrics := <-app.metrics
+		assert.Equal(t, []string{"source", "instance"}, metrics[0].LabelKeys)
+		assert.Equal(t, []string{"SG", "edge-events"}, metrics[0].LabelVals)
+		event := <-app.events
+		assert.Equal(t, map[string]interface{}{"error": "failure", "instance": "edge-events"}, event.Labels)
+	})
+}
+
+// countingApplication counts runs and received events of each instance
+type countingApplication struct {
+	registeredApplication
+	runs   chan string
+	events chan string
+	name   string
+}
+
+func (ca *countingApplication) Config(c []byte) error {
+	return yaml.Unmarshal(c, &ca.name)
+}
+
+func (ca *countingApplication) Run(ctx context.Context, _ chan bool) {
+	ca.runs <- ca.name
+	<-ctx.Done()
+}
+
+func (ca *countingApplication) ReceiveEvent(data.Event) { ca.events <- ca.name }
+
+func TestMultipleApplicationInstances(t *testing.T) {
+	originalApplications := applications
+	defer func() { applications = originalApplications }()
+	applications = map[string]application.Application{}
+	SetLogger(newTestLogger(t))
+
+	runs := make(chan string, 10)
+	events := make(chan string, 10)
+	registry.RegisterApplication("counting-application", func(*logging.Logger, bus.EventPublishFunc) application.Application {
+		return &countingApplication{runs: runs, events: events}
+	})
+
+	names := []string{}
+	for _, conf := range []string{"logs", "events", "alerts"} {
+		instance := ""
+		if conf == "alerts" {
+			instance = "alerting"
+		}
+		name, err := InitApplication("counting-application", instance, conf, filter.Config{})
+		require.NoError(t, err)
+		defer StopApplication(name)
+		names = append(names, name)
+	}
+	assert.Equal(t, []string{"counting-application", "counting-application1", "alerting"}, names)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	wg := &sync.WaitGroup{}
+	RunApplications(ctx, wg, make(chan bool))
+	defer func() {
+		cancel()
+		wg.Wait()
+	}()
+	assert.ElementsMatch(t, []string{"logs", "events", "alerts"}, []string{<-runs, <-runs, <-runs})
+
+	eventBus.Publish(data.Event{Index: "test"})
+	assert.ElementsMatch(t, []string{"logs", "events", "alerts"}, []string{<-events, <-events, <-events})
+}
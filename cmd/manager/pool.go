@@ -0,0 +1,137 @@
+package manager
+
+import (
+	"sync"
+	"sync/atomic"
+
+	"github.com/infrawatch/sg-core/pkg/bus"
+	"github.com/infrawatch/sg-core/pkg/transport"
+)
+
+// WorkerPool configuration of workers passing messages received by transport to its handlers through
+// a bounded queue. Messages are handled in goroutine of the transport when no workers are configured
+type WorkerPool struct {
+	Workers   int    `yaml:"workers" validate:"min=0"`                                        // order of messages is kept only with single worker
+	QueueSize int    `yaml:"queueSize" validate:"min=0"`                                      // maximum number of messages waiting for workers
+	Overflow  string `yaml:"overflow" validate:"omitempty,oneof=block dropNewest dropOldest"` // default block slows down the transport
+}
+
+// WorkerQueueStatus statistics of transport's worker pool queue
+type WorkerQueueStatus struct {
+	Workers int    `json:"workers"`
+	Depth   int    `json:"depth"`
+	Dropped uint64 `json:"dropped"`
+}
+
+var (
+	workerPools     = map[string]WorkerPool{}
+	workerPoolsLock sync.Mutex
+)
+
+// SetTransportWorkerPool set worker pool of loaded transport, it is used when the transport is run
+func SetTransportWorkerPool(name string, pool WorkerPool) {
+	workerPoolsLock.Lock()
+	defer workerPoolsLock.Unlock()
+	workerPools[name] = pool
+}
+
+func transportWorkerPool(name string) WorkerPool {
+	workerPoolsLock.Lock()
+	defer workerPoolsLock.Unlock()
+	return workerPools[name]
+}
+
+func deleteWorkerPool(name string) {
+	workerPoolsLock.Lock()
+	defer workerPoolsLock.Unlock()
+	delete(workerPools, name)
+}
+
+// messageQueue bounded queue of messages served by fixed number of workers
+type messageQueue struct {
+	messages chan []byte
+	workers  int
+	policy   bus.OverflowPolicy
+	dropped  atomic.Uint64
+	wg       sync.WaitGroup
+	lock     sync.RWMutex // pushing holds read lock, so that messages channel is not closed under it
+	closed   bool
+}
+
+func newMessageQueue(pool WorkerPool, handle transport.WriteFn) *messageQueue {
+	size := pool.QueueSize
+	if size <= 0 {
+		size = bus.DefaultQueueSize
+	}
+	q := &messageQueue{
+		messages: make(chan []byte, size),
+		workers:  pool.Workers,
+		policy:   bus.Block,
+	}
+	if pool.Overflow != "" {
+		q.policy = bus.OverflowPolicyFromString(pool.Overflow)
+	}
+	for i := 0; i < pool.Workers; i++ {
+		q.wg.Add(1)
+		go func() {
+			defer q.wg.Done()
+			for blob := range q.messages {
+				handle(blob)
+			}
+		}()
+	}
+	return q
+}
+
+// push queues copy of the message, transports are allowed to reuse the message once write function returns.
+// Messages written after the queue is closed, eg. by misbehaving transport after its Run returned, are dropped
+func (q *messageQueue) push(msg []byte) {
+	blob := make([]byte, len(msg))
+	copy(blob, msg)
+	q.lock.RLock()
+	defer q.lock.RUnlock()
+	if q.closed {
+		q.dropped.Add(1)
+		return
+	}
+	switch q.policy {
+	case bus.Block:
+		q.messages <- blob
+	case bus.DropOldest:
+		for {
+			select {
+			case q.messages <- blob:
+				return
+			default:
+			}
+			select {
+			case <-q.messages:
+				q.dropped.Add(1)
+			default:
+			}
+		}
+	default:
+		select {
+		case q.messages <- blob:
+		default:
+			q.dropped.Add(1)
+		}
+	}
+}
+
+// close stops accepting messages and waits until workers handle all queued messages
+func (q *messageQueue) close() {
+	q.lock.Lock()
+	q.closed = true
+	close(q.messages)
+	q.lock.Unlock()
+	q.wg.Wait()
+}
+
+func (q *messageQueue) stats() *WorkerQueueStatus {
+	return &WorkerQueueStatus{
+		Workers: q.workers,
+		Depth:   len(q.messages),
+		Dropped: q.dropped.Load(),
+	}
+}
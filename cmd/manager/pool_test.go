@@ -0,0 +1,115 @@
+package manager
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/infrawatch/sg-core/pkg/bus"
+	"github.com/infrawatch/sg-core/pkg/handler"
+	"github.com/infrawatch/sg-core/pkg/transport"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// blockingHandler reports received messages and blocks until it is released
+type blockingHandler struct {
+	registeredHandler
+	received chan string
+	release  chan struct{}
+}
+
+func (bh *blockingHandler) Handle(msg []byte, _ bool, _ bus.MetricPublishFunc, _ bus.EventPublishFunc) error {
+	bh.received <- string(msg)
+	<-bh.release
+	return nil
+}
+
+// reusingTransport writes all messages from the same buffer
+type reusingTransport struct {
+	registeredTransport
+	messages []string
+}
+
+func (rt *reusingTransport) Run(ctx context.Context, w transport.WriteFn, _ chan bool) {
+	buf := make([]byte, 16)
+	for _, msg := range rt.messages {
+		n := copy(buf, msg)
+		w(buf[:n])
+	}
+	<-ctx.Done()
+}
+
+func TestMessageQueue(t *testing.T) {
+	for _, tc := range []struct {
+		overflow string
+		kept     string
+	}{
+		{"dropNewest", "b"},
+		{"dropOldest", "c"},
+	} {
+		t.Run(tc.overflow, func(t *testing.T) {
+			h := &blockingHandler{received: make(chan string, 3), release: make(chan struct{})}
+			q := newMessageQueue(WorkerPool{Workers: 1, QueueSize: 1, Overflow: tc.overflow}, func(blob []byte) {
+				_ = h.Handle(blob, false, nil, nil)
+			})
+			q.push([]byte("a"))
+			assert.Equal(t, "a", <-h.received)
+			q.push([]byte("b"))
+			q.push([]byte("c"))
+			assert.Equal(t, &WorkerQueueStatus{Workers: 1, Depth: 1, Dropped: 1}, q.stats())
+
+			close(h.release)
+			q.close()
+			assert.Equal(t, tc.kept, <-h.received)
+		})
+	}
+	t.Run("push after close", func(t *testing.T) {
+		handled := 0
+		q := newMessageQueue(WorkerPool{Workers: 1, QueueSize: 1}, func([]byte) { handled++ })
+		q.push([]byte("a"))
+		q.close()
+		assert.NotPanics(t, func() { q.push([]byte("late")) })
+		assert.Equal(t, 1, handled)
+		assert.Equal(t, uint64(1), q.stats().Dropped)
+	})
+}
+
+func TestWorkerPool(t *testing.T) {
+	originalTransports := transports
+	originalHandlers := handlers
+	defer func() {
+		transports = originalTransports
+		handlers = originalHandlers
+	}()
+	SetLogger(newTestLogger(t))
+
+	h := &blockingHandler{received: make(chan string, 3), release: make(chan struct{})}
+	transports = map[string]transport.Transport{"reusing0": &reusingTransport{messages: []string{"first", "second", "third"}}}
+	handlers = map[string][]handler.Handler{"reusing0": {h}}
+	trackPlugin(transportType, "reusing", "reusing0", transports["reusing0"])
+	SetTransportWorkerPool("reusing0", WorkerPool{Workers: 2, QueueSize: 4})
+	defer StopTransport("reusing0")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	wg := &sync.WaitGroup{}
+	RunTransports(ctx, wg, make(chan bool), false)
+
+	// both workers are blocked in handler at the same time
+	received := []string{<-h.received, <-h.received}
+	assert.ElementsMatch(t, []string{"first", "second"}, received, "queued messages are copies of transport buffer")
+	require.Eventually(t, func() bool {
+		for _, st := range Status() {
+			if st.Instance == "reusing0" {
+				return assert.ObjectsAreEqual(&WorkerQueueStatus{Workers: 2, Depth: 1}, st.WorkerQueue)
+			}
+		}
+		return false
+	}, time.Second, 10*time.Millisecond)
+
+	close(h.release)
+	assert.Equal(t, "third", <-h.received)
+	cancel()
+	wg.Wait()
+}
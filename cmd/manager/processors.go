@@ -0,0 +1,283 @@
+package manager
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"sync/atomic"
+
+	"github.com/infrawatch/apputils/logging"
+	"github.com/infrawatch/sg-core/pkg/bus"
+	"github.com/infrawatch/sg-core/pkg/data"
+	"github.com/infrawatch/sg-core/pkg/pluginlog"
+	"github.com/infrawatch/sg-core/pkg/processor"
+	"github.com/infrawatch/sg-core/pkg/registry"
+	"github.com/pkg/errors"
+	"gopkg.in/yaml.v2"
+)
+
+// processors are loaded in chains. Each transport can have its own chain applied to data
+// published by its handlers. Global chain is applied to all data published to the buses,
+// so it runs after chains of transports and before data reach applications
+
+// ErrProcessorNoHook returned if processor plugin implements neither processor.MetricProcessor nor processor.EventProcessor
+var ErrProcessorNoHook = errors.New("processor plugin does not implement either processor.MetricProcessor or processor.EventProcessor")
+
+// globalChainName owner of global processor chain used in instance names of its processors
+const globalChainName = "global"
+
+var (
+	transportChains = map[string]*chain{}
+	globalChain     atomic.Pointer[chain]
+)
+
+type loadedProcessor struct {
+	processor.Processor
+	name     string
+	instance string
+	config   interface{}
+	logger   *logging.Logger
+}
+
+// chain of processors applied in order
+type chain struct {
+	processors []loadedProcessor
+	run        *runState
+}
+
+// newChain creates and configures processors of a chain owned by given transport or global chain.
+// Instance names of processors of the replaced chain can be reused by the new chain
+func newChain(owner string, blocks []PluginBlock, replaced *chain) (*chain, error) {
+	c := &chain{}
+	for i, block := range blocks {
+		instance := block.Instance
+		if instance == "" {
+			instance = fmt.Sprintf("%s%d[%s]", block.Name, i, owner)
+		} else if c.has(instance) || (tracked(processorType, instance) && !replaced.has(instance)) {
+			c.release()
+			return nil, errors.Wrapf(ErrInstanceExists, "processor '%s'", instance)
+		}
+		l := pluginlog.Copy(logger)
+		pluginlog.Register(l, pluginlog.Identity{Type: processorType, Name: block.Name, Instance: instance})
+		c.processors = append(c.processors, loadedProcessor{name: block.Name, instance: instance, config: block.Config, logger: l})
+
+		p, err := newProcessor(block.Name, block.Config, l)
+		if err != nil {
+			c.release()
+			return nil, err
+		}
+		c.processors[i].Processor = p
+	}
+	return c, nil
+}
+
+// has returns true if the chain contains processor instance of given name
+func (c *chain) has(instance string) bool {
+	if c == nil {
+		return false
+	}
+	for _, p := range c.processors {
+		if p.instance == instance {
+			return true
+		}
+	}
+	return false
+}
+
+// track starts reporting status of processors in the chain
+func (c *chain) track() {
+	for _, p := range c.processors {
+		trackPlugin(processorType, p.name, p.instance, p.Processor)
+		trackConfig(processorType, p.instance, p.config)
+	}
+}
+
+// release stops reporting status of processors in the chain
+func (c *chain) release() {
+	for _, p := range c.processors {
+		untrackPlugin(processorType, p.instance)
+		pluginlog.Unregister(p.logger)
+	}
+}
+
+// start spawns processors implementing processor.Runner
+func (c *chain) start(ctx context.Context, wg *sync.WaitGroup) {
+	c.run = newRunState(ctx)
+	for _, p := range c.processors {
+		setState(processorType, p.instance, StateRunning, nil)
+		if r, ok := p.Processor.(processor.Runner); ok {
+			c.run.spawn(wg, r.Run)
+		}
+	}
+}
+
+func (c *chain) stop() {
+	if c.run != nil {
+		c.run.stop()
+	}
+	c.setState(StateExited)
+}
+
+func (c *chain) setState(state State) {
+	for _, p := range c.processors {
+		setState(processorType, p.instance, state, nil)
+	}
+}
+
+// metrics passes batch of metrics through the chain
+func (c *chain) metrics(metrics []data.Metric) []data.Metric {
+	if c == nil {
+		return metrics
+	}
+	for _, p := range c.processors {
+		if len(metrics) == 0 {
+			return nil
+		}
+		if mp, ok := p.Processor.(processor.MetricProcessor); ok {
+			metrics = mp.ProcessMetrics(metrics)
+		}
+	}
+	return metrics
+}
+
+// events passes event through the chain
+func (c *chain) events(event data.Event) []data.Event {
+	events := []data.Event{event}
+	if c == nil {
+		return events
+	}
+	for _, p := range c.processors {
+		ep, ok := p.Processor.(processor.EventProcessor)
+		if !ok {
+			continue
+		}
+		processed := []data.Event{}
+		for _, e := range events {
+			processed = append(processed, ep.ProcessEvent(e)...)
+		}
+		events = processed
+	}
+	return events
+}
+
+// publishMetrics passes metrics through global processor chain and publishes them to metric bus
+func publishMetrics(metrics []data.Metric) {
+	metricBus.PublishBatch(globalChain.Load().metrics(metrics))
+}
+
+// publishEvent passes event through global processor chain and publishes result to event bus
+func publishEvent(event data.Event) {
+	for _, e := range globalChain.Load().events(event) {
+		eventBus.Publish(e)
+	}
+}
+
+// publishers functions publishing data through processor chain of a transport
+type publishers struct {
+	metrics bus.MetricBatchPublishFunc
+	metric  bus.MetricPublishFunc
+	event   bus.EventPublishFunc
+}
+
+func newPublishers(c *chain) publishers {
+	pub := publishers{
+		metrics: publishMetrics,
+		event:   publishEvent,
+	}
+	if c != nil && len(c.processors) > 0 {
+		pub.metrics = func(metrics []data.Metric) {
+			publishMetrics(c.metrics(metrics))
+		}
+		pub.event = func(event data.Event) {
+			for _, e := range c.events(event) {
+				publishEvent(e)
+			}
+		}
+	}
+	pub.metric = pub.metrics.Single()
+	return pub
+}
+
+// SetTransportProcessors load processors applied to data published by handlers of given transport
+func SetTransportProcessors(name string, blocks []PluginBlock) error {
+	c, err := newChain(name, blocks, nil)
+	if err != nil {
+		return err
+	}
+	c.track()
+	transportChains[name] = c
+	return nil
+}
+
+// SetProcessors load global processor chain applied to all data before they reach applications.
+// Current global chain is replaced and stopped, new chain is started by RunProcessors
+func SetProcessors(blocks []PluginBlock) error {
+	c, err := newChain(globalChainName, blocks, globalChain.Load())
+	if err != nil {
+		return err
+	}
+	if old := globalChain.Swap(c); old != nil {
+		old.stop()
+		old.release()
+	}
+	c.track()
+	return nil
+}
+
+// RunProcessors starts global processor chain if it is not running yet
+func RunProcessors(ctx context.Context, wg *sync.WaitGroup) {
+	if c := globalChain.Load(); c != nil && c.run == nil {
+		c.start(ctx, wg)
+	}
+}
+
+// stopProcessors stops and unloads global processor chain
+func stopProcessors() {
+	if c := globalChain.Swap(nil); c != nil {
+		c.stop()
+		c.release()
+	}
+}
+
+// newProcessor creates processor plugin and configures it
+func newProcessor(name string, config interface{}, l *logging.Logger) (processor.Processor, error) {
+	new, err := processorConstructor(name)
+	if err != nil {
+		return nil, err
+	}
+	p := new(l)
+
+	c, err := yaml.Marshal(config)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed parsing processor plugin config for '%s'", name)
+	}
+
+	err = p.Config(c)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed configuring processor plugin '%s'", name)
+	}
+
+	_, metricHook := p.(processor.MetricProcessor)
+	_, eventHook := p.(processor.EventProcessor)
+	if !(metricHook || eventHook) {
+		return nil, ErrProcessorNoHook
+	}
+	return p, nil
+}
+
+func processorConstructor(name string) (func(*logging.Logger) processor.Processor, error) {
+	if new, ok := registry.Processor(name); ok {
+		return new, nil
+	}
+
+	n, err := initPlugin(name)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed initializing processor plugin")
+	}
+
+	new, ok := n.(func(*logging.Logger) processor.Processor)
+	if !ok {
+		return nil, fmt.Errorf("plugin %s constructor 'New' did not return type 'processor.Processor'", name)
+	}
+	return new, nil
+}
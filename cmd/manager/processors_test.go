@@ -0,0 +1,187 @@
+package manager
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/infrawatch/apputils/logging"
+	"github.com/infrawatch/sg-core/pkg/application"
+	"github.com/infrawatch/sg-core/pkg/bus"
+	"github.com/infrawatch/sg-core/pkg/data"
+	"github.com/infrawatch/sg-core/pkg/filter"
+	"github.com/infrawatch/sg-core/pkg/handler"
+	"github.com/infrawatch/sg-core/pkg/processor"
+	"github.com/infrawatch/sg-core/pkg/registry"
+	"github.com/infrawatch/sg-core/pkg/transport"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v2"
+)
+
+// prefixProcessor prefixes names of metrics and indexes of events, events are fanned out when configured
+type prefixProcessor struct {
+	Prefix string `yaml:"prefix"`
+	FanOut bool   `yaml:"fanOut"`
+	runs   chan string
+}
+
+func (pp *prefixProcessor) Config(c []byte) error {
+	return yaml.Unmarshal(c, pp)
+}
+
+func (pp *prefixProcessor) ProcessMetrics(metrics []data.Metric) []data.Metric {
+	res := []data.Metric{}
+	for _, m := range metrics {
+		if m.Name == "drop" {
+			continue
+		}
+		m.Name = pp.Prefix + m.Name
+		res = append(res, m)
+	}
+	return res
+}
+
+func (pp *prefixProcessor) ProcessEvent(e data.Event) []data.Event {
+	e.Index = pp.Prefix + e.Index
+	if pp.FanOut {
+		return []data.Event{e, e}
+	}
+	return []data.Event{e}
+}
+
+func (pp *prefixProcessor) Run(ctx context.Context) {
+	pp.runs <- "started " + pp.Prefix
+	<-ctx.Done()
+	pp.runs <- "stopped " + pp.Prefix
+}
+
+type hooklessProcessor struct{}
+
+func (hp *hooklessProcessor) Config([]byte) error { return nil }
+
+// publishingHandler publishes metric and event for each message
+type publishingHandler struct {
+	registeredHandler
+}
+
+func (ph *publishingHandler) Handle(msg []byte, _ bool, mpf bus.MetricPublishFunc, epf bus.EventPublishFunc) error {
+	mpf(string(msg), 0, data.GAUGE, 0, 0, nil, nil)
+	epf(data.Event{Index: string(msg)})
+	return nil
+}
+
+type receivingApplication struct {
+	registeredApplication
+	metrics chan []data.Metric
+	events  chan data.Event
+}
+
+func (ra *receivingApplication) ReceiveMetrics(metrics []data.Metric) { ra.metrics <- metrics }
+func (ra *receivingApplication) ReceiveEvent(e data.Event)            { ra.events <- e }
+
+func TestProcessors(t *testing.T) {
+	originalTransports := transports
+	originalHandlers := handlers
+	originalApplications := applications
+	defer func() {
+		transports = originalTransports
+		handlers = originalHandlers
+		applications = originalApplications
+	}()
+	SetLogger(newTestLogger(t))
+
+	runs := make(chan string, 10)
+	registry.RegisterProcessor("prefix", func(*logging.Logger) processor.Processor { return &prefixProcessor{runs: runs} })
+	registry.RegisterProcessor("hookless", func(*logging.Logger) processor.Processor { return &hooklessProcessor{} })
+
+	app := &receivingApplication{metrics: make(chan []data.Metric, 10), events: make(chan data.Event, 10)}
+	registry.RegisterApplication("receiving-application", func(*logging.Logger, bus.EventPublishFunc) application.Application {
+		return app
+	})
+	applications = map[string]application.Application{}
+	_, err := InitApplication("receiving-application", "", nil, filter.Config{})
+	require.NoError(t, err)
+	defer StopApplication("receiving-application")
+
+	blocks := func(prefixes ...string) []PluginBlock {
+		res := []PluginBlock{}
+		for _, prefix := range prefixes {
+			res = append(res, PluginBlock{Name: "prefix", Config: map[string]interface{}{"prefix": prefix, "fanOut": prefix == "transport."}})
+		}
+		return res
+	}
+
+	messages := []string{"cpu", "drop"}
+	transports = map[string]transport.Transport{"writing0": &writingTransport{messages: messages}}
+	handlers = map[string][]handler.Handler{"writing0": {&publishingHandler{}}}
+	require.NoError(t, SetTransportProcessors("writing0", blocks("transport.")))
+	require.NoError(t, SetProcessors(blocks("global.")))
+	defer StopTransport("writing0")
+	defer stopProcessors()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	wg := &sync.WaitGroup{}
+	RunProcessors(ctx, wg)
+	RunTransports(ctx, wg, make(chan bool), false)
+
+	t.Run("chains are applied in order", func(t *testing.T) {
+		assert.Equal(t, []data.Metric{{Name: "global.transport.cpu", Type: data.GAUGE}}, <-app.metrics)
+		for _, index := range []string{"global.transport.cpu", "global.transport.cpu", "global.transport.drop", "global.transport.drop"} {
+			assert.Equal(t, index, (<-app.events).Index)
+		}
+		assert.Empty(t, app.metrics, "dropped metric is not published")
+
+		// events published by applications pass global chain only
+		publishEvent(data.Event{Index: "application"})
+		assert.Equal(t, "global.application", (<-app.events).Index)
+	})
+
+	t.Run("processors are tracked and run", func(t *testing.T) {
+		assert.ElementsMatch(t, []string{"started transport.", "started global."}, []string{<-runs, <-runs})
+		instances := []string{}
+		for _, st := range Status() {
+			if st.Type == processorType {
+				assert.Equal(t, StateRunning, st.State)
+				instances = append(instances, st.Instance)
+			}
+		}
+		assert.Equal(t, []string{"prefix0[global]", "prefix0[writing0]"}, instances)
+	})
+
+	t.Run("global chain is replaced", func(t *testing.T) {
+		require.NoError(t, SetProcessors(blocks("new.")))
+		assert.Equal(t, "stopped global.", <-runs)
+		RunProcessors(ctx, wg)
+		assert.Equal(t, "started new.", <-runs)
+
+		publishMetrics([]data.Metric{{Name: "metric"}})
+		assert.Equal(t, []data.Metric{{Name: "new.metric"}}, <-app.metrics)
+	})
+
+	t.Run("invalid processors", func(t *testing.T) {
+		err := SetProcessors([]PluginBlock{{Name: "hookless"}})
+		assert.Equal(t, ErrProcessorNoHook, err)
+		assert.Error(t, ValidateProcessor("unknown-processor", nil))
+
+		publishMetrics([]data.Metric{{Name: "metric"}})
+		assert.Equal(t, []data.Metric{{Name: "new.metric"}}, <-app.metrics, "failed load keeps current chain")
+	})
+
+	cancel()
+	select {
+	case <-waitGroupDone(wg):
+	case <-time.After(time.Second):
+		t.Fatal("processors did not stop")
+	}
+}
+
+func waitGroupDone(wg *sync.WaitGroup) chan struct{} {
+	done := make(chan struct{})
+	go func() {
+		wg.Wait()
+		close(done)
+	}()
+	return done
+}
@@ -0,0 +1,119 @@
+package manager
+
+import (
+	"sync"
+	"sync/atomic"
+
+	"github.com/infrawatch/apputils/logging"
+	"github.com/infrawatch/sg-core/pkg/bus"
+	"github.com/infrawatch/sg-core/pkg/data"
+	"github.com/infrawatch/sg-core/pkg/filter"
+	"github.com/infrawatch/sg-core/pkg/transport"
+)
+
+// transports implementing transport.Sender are subscribed to the event bus while they run and
+// receive TASK events addressed to them. Transports implementing transport.Forwarder are also
+// subscribed to buses they forward. Sending can block, so tasks and forwarded data are passed from
+// bus workers to the transport through its own bounded queue, they are dropped when the queue is full
+
+// taskQueueSize maximum number of tasks waiting to be sent by a transport
+const taskQueueSize = bus.DefaultQueueSize
+
+var senderSubscriptions = map[string][]subscription{} // transport -> subscriptions to buses
+
+// taskQueue bounded queue of tasks run by single goroutine, senders are not required to be safe
+// for concurrent use
+type taskQueue struct {
+	tasks   chan func() error
+	dropped atomic.Uint64
+	wg      sync.WaitGroup
+}
+
+func newTaskQueue(name string) *taskQueue {
+	q := &taskQueue{tasks: make(chan func() error, taskQueueSize)}
+	q.wg.Add(1)
+	go func() {
+		defer q.wg.Done()
+		for task := range q.tasks {
+			if err := task(); err != nil {
+				log.Warn("failed sending task", logging.Metadata{"error": err, "transport": name})
+				setLastError(transportType, name, err)
+			}
+		}
+	}()
+	return q
+}
+
+// push queues task without blocking the bus worker
+func (q *taskQueue) push(task func() error) {
+	select {
+	case q.tasks <- task:
+	default:
+		q.dropped.Add(1)
+	}
+}
+
+// close stops accepting tasks and waits until all queued tasks are run
+func (q *taskQueue) close() {
+	close(q.tasks)
+	q.wg.Wait()
+}
+
+// subscribeSender subscribes transport to TASK events addressed to it, if the transport is able to send them,
+// and to data it forwards
+func subscribeSender(name string, t transport.Transport) {
+	s, ok := t.(transport.Sender)
+	if !ok {
+		return
+	}
+	if _, ok := senderSubscriptions[name]; ok {
+		return
+	}
+
+	var fwd transport.Forwarder
+	var f *filter.Filter
+	conf := transport.ForwardConfig{}
+	if fw, ok := t.(transport.Forwarder); ok {
+		var err error
+		conf = fw.Forward()
+		f, err = filter.New(conf.Filter)
+		if err != nil {
+			log.Error("failed parsing forward filter, data are not forwarded", logging.Metadata{"error": err, "transport": name})
+			setLastError(transportType, name, err)
+			conf = transport.ForwardConfig{}
+		}
+		fwd = fw
+	}
+
+	q := newTaskQueue(name)
+	subs := []subscription{}
+	// metric bus subscription is cancelled first, queue is closed with the event bus subscription
+	if conf.Metrics {
+		id := metricBus.SubscribeBatch(filterMetrics(f, func(metrics []data.Metric) {
+			q.push(func() error { return fwd.ForwardMetrics(metrics) })
+		}))
+		subs = append(subs, subscription{bus: metricBusName, id: id})
+	}
+	id := eventBus.Subscribe(func(e data.Event) {
+		if target, ok := e.Labels[transport.TaskTransportLabel].(string); ok && target == name && e.Type == data.TASK {
+			q.push(func() error { return s.Send(e) })
+			return
+		}
+		if conf.Events && f.Event(e) {
+			q.push(func() error { return fwd.ForwardEvent(e) })
+		}
+	})
+	subs = append(subs, subscription{bus: eventBusName, id: id, tasks: q})
+	senderSubscriptions[name] = subs
+	trackSubscriptions(transportType, name, subs)
+}
+
+// unsubscribeSender unsubscribes transport from buses, tasks already queued for the transport are sent before return
+func unsubscribeSender(name string) {
+	if subs, ok := senderSubscriptions[name]; ok {
+		for _, sub := range subs {
+			sub.unsubscribe()
+		}
+		delete(senderSubscriptions, name)
+	}
+}
@@ -0,0 +1,238 @@
+package manager
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/infrawatch/sg-core/pkg/data"
+	"github.com/infrawatch/sg-core/pkg/handler"
+	"github.com/infrawatch/sg-core/pkg/transport"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// sendingTransport reports messages of tasks it was asked to send
+type sendingTransport struct {
+	registeredTransport
+	sent    chan string
+	release chan bool // task "block" is sent once closed
+}
+
+func (st *sendingTransport) Run(ctx context.Context, _ transport.WriteFn, _ chan bool) {
+	<-ctx.Done()
+}
+
+func (st *sendingTransport) Send(e data.Event) error {
+	st.sent <- e.Message
+	if e.Message == "block" {
+		<-st.release
+	}
+	if e.Message == "fail" {
+		return errors.New("send failed")
+	}
+	return nil
+}
+
+func TestSenders(t *testing.T) {
+	originalTransports := transports
+	originalHandlers := handlers
+	defer func() {
+		transports = originalTransports
+		handlers = originalHandlers
+	}()
+	SetLogger(newTestLogger(t))
+
+	st := &sendingTransport{sent: make(chan string, 4)}
+	transports = map[string]transport.Transport{
+		"sending0":    st,
+		"registered0": &registeredTransport{},
+	}
+	handlers = map[string][]handler.Handler{}
+	trackPlugin(transportType, "sending", "sending0", transports["sending0"])
+	trackPlugin(transportType, "registered", "registered0", transports["registered0"])
+	defer StopTransport("registered0")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	wg := &sync.WaitGroup{}
+	RunTransports(ctx, wg, make(chan bool), false)
+	require.Contains(t, senderSubscriptions, "sending0")
+	assert.NotContains(t, senderSubscriptions, "registered0", "transport without Sender is not subscribed")
+
+	task := func(target string, msg string) data.Event {
+		return data.Event{Type: data.TASK, Message: msg, Labels: map[string]interface{}{transport.TaskTransportLabel: target}}
+	}
+	eventBus.Publish(data.Event{Type: data.EVENT, Message: "event", Labels: map[string]interface{}{transport.TaskTransportLabel: "sending0"}})
+	eventBus.Publish(task("registered0", "other transport"))
+	eventBus.Publish(task("sending0", "fail"))
+	eventBus.Publish(task("sending0", "ok"))
+
+	assert.Equal(t, "fail", <-st.sent)
+	assert.Equal(t, "ok", <-st.sent)
+	for _, s := range Status() {
+		if s.Instance == "sending0" {
+			assert.Equal(t, "send failed", s.LastError)
+			assert.Len(t, s.Queues, 1)
+		}
+	}
+
+	StopTransport("sending0")
+	assert.NotContains(t, senderSubscriptions, "sending0")
+	eventBus.Publish(task("sending0", "after stop"))
+	select {
+	case msg := <-st.sent:
+		t.Errorf("stopped transport was asked to send %q", msg)
+	case <-time.After(50 * time.Millisecond):
+	}
+	cancel()
+	wg.Wait()
+}
+
+func TestShutdownSenders(t *testing.T) {
+	originalTransports := transports
+	originalHandlers := handlers
+	defer func() {
+		transports = originalTransports
+		handlers = originalHandlers
+	}()
+	SetLogger(newTestLogger(t))
+
+	st := &sendingTransport{sent: make(chan string, 4), release: make(chan bool)}
+	transports = map[string]transport.Transport{"sending0": st}
+	handlers = map[string][]handler.Handler{}
+	trackPlugin(transportType, "sending", "sending0", st)
+	defer StopTransport("sending0")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	wg := &sync.WaitGroup{}
+	RunTransports(ctx, wg, make(chan bool), false)
+	require.Len(t, senderSubscriptions["sending0"], 1)
+	q := senderSubscriptions["sending0"][0].tasks
+
+	task := func(msg string) data.Event {
+		return data.Event{Type: data.TASK, Message: msg, Labels: map[string]interface{}{transport.TaskTransportLabel: "sending0"}}
+	}
+	eventBus.Publish(task("block"))
+	require.Equal(t, "block", <-st.sent)
+	eventBus.Publish(task("queued"))
+	close(st.release)
+
+	Shutdown(time.Second)
+	assert.Empty(t, senderSubscriptions)
+	assert.Equal(t, "queued", <-st.sent, "queued tasks are sent on shutdown")
+	_, open := <-q.tasks
+	assert.False(t, open, "task queue is closed and drained")
+}
+
+func TestSenderQueueOverflow(t *testing.T) {
+	originalTransports := transports
+	originalHandlers := handlers
+	defer func() {
+		transports = originalTransports
+		handlers = originalHandlers
+	}()
+	SetLogger(newTestLogger(t))
+
+	st := &sendingTransport{sent: make(chan string, taskQueueSize+2), release: make(chan bool)}
+	transports = map[string]transport.Transport{"sending0": st}
+	handlers = map[string][]handler.Handler{}
+	trackPlugin(transportType, "sending", "sending0", st)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	wg := &sync.WaitGroup{}
+	RunTransports(ctx, wg, make(chan bool), false)
+
+	task := func(msg string) data.Event {
+		return data.Event{Type: data.TASK, Message: msg, Labels: map[string]interface{}{transport.TaskTransportLabel: "sending0"}}
+	}
+	eventBus.Publish(task("block"))
+	require.Equal(t, "block", <-st.sent)
+	for i := 0; i < taskQueueSize+10; i++ {
+		eventBus.Publish(task("queued"))
+	}
+
+	// bus workers are not blocked by the sending transport
+	drainCtx, drainCancel := context.WithTimeout(ctx, 5*time.Second)
+	defer drainCancel()
+	require.NoError(t, eventBus.Drain(drainCtx))
+	for _, s := range Status() {
+		if s.Instance == "sending0" {
+			assert.Equal(t, []QueueStatus{{Bus: eventBusName, Depth: taskQueueSize, Dropped: 10}}, s.Queues)
+		}
+	}
+
+	close(st.release)
+	StopTransport("sending0")
+	assert.Len(t, st.sent, taskQueueSize, "queued tasks are sent before transport stops")
+	cancel()
+	wg.Wait()
+}
+
+// forwardingTransport reports forwarded data besides messages of tasks
+type forwardingTransport struct {
+	sendingTransport
+	conf      transport.ForwardConfig
+	forwarded chan string
+}
+
+func (ft *forwardingTransport) Forward() transport.ForwardConfig {
+	return ft.conf
+}
+
+func (ft *forwardingTransport) ForwardEvent(e data.Event) error {
+	ft.forwarded <- "event " + e.Message
+	return nil
+}
+
+func (ft *forwardingTransport) ForwardMetrics(metrics []data.Metric) error {
+	for _, m := range metrics {
+		ft.forwarded <- "metric " + m.Name
+	}
+	return nil
+}
+
+func TestForwarders(t *testing.T) {
+	originalTransports := transports
+	originalHandlers := handlers
+	defer func() {
+		transports = originalTransports
+		handlers = originalHandlers
+	}()
+	SetLogger(newTestLogger(t))
+
+	ft := &forwardingTransport{
+		sendingTransport: sendingTransport{sent: make(chan string, 4)},
+		conf:             transport.ForwardConfig{Events: true, Metrics: true},
+		forwarded:        make(chan string, 4),
+	}
+	ft.conf.Filter.Metrics.Name.Allow = []string{"forwarded_.*"}
+	ft.conf.Filter.Events.Index.Deny = []string{"internal"}
+	transports = map[string]transport.Transport{"forwarding0": ft}
+	handlers = map[string][]handler.Handler{}
+	trackPlugin(transportType, "forwarding", "forwarding0", ft)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	wg := &sync.WaitGroup{}
+	RunTransports(ctx, wg, make(chan bool), false)
+	require.Len(t, senderSubscriptions["forwarding0"], 2)
+
+	eventBus.Publish(data.Event{Type: data.TASK, Message: "task", Labels: map[string]interface{}{transport.TaskTransportLabel: "forwarding0"}})
+	assert.Equal(t, "task", <-ft.sent, "tasks addressed to transport are sent, not forwarded")
+	eventBus.Publish(data.Event{Index: "internal", Message: "denied"})
+	eventBus.Publish(data.Event{Index: "collectd", Message: "allowed"})
+	assert.Equal(t, "event allowed", <-ft.forwarded)
+	metricBus.PublishBatch([]data.Metric{{Name: "other"}, {Name: "forwarded_metric"}})
+	assert.Equal(t, "metric forwarded_metric", <-ft.forwarded)
+
+	StopTransport("forwarding0")
+	assert.NotContains(t, senderSubscriptions, "forwarding0")
+	assert.Empty(t, ft.forwarded)
+	cancel()
+	wg.Wait()
+}
@@ -0,0 +1,279 @@
+package manager
+
+import (
+	"sort"
+	"sync"
+	"sync/atomic"
+
+	"github.com/infrawatch/sg-core/pkg/application"
+	"github.com/infrawatch/sg-core/pkg/transport"
+)
+
+// State describes lifecycle state of a loaded plugin
+type State string
+
+// plugin states
+const (
+	// StateConfigured plugin was loaded and configured, but is not running yet
+	StateConfigured State = "configured"
+	// StateRunning plugin is running
+	StateRunning State = "running"
+	// StateExited plugin exited after being stopped
+	StateExited State = "exited"
+	// StateFailed plugin exited on its own or reported failure
+	StateFailed State = "failed"
+)
+
+// plugin types
+const (
+	transportType   = "transport"
+	handlerType     = "handler"
+	applicationType = "application"
+	processorType   = "processor"
+)
+
+// bus names
+const (
+	eventBusName  = "events"
+	metricBusName = "metrics"
+)
+
+// PluginStatus status of one loaded plugin
+type PluginStatus struct {
+	Type        string             `json:"type"`
+	Name        string             `json:"name"`
+	Instance    string             `json:"instance"`
+	State       State              `json:"state"`
+	LastError   string             `json:"lastError,omitempty"`
+	Restarts    int                `json:"restarts"`
+	Queues      []QueueStatus      `json:"queues,omitempty"`
+	WorkerQueue *WorkerQueueStatus `json:"workerQueue,omitempty"` // transports with worker pool only
+}
+
+// QueueStatus statistics of subscriber queue of application or sending transport on a bus, task
+// queue of sending transport is included
+type QueueStatus struct {
+	Bus     string `json:"bus"`
+	Depth   int    `json:"depth"`
+	Dropped uint64 `json:"dropped"`
+}
+
+// PluginInfo status of loaded plugin together with its configuration
+type PluginInfo struct {
+	PluginStatus
+	Config interface{} `json:"config"`
+}
+
+// HandlerCounters numbers of messages passed to a handler and of messages it failed to handle
+type HandlerCounters struct {
+	Instance string `json:"instance"`
+	Messages uint64 `json:"messages"`
+	Errors   uint64 `json:"errors"`
+}
+
+type messageCounters struct {
+	messages atomic.Uint64
+	errors   atomic.Uint64
+}
+
+type statusEntry struct {
+	PluginStatus
+	reporter      func() error
+	subscriptions []subscription
+	config        interface{}
+	counters      *messageCounters // handlers only
+	workerQueue   *messageQueue    // transports with worker pool only
+}
+
+var (
+	statuses     = map[string]*statusEntry{}
+	statusesLock sync.RWMutex
+)
+
+func statusKey(typ string, instance string) string {
+	return typ + "/" + instance
+}
+
+func trackPlugin(typ string, name string, instance string, plugin interface{}) {
+	entry := &statusEntry{
+		PluginStatus: PluginStatus{
+			Type:     typ,
+			Name:     name,
+			Instance: instance,
+			State:    StateConfigured,
+		},
+	}
+	if typ == handlerType {
+		entry.counters = &messageCounters{}
+	}
+	switch p := plugin.(type) {
+	case transport.StatusReporter:
+		entry.reporter = p.Status
+	case application.StatusReporter:
+		entry.reporter = p.Status
+	}
+
+	statusesLock.Lock()
+	defer statusesLock.Unlock()
+	statuses[statusKey(typ, instance)] = entry
+}
+
+// tracked returns true if plugin instance of given type is loaded
+func tracked(typ string, instance string) bool {
+	statusesLock.RLock()
+	defer statusesLock.RUnlock()
+	_, ok := statuses[statusKey(typ, instance)]
+	return ok
+}
+
+func trackSubscriptions(typ string, instance string, subs []subscription) {
+	statusesLock.Lock()
+	defer statusesLock.Unlock()
+	if entry, ok := statuses[statusKey(typ, instance)]; ok {
+		entry.subscriptions = subs
+	}
+}
+
+func trackConfig(typ string, instance string, config interface{}) {
+	statusesLock.Lock()
+	defer statusesLock.Unlock()
+	if entry, ok := statuses[statusKey(typ, instance)]; ok {
+		entry.config = config
+	}
+}
+
+// handlerCounters returns message counters of handler. Counters of untracked handler are not reported
+func handlerCounters(instance string) *messageCounters {
+	statusesLock.RLock()
+	defer statusesLock.RUnlock()
+	if entry, ok := statuses[statusKey(handlerType, instance)]; ok && entry.counters != nil {
+		return entry.counters
+	}
+	return &messageCounters{}
+}
+
+func setWorkerQueue(instance string, q *messageQueue) {
+	statusesLock.Lock()
+	defer statusesLock.Unlock()
+	if entry, ok := statuses[statusKey(transportType, instance)]; ok {
+		entry.workerQueue = q
+	}
+}
+
+func untrackPlugin(typ string, instance string) {
+	statusesLock.Lock()
+	defer statusesLock.Unlock()
+	delete(statuses, statusKey(typ, instance))
+}
+
+func setState(typ string, instance string, state State, err error) {
+	statusesLock.Lock()
+	defer statusesLock.Unlock()
+	if entry, ok := statuses[statusKey(typ, instance)]; ok {
+		entry.State = state
+		if err != nil {
+			entry.LastError = err.Error()
+		}
+	}
+}
+
+func addRestart(typ string, instance string) {
+	statusesLock.Lock()
+	defer statusesLock.Unlock()
+	if entry, ok := statuses[statusKey(typ, instance)]; ok {
+		entry.Restarts++
+	}
+}
+
+func setLastError(typ string, instance string, err error) {
+	statusesLock.Lock()
+	defer statusesLock.Unlock()
+	if entry, ok := statuses[statusKey(typ, instance)]; ok {
+		entry.LastError = err.Error()
+	}
+}
+
+// Status returns status of all loaded plugins sorted by type and instance name. Running plugins
+// implementing StatusReporter interface are asked for their health
+func Status() []PluginStatus {
+	statusesLock.RLock()
+	entries := make([]statusEntry, 0, len(statuses))
+	for _, entry := range statuses {
+		entries = append(entries, *entry)
+	}
+	statusesLock.RUnlock()
+
+	res := make([]PluginStatus, 0, len(entries))
+	for _, entry := range entries {
+		if entry.State == StateRunning && entry.reporter != nil {
+			if err := entry.reporter(); err != nil {
+				entry.State = StateFailed
+				entry.LastError = err.Error()
+			}
+		}
+		for _, sub := range entry.subscriptions {
+			if st, ok := sub.stats(); ok {
+				entry.Queues = append(entry.Queues, QueueStatus{Bus: sub.bus, Depth: st.Depth, Dropped: st.Dropped})
+			}
+		}
+		if entry.workerQueue != nil {
+			entry.WorkerQueue = entry.workerQueue.stats()
+		}
+		res = append(res, entry.PluginStatus)
+	}
+	sort.Slice(res, func(i, j int) bool {
+		if res[i].Type != res[j].Type {
+			return res[i].Type > res[j].Type
+		}
+		return res[i].Instance < res[j].Instance
+	})
+	return res
+}
+
+// Ready returns true if all loaded plugins are running
+func Ready() bool {
+	for _, st := range Status() {
+		if st.State != StateRunning {
+			return false
+		}
+	}
+	return true
+}
+
+// Plugins returns status of all loaded plugins together with their configuration. Secrets in
+// configuration are redacted
+func Plugins() []PluginInfo {
+	plugins := []PluginInfo{}
+	for _, st := range Status() {
+		statusesLock.RLock()
+		var config interface{}
+		if entry, ok := statuses[statusKey(st.Type, st.Instance)]; ok {
+			config = entry.config
+		}
+		statusesLock.RUnlock()
+		plugins = append(plugins, PluginInfo{PluginStatus: st, Config: Redact(config)})
+	}
+	return plugins
+}
+
+// Counters returns message counters of all loaded handlers sorted by instance name
+func Counters() []HandlerCounters {
+	statusesLock.RLock()
+	res := []HandlerCounters{}
+	for _, entry := range statuses {
+		if entry.counters == nil {
+			continue
+		}
+		res = append(res, HandlerCounters{
+			Instance: entry.Instance,
+			Messages: entry.counters.messages.Load(),
+			Errors:   entry.counters.errors.Load(),
+		})
+	}
+	statusesLock.RUnlock()
+
+	sort.Slice(res, func(i, j int) bool {
+		return res[i].Instance < res[j].Instance
+	})
+	return res
+}
@@ -0,0 +1,241 @@
+package manager
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/infrawatch/apputils/logging"
+	"github.com/infrawatch/sg-core/pkg/data"
+)
+
+// supervisor restarts transports and applications whose Run exits on its own or which signal
+// failure. Failed plugins are restarted by calling their Run again with exponential backoff
+// until their restart budget is spent
+
+// failure policies applied when restart budget of a plugin is spent
+const (
+	// OnFailureExit shuts down sg-core
+	OnFailureExit = "exit"
+	// OnFailureContinue leaves the plugin failed and keeps the rest of sg-core running
+	OnFailureContinue = "continue"
+)
+
+// default restart policy
+const (
+	DefaultMaxRestarts = 5
+	DefaultBackoff     = time.Second
+	DefaultMaxBackoff  = time.Minute
+	DefaultResetAfter  = 10 * time.Minute
+)
+
+// RestartPolicy describes how failed plugin is restarted
+type RestartPolicy struct {
+	MaxRestarts *int          `yaml:"maxRestarts" validate:"omitempty,min=0"` // number of restarts before failure is terminal, 0 disables restarting
+	Backoff     time.Duration `yaml:"backoff"`                                // delay before first restart, doubled with each subsequent restart
+	MaxBackoff  time.Duration `yaml:"maxBackoff"`
+	ResetAfter  time.Duration `yaml:"resetAfter"` // restart budget and backoff are reset when plugin ran at least this long before failing
+	OnFailure   string        `yaml:"onFailure" validate:"omitempty,oneof=exit continue"`
+}
+
+func (rp RestartPolicy) withDefaults() RestartPolicy {
+	if rp.MaxRestarts == nil {
+		maxRestarts := DefaultMaxRestarts
+		rp.MaxRestarts = &maxRestarts
+	}
+	if rp.Backoff <= 0 {
+		rp.Backoff = DefaultBackoff
+	}
+	if rp.MaxBackoff < rp.Backoff {
+		rp.MaxBackoff = max(DefaultMaxBackoff, rp.Backoff)
+	}
+	if rp.ResetAfter <= 0 {
+		rp.ResetAfter = DefaultResetAfter
+	}
+	if rp.OnFailure == "" {
+		rp.OnFailure = OnFailureExit
+	}
+	return rp
+}
+
+var (
+	restartPolicies     = map[string]RestartPolicy{}
+	restartPoliciesLock sync.Mutex
+)
+
+// SetTransportRestartPolicy set restart policy of loaded transport
+func SetTransportRestartPolicy(name string, policy RestartPolicy) {
+	setRestartPolicy(transportType, name, policy)
+}
+
+// SetApplicationRestartPolicy set restart policy of loaded application
+func SetApplicationRestartPolicy(name string, policy RestartPolicy) {
+	setRestartPolicy(applicationType, name, policy)
+}
+
+func setRestartPolicy(typ string, instance string, policy RestartPolicy) {
+	restartPoliciesLock.Lock()
+	defer restartPoliciesLock.Unlock()
+	restartPolicies[statusKey(typ, instance)] = policy.withDefaults()
+}
+
+func restartPolicy(typ string, instance string) RestartPolicy {
+	restartPoliciesLock.Lock()
+	defer restartPoliciesLock.Unlock()
+	if policy, ok := restartPolicies[statusKey(typ, instance)]; ok {
+		return policy
+	}
+	return RestartPolicy{}.withDefaults()
+}
+
+func deleteRestartPolicy(typ string, instance string) {
+	restartPoliciesLock.Lock()
+	defer restartPoliciesLock.Unlock()
+	delete(restartPolicies, statusKey(typ, instance))
+}
+
+// supervise runs plugin until it is stopped. Failed plugin is restarted according to its
+// restart policy. When the restart budget is spent and policy is OnFailureExit, sg-core is
+// notified through done channel. Returns final state of the plugin
+func (rs *runState) supervise(typ string, instance string, done chan bool, run func(context.Context, chan bool)) State {
+	policy := restartPolicy(typ, instance)
+	restarts := 0
+	backoff := policy.Backoff
+	for {
+		setState(typ, instance, StateRunning, nil)
+		started := time.Now()
+		err := rs.attempt(run)
+		if rs.ctx.Err() != nil {
+			setState(typ, instance, StateExited, nil)
+			return StateExited
+		}
+		setState(typ, instance, StateFailed, err)
+
+		if time.Since(started) >= policy.ResetAfter {
+			restarts = 0
+			backoff = policy.Backoff
+		}
+		if restarts >= *policy.MaxRestarts {
+			log.Error("plugin failed, restart budget spent", logging.Metadata{typ: instance, "error": err, "restarts": restarts})
+			if policy.OnFailure == OnFailureExit {
+				select {
+				case done <- true:
+				case <-rs.ctx.Done():
+				}
+			}
+			return StateFailed
+		}
+
+		restarts++
+		addRestart(typ, instance)
+		log.Warn("plugin failed, restarting", logging.Metadata{typ: instance, "error": err, "backoff": backoff.String()})
+		select {
+		case <-time.After(backoff):
+		case <-rs.ctx.Done():
+			setState(typ, instance, StateExited, nil)
+			return StateExited
+		}
+		backoff = min(2*backoff, policy.MaxBackoff)
+	}
+}
+
+// attempt runs plugin once. Plugin signaling failure is stopped and ErrPluginSignaled is returned,
+// otherwise ErrPluginExited is returned when plugin exits without being stopped
+func (rs *runState) attempt(run func(context.Context, chan bool)) error {
+	ctx, cancel := context.WithCancel(rs.ctx)
+	defer cancel()
+
+	// buffered, so that plugin signaling failure after its Run returned is not blocked
+	pluginDone := make(chan bool, 1)
+	finished := make(chan struct{})
+	signaled := make(chan struct{})
+	drained := make(chan struct{})
+	signal := func() {
+		select {
+		case <-signaled:
+		default:
+			close(signaled)
+			cancel()
+		}
+	}
+	// plugins may signal failure more than once, eg. from multiple goroutines, so signals
+	// are received until Run returns
+	go func() {
+		defer close(drained)
+		for {
+			select {
+			case <-pluginDone:
+				signal()
+			case <-finished:
+				select {
+				case <-pluginDone:
+					signal()
+				default:
+				}
+				return
+			}
+		}
+	}()
+
+	run(ctx, pluginDone)
+	close(finished)
+	<-drained
+
+	select {
+	case <-signaled:
+		return ErrPluginSignaled
+	default:
+		return ErrPluginExited
+	}
+}
+
+// RunSupervisorMetrics periodically publishes restart counts of transports and applications and numbers
+// of data dropped from their bus queues to the metric bus. New drops are also logged
+func RunSupervisorMetrics(ctx context.Context, wg *sync.WaitGroup) {
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		dropped := map[string]uint64{}
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(time.Second):
+				publishMetrics(supervisorMetrics(dropped))
+			}
+		}
+	}()
+}
+
+// supervisorMetrics returns restart and drop counts of plugins, new drops since the counts recorded
+// in dropped are logged
+func supervisorMetrics(dropped map[string]uint64) []data.Metric {
+	metrics := []data.Metric{}
+	for _, st := range Status() {
+		if st.Type != transportType && st.Type != applicationType {
+			continue
+		}
+		metrics = append(metrics, data.Metric{
+			Name:      "sg_total_plugin_restart_count",
+			Type:      data.COUNTER,
+			Value:     float64(st.Restarts),
+			LabelKeys: []string{"source", "type", "plugin", "instance"},
+			LabelVals: []string{"SG", st.Type, st.Name, st.Instance},
+		})
+		for _, q := range st.Queues {
+			metrics = append(metrics, data.Metric{
+				Name:      "sg_total_bus_dropped_count",
+				Type:      data.COUNTER,
+				Value:     float64(q.Dropped),
+				LabelKeys: []string{"source", "type", "plugin", "instance", "bus"},
+				LabelVals: []string{"SG", st.Type, st.Name, st.Instance, q.Bus},
+			})
+			key := statusKey(st.Type, st.Instance) + "/" + q.Bus
+			if q.Dropped > dropped[key] {
+				log.Warn("bus queue full, data dropped", logging.Metadata{st.Type: st.Instance, "bus": q.Bus, "dropped": q.Dropped - dropped[key]})
+			}
+			dropped[key] = q.Dropped
+		}
+	}
+	return metrics
+}
@@ -0,0 +1,205 @@
+package manager
+
+import (
+	"context"
+	"path"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/infrawatch/apputils/logging"
+	"github.com/infrawatch/sg-core/pkg/application"
+	"github.com/infrawatch/sg-core/pkg/bus"
+	"github.com/infrawatch/sg-core/pkg/data"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestLogger(t *testing.T) *logging.Logger {
+	l, err := logging.NewLogger(logging.DEBUG, path.Join(t.TempDir(), "test.log"))
+	require.NoError(t, err)
+	return l
+}
+
+// failingApplication fails given number of times before it runs until stopped
+type failingApplication struct {
+	registeredApplication
+	failures int32
+	signal   bool
+	runs     atomic.Int32
+}
+
+func (fa *failingApplication) Run(ctx context.Context, done chan bool) {
+	if fa.runs.Add(1) <= fa.failures {
+		if fa.signal {
+			done <- true
+			<-ctx.Done()
+		}
+		return
+	}
+	<-ctx.Done()
+}
+
+// signalingApplication signals failure from several goroutines on its first run and waits for them before return
+type signalingApplication struct {
+	registeredApplication
+	runs atomic.Int32
+}
+
+func (sa *signalingApplication) Run(ctx context.Context, done chan bool) {
+	signals := sync.WaitGroup{}
+	if sa.runs.Add(1) == 1 {
+		for i := 0; i < 3; i++ {
+			signals.Add(1)
+			go func() {
+				defer signals.Done()
+				done <- true
+			}()
+		}
+	}
+	<-ctx.Done()
+	signals.Wait()
+}
+
+func TestRestartPolicyDefaults(t *testing.T) {
+	rp := RestartPolicy{}.withDefaults()
+	assert.Equal(t, DefaultMaxRestarts, *rp.MaxRestarts)
+	assert.Equal(t, DefaultBackoff, rp.Backoff)
+	assert.Equal(t, DefaultMaxBackoff, rp.MaxBackoff)
+	assert.Equal(t, DefaultResetAfter, rp.ResetAfter)
+	assert.Equal(t, OnFailureExit, rp.OnFailure)
+
+	rp = RestartPolicy{Backoff: 2 * time.Minute}.withDefaults()
+	assert.Equal(t, 2*time.Minute, rp.MaxBackoff)
+}
+
+func TestSupervisor(t *testing.T) {
+	originalApplications := applications
+	originalStatuses := statuses
+	defer func() {
+		applications = originalApplications
+		statuses = originalStatuses
+	}()
+	SetLogger(newTestLogger(t))
+
+	run := func(app application.Application, policy RestartPolicy) (chan bool, *sync.WaitGroup, context.CancelFunc) {
+		statuses = map[string]*statusEntry{}
+		applications = map[string]application.Application{"failing": app}
+		trackPlugin(applicationType, "failing", "failing", app)
+		SetApplicationRestartPolicy("failing", policy)
+
+		ctx, cancel := context.WithCancel(context.Background())
+		wg := &sync.WaitGroup{}
+		done := make(chan bool)
+		RunApplications(ctx, wg, done)
+		return done, wg, cancel
+	}
+	restarts := func(n int) *int { return &n }
+
+	t.Run("restart after exit", func(t *testing.T) {
+		app := &failingApplication{failures: 2}
+		_, wg, cancel := run(app, RestartPolicy{Backoff: time.Millisecond})
+		defer StopApplication("failing")
+
+		assert.Eventually(t, func() bool { return app.runs.Load() == 3 }, time.Second, time.Millisecond)
+		st := Status()
+		require.Len(t, st, 1)
+		assert.Equal(t, StateRunning, st[0].State)
+		assert.Equal(t, 2, st[0].Restarts)
+		assert.Equal(t, ErrPluginExited.Error(), st[0].LastError)
+
+		cancel()
+		wg.Wait()
+		assert.Equal(t, StateExited, Status()[0].State)
+	})
+
+	t.Run("restart after signaled failure", func(t *testing.T) {
+		app := &failingApplication{failures: 1, signal: true}
+		_, wg, cancel := run(app, RestartPolicy{Backoff: time.Millisecond})
+		defer StopApplication("failing")
+
+		assert.Eventually(t, func() bool { return app.runs.Load() == 2 }, time.Second, time.Millisecond)
+		assert.Equal(t, ErrPluginSignaled.Error(), Status()[0].LastError)
+		cancel()
+		wg.Wait()
+	})
+
+	t.Run("repeated signals", func(t *testing.T) {
+		app := &signalingApplication{}
+		_, wg, cancel := run(app, RestartPolicy{Backoff: time.Millisecond})
+		defer StopApplication("failing")
+
+		// the first run returns only when all its signals were received
+		require.Eventually(t, func() bool { return app.runs.Load() == 2 }, time.Second, time.Millisecond)
+		assert.Equal(t, 1, Status()[0].Restarts)
+		cancel()
+		wg.Wait()
+	})
+
+	t.Run("exit when budget is spent", func(t *testing.T) {
+		app := &failingApplication{failures: 3}
+		done, wg, cancel := run(app, RestartPolicy{MaxRestarts: restarts(2), Backoff: time.Millisecond})
+		defer StopApplication("failing")
+
+		select {
+		case <-done:
+		case <-time.After(time.Second):
+			t.Fatal("sg-core was not notified about failed plugin")
+		}
+		wg.Wait()
+		cancel()
+		assert.Equal(t, int32(3), app.runs.Load())
+		assert.Equal(t, StateFailed, Status()[0].State)
+		assert.Equal(t, 2, Status()[0].Restarts)
+	})
+
+	t.Run("continue when budget is spent", func(t *testing.T) {
+		app := &failingApplication{failures: 1}
+		done, wg, cancel := run(app, RestartPolicy{MaxRestarts: restarts(0), OnFailure: OnFailureContinue})
+		defer StopApplication("failing")
+
+		wg.Wait()
+		cancel()
+		assert.Empty(t, done)
+		assert.Equal(t, int32(1), app.runs.Load())
+		assert.Equal(t, StateFailed, Status()[0].State)
+	})
+}
+
+func TestSupervisorMetrics(t *testing.T) {
+	originalStatuses := statuses
+	defer func() {
+		statuses = originalStatuses
+		eventBus.SetQueueConfig(bus.QueueConfig{})
+	}()
+	SetLogger(newTestLogger(t))
+	statuses = map[string]*statusEntry{}
+
+	eventBus.SetQueueConfig(bus.QueueConfig{QueueSize: 1, Overflow: "dropNewest"})
+	received := make(chan struct{}, 2)
+	release := make(chan struct{})
+	sub := subscription{bus: eventBusName, id: eventBus.Subscribe(func(data.Event) {
+		received <- struct{}{}
+		<-release
+	})}
+	defer sub.unsubscribe()
+	defer close(release)
+	trackPlugin(applicationType, "registered", "registered0", &registeredApplication{})
+	trackSubscriptions(applicationType, "registered0", []subscription{sub})
+
+	// the first event is being received, the second waits in the queue, the rest is dropped
+	eventBus.Publish(data.Event{})
+	<-received
+	for i := 0; i < 3; i++ {
+		eventBus.Publish(data.Event{})
+	}
+
+	dropped := map[string]uint64{}
+	metrics := supervisorMetrics(dropped)
+	require.Len(t, metrics, 2)
+	assert.Equal(t, "sg_total_bus_dropped_count", metrics[1].Name)
+	assert.Equal(t, float64(2), metrics[1].Value)
+	assert.Equal(t, []string{"SG", applicationType, "registered", "registered0", eventBusName}, metrics[1].LabelVals)
+	assert.Equal(t, map[string]uint64{"application/registered0/events": 2}, dropped)
+}
@@ -0,0 +1,36 @@
+package manager
+
+import (
+	"github.com/infrawatch/sg-core/pkg/filter"
+	"github.com/pkg/errors"
+)
+
+// validation of plugin configurations loads plugins and configures them
+// without registering or running them
+
+// ValidateTransport loads transport plugin and configures it
+func ValidateTransport(name string, config interface{}) error {
+	_, err := newTransport(name, config, logger)
+	return err
+}
+
+// ValidateHandler loads handler plugin and configures it
+func ValidateHandler(name string, config interface{}) error {
+	_, err := newHandler(name, config)
+	return err
+}
+
+// ValidateApplication loads application plugin and configures it together with its filter
+func ValidateApplication(name string, config interface{}, filterConf filter.Config) error {
+	if _, err := filter.New(filterConf); err != nil {
+		return errors.Wrapf(err, "failed parsing filter of application '%s'", name)
+	}
+	_, err := newApplication(name, config, logger, publishEvent)
+	return err
+}
+
+// ValidateProcessor loads processor plugin and configures it
+func ValidateProcessor(name string, config interface{}) error {
+	_, err := newProcessor(name, config, logger)
+	return err
+}
@@ -0,0 +1,65 @@
+package manager
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+
+	"github.com/infrawatch/apputils/logging"
+	"github.com/infrawatch/sg-core/pkg/application"
+	"github.com/infrawatch/sg-core/pkg/bus"
+	"github.com/infrawatch/sg-core/pkg/config"
+	"github.com/infrawatch/sg-core/pkg/filter"
+	"github.com/infrawatch/sg-core/pkg/registry"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type validatedApplication struct {
+	registeredApplication
+}
+
+type validatedConfig struct {
+	Host string `validate:"required"`
+}
+
+func (va *validatedApplication) Config(c []byte) error {
+	conf := validatedConfig{}
+	return config.ParseConfig(bytes.NewReader(c), &conf)
+}
+
+func TestValidate(t *testing.T) {
+	SetLogger(newTestLogger(t))
+	SetPluginDir(t.TempDir())
+	registry.RegisterApplication("validated-application", func(*logging.Logger, bus.EventPublishFunc) application.Application {
+		return &validatedApplication{}
+	})
+
+	originalApplications := applications
+	defer func() { applications = originalApplications }()
+	applications = map[string]application.Application{}
+
+	t.Run("valid configuration", func(t *testing.T) {
+		err := ValidateApplication("validated-application", map[string]interface{}{"host": "localhost"}, filter.Config{})
+		assert.NoError(t, err)
+		assert.Empty(t, applications, "validated application is not registered")
+	})
+
+	t.Run("missing field", func(t *testing.T) {
+		err := ValidateApplication("validated-application", map[string]interface{}{}, filter.Config{})
+		var verr *config.ValidationError
+		require.True(t, errors.As(err, &verr))
+		assert.Equal(t, []string{"host"}, verr.Fields)
+	})
+
+	t.Run("invalid filter", func(t *testing.T) {
+		err := ValidateApplication("validated-application", map[string]interface{}{"host": "localhost"},
+			filter.Config{Labels: []filter.LabelMatcher{{Name: "host", Op: "=~", Value: "["}}})
+		assert.Error(t, err)
+	})
+
+	t.Run("unknown plugin", func(t *testing.T) {
+		assert.Error(t, ValidateTransport("unknown-transport", nil))
+		assert.Error(t, ValidateHandler("unknown-handler", nil))
+	})
+}
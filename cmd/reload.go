@@ -0,0 +1,317 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"sort"
+
+	"github.com/infrawatch/apputils/logging"
+	"github.com/infrawatch/sg-core/cmd/manager"
+	"github.com/infrawatch/sg-core/pkg/pluginlog"
+	"gopkg.in/yaml.v3"
+)
+
+// bookkeeping of loaded plugins. On configuration reload only plugins whose
+// configuration block changed are stopped and loaded again
+var (
+	loadedTransports   = map[string]loadedPlugin[transportConfig]{}   // block fingerprint -> transport
+	loadedApplications = map[string]loadedPlugin[applicationConfig]{} // block fingerprint -> application
+	loadedProcessors   = ""                                           // fingerprint of global processor chain
+)
+
+// loadedPlugin instance name of loaded plugin together with configuration block it was loaded from
+type loadedPlugin[T any] struct {
+	name  string
+	block T
+}
+
+// fingerprint returns identifier of configuration block
+func fingerprint(block interface{}) string {
+	blob, _ := yaml.Marshal(block)
+	sum := sha256.Sum256(blob)
+	return hex.EncodeToString(sum[:])
+}
+
+// fingerprints returns identifiers of configuration blocks. Identical blocks are numbered,
+// so that each of them identifies separate plugin instance
+func fingerprints[T any](blocks []T) []string {
+	res := make([]string, len(blocks))
+	seen := map[string]int{}
+	for i, block := range blocks {
+		fp := fingerprint(block)
+		if n := seen[fp]; n > 0 {
+			res[i] = fmt.Sprintf("%s-%d", fp, n)
+		} else {
+			res[i] = fp
+		}
+		seen[fp]++
+	}
+	return res
+}
+
+// changedBlocks pairs blocks which are not loaded yet with loaded blocks which are no longer configured
+// and load the same plugin under the same instance name, ie. blocks whose configuration changed.
+// Returns fingerprint of the loaded block by index of the changed block
+func changedBlocks[T any](loaded map[string]loadedPlugin[T], fps []string, blocks []T, key func(T) string) map[int]string {
+	wanted := map[string]bool{}
+	for _, fp := range fps {
+		wanted[fp] = true
+	}
+	// blocks of plugins without instance name are paired in order of their generated names
+	stale := []string{}
+	for fp := range loaded {
+		if !wanted[fp] {
+			stale = append(stale, fp)
+		}
+	}
+	sort.Slice(stale, func(i, j int) bool { return loaded[stale[i]].name < loaded[stale[j]].name })
+
+	res := map[int]string{}
+	for i, block := range blocks {
+		if _, ok := loaded[fps[i]]; ok {
+			continue
+		}
+		for j, fp := range stale {
+			if key(loaded[fp].block) == key(block) {
+				res[i] = fp
+				stale = append(stale[:j], stale[j+1:]...)
+				break
+			}
+		}
+	}
+	return res
+}
+
+// loadTransports stops transports which are no longer configured and loads transports from blocks
+// which are not loaded yet. Changed block is validated before its transport is stopped, current
+// transport is kept when the block is invalid and loaded again when the new one fails to load.
+// Transports of changed blocks keep their instance names, so the new transport can't be loaded
+// while the current one is
+func loadTransports(logger *pluginlog.Logger, conf configT) {
+	fps := fingerprints(conf.Transports)
+	changed := changedBlocks(loadedTransports, fps, conf.Transports, func(block transportConfig) string {
+		return block.Name + "/" + block.Instance
+	})
+	replaced := map[string]bool{}
+	for _, fp := range changed {
+		replaced[fp] = true
+	}
+	wanted := map[string]bool{}
+	for _, fp := range fps {
+		wanted[fp] = true
+	}
+	for fp, t := range loadedTransports {
+		if !wanted[fp] && !replaced[fp] {
+			manager.StopTransport(t.name)
+			delete(loadedTransports, fp)
+			logger.Info("unloaded transport", logging.Metadata{"transport": t.name})
+		}
+	}
+
+	for i, tConfig := range conf.Transports {
+		fp := fps[i]
+		if _, ok := loadedTransports[fp]; ok {
+			continue
+		}
+		oldFp, ok := changed[i]
+		if !ok {
+			if tName, err := loadTransport(logger, tConfig); err == nil {
+				loadedTransports[fp] = loadedPlugin[transportConfig]{name: tName, block: tConfig}
+			}
+			continue
+		}
+
+		current := loadedTransports[oldFp]
+		if err := validateTransport(tConfig); err != nil {
+			logger.Error("changed transport is invalid, keeping current one", logging.Metadata{"transport": current.name, "error": err})
+			continue
+		}
+		manager.StopTransport(current.name)
+		delete(loadedTransports, oldFp)
+		logger.Info("unloaded transport", logging.Metadata{"transport": current.name})
+		if tName, err := loadTransport(logger, tConfig); err == nil {
+			loadedTransports[fp] = loadedPlugin[transportConfig]{name: tName, block: tConfig}
+			continue
+		}
+		if tName, err := loadTransport(logger, current.block); err == nil {
+			loadedTransports[oldFp] = loadedPlugin[transportConfig]{name: tName, block: current.block}
+			logger.Warn("restored current configuration of transport", logging.Metadata{"transport": tName})
+		}
+	}
+}
+
+// loadTransport loads transport together with its handlers, processors and dead-letter store from configuration
+// block. Returns instance name of the transport
+func loadTransport(logger *pluginlog.Logger, tConfig transportConfig) (string, error) {
+	tName, err := manager.InitTransport(tConfig.Name, tConfig.Instance, tConfig.Config)
+	if err != nil {
+		logger.Error("failed configuring transport", logging.Metadata{"transport": tConfig.Name, "error": err})
+		return "", err
+	}
+	err = manager.SetTransportHandlers(tName, tConfig.Handlers)
+	if err != nil {
+		manager.StopTransport(tName)
+		logger.Error("transport handlers failed to load", logging.Metadata{"transport": tName, "error": err})
+		return "", err
+	}
+	err = manager.SetTransportProcessors(tName, tConfig.Processors)
+	if err != nil {
+		manager.StopTransport(tName)
+		logger.Error("transport processors failed to load", logging.Metadata{"transport": tName, "error": err})
+		return "", err
+	}
+	err = manager.SetTransportDeadLetters(tName, tConfig.DeadLetter)
+	if err != nil {
+		manager.StopTransport(tName)
+		logger.Error("transport dead-letter store failed to open", logging.Metadata{"transport": tName, "error": err})
+		return "", err
+	}
+	manager.SetTransportRestartPolicy(tName, tConfig.Restart)
+	manager.SetTransportWorkerPool(tName, tConfig.WorkerPool)
+	logger.Info("loaded transport", logging.Metadata{"transport": tName})
+	return tName, nil
+}
+
+// validateTransport loads and configures plugins of transport block without registering them
+func validateTransport(tConfig transportConfig) error {
+	if err := manager.ValidateTransport(tConfig.Name, tConfig.Config); err != nil {
+		return err
+	}
+	for _, hConfig := range tConfig.Handlers {
+		if err := manager.ValidateHandler(hConfig.Name, hConfig.Config); err != nil {
+			return err
+		}
+	}
+	for _, pConfig := range tConfig.Processors {
+		if err := manager.ValidateProcessor(pConfig.Name, pConfig.Config); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// loadProcessors replaces global processor chain when its configuration changed. Current chain
+// is kept when the new one fails to load
+func loadProcessors(logger *pluginlog.Logger, conf configT) {
+	fp := fingerprint(conf.Processors)
+	if fp == loadedProcessors {
+		return
+	}
+	err := manager.SetProcessors(conf.Processors)
+	if err != nil {
+		logger.Error("global processors failed to load", logging.Metadata{"error": err})
+		return
+	}
+	loadedProcessors = fp
+	logger.Info("loaded global processors", logging.Metadata{"processors": len(conf.Processors)})
+}
+
+// loadApplications stops applications which are no longer configured and loads applications from
+// blocks which are not loaded yet. Changed blocks are replaced the same way as blocks of transports
+func loadApplications(logger *pluginlog.Logger, conf configT) error {
+	fps := fingerprints(conf.Applications)
+	changed := changedBlocks(loadedApplications, fps, conf.Applications, func(block applicationConfig) string {
+		return block.Name + "/" + block.Instance
+	})
+	replaced := map[string]bool{}
+	for _, fp := range changed {
+		replaced[fp] = true
+	}
+	wanted := map[string]bool{}
+	for _, fp := range fps {
+		wanted[fp] = true
+	}
+	for fp, a := range loadedApplications {
+		if !wanted[fp] && !replaced[fp] {
+			manager.StopApplication(a.name)
+			delete(loadedApplications, fp)
+			logger.Info("unloaded application plugin", logging.Metadata{"application": a.name})
+		}
+	}
+
+	var err error
+	for i, aConfig := range conf.Applications {
+		fp := fps[i]
+		if _, ok := loadedApplications[fp]; ok {
+			continue
+		}
+		oldFp, ok := changed[i]
+		if !ok {
+			var aName string
+			if aName, err = loadApplication(logger, aConfig); aName != "" {
+				loadedApplications[fp] = loadedPlugin[applicationConfig]{name: aName, block: aConfig}
+			}
+			continue
+		}
+
+		current := loadedApplications[oldFp]
+		if verr := manager.ValidateApplication(aConfig.Name, aConfig.Config, aConfig.Filter); verr != nil {
+			logger.Error("changed application is invalid, keeping current one", logging.Metadata{"application": current.name, "error": verr})
+			continue
+		}
+		manager.StopApplication(current.name)
+		delete(loadedApplications, oldFp)
+		logger.Info("unloaded application plugin", logging.Metadata{"application": current.name})
+		var aName string
+		if aName, err = loadApplication(logger, aConfig); aName != "" {
+			loadedApplications[fp] = loadedPlugin[applicationConfig]{name: aName, block: aConfig}
+			continue
+		}
+		if aName, _ := loadApplication(logger, current.block); aName != "" {
+			loadedApplications[oldFp] = loadedPlugin[applicationConfig]{name: aName, block: current.block}
+			logger.Warn("restored current configuration of application", logging.Metadata{"application": aName})
+		}
+	}
+	return err
+}
+
+// loadApplication loads application from configuration block. Returns instance name of the application,
+// it is empty when the application failed to load. Application which does not receive data stays loaded,
+// manager.ErrAppNotReceiver is returned for it
+func loadApplication(logger *pluginlog.Logger, aConfig applicationConfig) (string, error) {
+	aName, err := manager.InitApplication(aConfig.Name, aConfig.Instance, aConfig.Config, aConfig.Filter)
+	if err != nil {
+		if err == manager.ErrAppNotReceiver {
+			logger.Warn(err.Error(), logging.Metadata{"application": aName})
+		} else {
+			logger.Error("failed configuring application", logging.Metadata{"application": aConfig.Name, "error": err})
+			return "", err
+		}
+	}
+	manager.SetApplicationRestartPolicy(aName, aConfig.Restart)
+	logger.Info("loaded application plugin", logging.Metadata{"application": aName})
+	return aName, err
+}
+
+// reloadConfiguration re-reads configuration file and restarts only plugins whose configuration changed
+func reloadConfiguration(path string, logger *pluginlog.Logger) bool {
+	conf, err := readConfiguration(path)
+	if err != nil {
+		logger.Error("failed reloading configuration, keeping current one", logging.Metadata{"error": err})
+		return false
+	}
+
+	if conf.HandlerErrors != configuration.HandlerErrors || conf.EventBus != configuration.EventBus ||
+		conf.MetricBus != configuration.MetricBus || conf.HTTP != configuration.HTTP || conf.Debug != configuration.Debug ||
+		conf.LogFormat != configuration.LogFormat || conf.LogOutput != configuration.LogOutput {
+		logger.Warn("changes of handleErrors, blockEventBus, eventBus, metricBus, http, debug, logFormat and logOutput require restart")
+	}
+
+	setLogLevel(logger, conf.LogLevel)
+	manager.SetPluginDir(conf.PluginDir)
+	loadProcessors(logger, conf)
+	loadTransports(logger, conf)
+	_ = loadApplications(logger, conf)
+
+	conf.HandlerErrors = configuration.HandlerErrors
+	conf.BlockEventBus = configuration.BlockEventBus
+	conf.EventBus = configuration.EventBus
+	conf.MetricBus = configuration.MetricBus
+	conf.HTTP = configuration.HTTP
+	conf.Debug = configuration.Debug
+	conf.LogFormat = configuration.LogFormat
+	conf.LogOutput = configuration.LogOutput
+	configuration = conf
+	return true
+}
@@ -0,0 +1,137 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/infrawatch/apputils/logging"
+	"github.com/infrawatch/sg-core/cmd/manager"
+	"github.com/infrawatch/sg-core/pkg/application"
+	"github.com/infrawatch/sg-core/pkg/bus"
+	"github.com/infrawatch/sg-core/pkg/data"
+	"github.com/infrawatch/sg-core/pkg/pluginlog"
+	"github.com/infrawatch/sg-core/pkg/registry"
+	"github.com/infrawatch/sg-core/pkg/transport"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v3"
+)
+
+func TestFingerprints(t *testing.T) {
+	type block struct {
+		Name   string
+		Config interface{}
+	}
+	fps := fingerprints([]block{
+		{Name: "elasticsearch", Config: map[string]string{"hostURL": "http://logs:9200"}},
+		{Name: "elasticsearch", Config: map[string]string{"hostURL": "http://events:9200"}},
+		{Name: "elasticsearch", Config: map[string]string{"hostURL": "http://logs:9200"}},
+	})
+	assert.Len(t, fps, 3)
+	assert.NotEqual(t, fps[0], fps[1])
+	assert.Equal(t, fps[0]+"-1", fps[2], "identical blocks are loaded separately")
+	assert.Equal(t, fps, fingerprints([]block{
+		{Name: "elasticsearch", Config: map[string]string{"hostURL": "http://logs:9200"}},
+		{Name: "elasticsearch", Config: map[string]string{"hostURL": "http://events:9200"}},
+		{Name: "elasticsearch", Config: map[string]string{"hostURL": "http://logs:9200"}},
+	}), "fingerprints are stable")
+}
+
+// reloadedTransport fails to configure when its configuration contains fail: true
+type reloadedTransport struct{}
+
+func (rt *reloadedTransport) Config(c []byte) error {
+	conf := struct{ Fail bool }{}
+	if err := yaml.Unmarshal(c, &conf); err != nil {
+		return err
+	}
+	if conf.Fail {
+		return errors.New("invalid configuration")
+	}
+	return nil
+}
+
+func (rt *reloadedTransport) Run(context.Context, transport.WriteFn, chan bool) {}
+
+// reloadedApplication fails to configure when its configuration contains fail: true
+type reloadedApplication struct {
+	reloadedTransport
+}
+
+func (ra *reloadedApplication) Run(context.Context, chan bool) {}
+func (ra *reloadedApplication) ReceiveEvent(data.Event)        {}
+
+// loadedConfig returns configuration of loaded plugin instance
+func loadedConfig(t *testing.T, typ string, instance string) interface{} {
+	for _, p := range manager.Plugins() {
+		if p.Type == typ && p.Instance == instance {
+			return p.Config
+		}
+	}
+	t.Fatalf("%s '%s' is not loaded", typ, instance)
+	return nil
+}
+
+func TestReloadChangedBlocks(t *testing.T) {
+	l, err := logging.NewLogger(logging.ERROR, filepath.Join(t.TempDir(), "sg-core.log"))
+	require.NoError(t, err)
+	manager.SetLogger(l)
+	logger := pluginlog.NewCore(l)
+	registry.RegisterTransport("reloaded", func(*logging.Logger) transport.Transport { return &reloadedTransport{} })
+	registry.RegisterApplication("reloaded", func(*logging.Logger, bus.EventPublishFunc) application.Application {
+		return &reloadedApplication{}
+	})
+	manager.SetPluginDir(t.TempDir())
+
+	transportConf := func(config map[string]interface{}) configT {
+		return configT{Transports: []transportConfig{{Name: "reloaded", Instance: "pipeline", Config: config}}}
+	}
+	t.Run("transport", func(t *testing.T) {
+		defer func() {
+			loadTransports(logger, configT{})
+			assert.Empty(t, loadedTransports)
+		}()
+		loadTransports(logger, transportConf(map[string]interface{}{"key": "a"}))
+		assert.Equal(t, map[string]interface{}{"key": "a"}, loadedConfig(t, "transport", "pipeline"))
+
+		loadTransports(logger, transportConf(map[string]interface{}{"fail": true}))
+		assert.Len(t, loadedTransports, 1)
+		assert.Equal(t, map[string]interface{}{"key": "a"}, loadedConfig(t, "transport", "pipeline"), "invalid block keeps current transport")
+
+		loadTransports(logger, transportConf(map[string]interface{}{"key": "b"}))
+		assert.Len(t, loadedTransports, 1)
+		assert.Equal(t, map[string]interface{}{"key": "b"}, loadedConfig(t, "transport", "pipeline"))
+
+		// dead-letter store can't be opened in regular file, so valid block fails to load
+		file := filepath.Join(t.TempDir(), "file")
+		require.NoError(t, os.WriteFile(file, nil, 0600))
+		conf := transportConf(map[string]interface{}{"key": "c"})
+		conf.Transports[0].DeadLetter.Directory = file
+		loadTransports(logger, conf)
+		assert.Len(t, loadedTransports, 1)
+		assert.Equal(t, map[string]interface{}{"key": "b"}, loadedConfig(t, "transport", "pipeline"), "current transport is restored")
+	})
+
+	applicationConf := func(config map[string]interface{}) configT {
+		return configT{Applications: []applicationConfig{{Name: "reloaded", Config: config}}}
+	}
+	t.Run("application", func(t *testing.T) {
+		defer func() {
+			require.NoError(t, loadApplications(logger, configT{}))
+			assert.Empty(t, loadedApplications)
+		}()
+		require.NoError(t, loadApplications(logger, applicationConf(map[string]interface{}{"key": "a"})))
+		assert.Equal(t, map[string]interface{}{"key": "a"}, loadedConfig(t, "application", "reloaded"))
+
+		require.NoError(t, loadApplications(logger, applicationConf(map[string]interface{}{"fail": true})))
+		assert.Len(t, loadedApplications, 1)
+		assert.Equal(t, map[string]interface{}{"key": "a"}, loadedConfig(t, "application", "reloaded"), "invalid block keeps current application")
+
+		require.NoError(t, loadApplications(logger, applicationConf(map[string]interface{}{"key": "b"})))
+		assert.Len(t, loadedApplications, 1)
+		assert.Equal(t, map[string]interface{}{"key": "b"}, loadedConfig(t, "application", "reloaded"), "changed block keeps instance name")
+	})
+}
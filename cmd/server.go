@@ -0,0 +1,150 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/infrawatch/apputils/logging"
+	"github.com/infrawatch/sg-core/cmd/manager"
+	"github.com/infrawatch/sg-core/pkg/deadletter"
+	"github.com/infrawatch/sg-core/pkg/pluginlog"
+	"github.com/pkg/errors"
+)
+
+// core HTTP listener serving endpoints for liveness and readiness probes,
+// reporting status of loaded plugins and admin endpoints
+
+// started is set once configured pipelines were spawned
+var started atomic.Bool
+
+func newServeMux(logger *pluginlog.Logger) *http.ServeMux {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/healthz", func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("ok\n"))
+	})
+	mux.HandleFunc("/readyz", func(w http.ResponseWriter, r *http.Request) {
+		if !started.Load() || !manager.Ready() {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			_, _ = w.Write([]byte("not ready\n"))
+			return
+		}
+		_, _ = w.Write([]byte("ready\n"))
+	})
+	mux.HandleFunc("/status", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, http.StatusOK, map[string]interface{}{
+			"ready":   started.Load() && manager.Ready(),
+			"plugins": manager.Status(),
+		})
+	})
+	mux.HandleFunc("/admin/plugins", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, http.StatusOK, manager.Plugins())
+	})
+	mux.HandleFunc("/admin/counters", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, http.StatusOK, manager.Counters())
+	})
+	mux.HandleFunc("/admin/deadletters", func(w http.ResponseWriter, r *http.Request) {
+		dls, err := manager.ListDeadLetters()
+		if err != nil {
+			writeJSON(w, http.StatusInternalServerError, map[string]string{"error": err.Error()})
+			return
+		}
+		writeJSON(w, http.StatusOK, dls)
+	})
+	mux.HandleFunc("/admin/deadletters/reinject", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			w.Header().Set("Allow", "POST")
+			writeJSON(w, http.StatusMethodNotAllowed, map[string]string{"error": "method not allowed"})
+			return
+		}
+		req := struct {
+			Transport string   `json:"transport"`
+			IDs       []string `json:"ids"` // all stored messages are re-injected when empty
+		}{}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			writeJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
+			return
+		}
+		results, err := manager.ReinjectDeadLetters(req.Transport, req.IDs)
+		switch {
+		case errors.Is(err, deadletter.ErrNotFound):
+			writeJSON(w, http.StatusNotFound, map[string]string{"error": err.Error()})
+			return
+		case errors.Is(err, manager.ErrTransportNotRunning):
+			writeJSON(w, http.StatusConflict, map[string]string{"error": err.Error()})
+			return
+		case err != nil:
+			writeJSON(w, http.StatusInternalServerError, map[string]string{"error": err.Error()})
+			return
+		}
+		logger.Info("dead letters re-injected through admin API", logging.Metadata{"transport": req.Transport, "count": len(results)})
+		writeJSON(w, http.StatusOK, results)
+	})
+	mux.HandleFunc("/admin/loglevel", func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet:
+		case http.MethodPut, http.MethodPost:
+			req := struct {
+				Level string `json:"level"`
+			}{}
+			if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+				writeJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
+				return
+			}
+			if _, ok := logLevels[req.Level]; !ok {
+				writeJSON(w, http.StatusBadRequest, map[string]string{"error": "unknown log level '" + req.Level + "'"})
+				return
+			}
+			setLogLevel(logger, req.Level)
+			logger.Warn("log level changed through admin API", logging.Metadata{"level": req.Level})
+		default:
+			w.Header().Set("Allow", "GET, PUT, POST")
+			writeJSON(w, http.StatusMethodNotAllowed, map[string]string{"error": "method not allowed"})
+			return
+		}
+		writeJSON(w, http.StatusOK, map[string]string{"level": strings.ToLower(logger.Level().String())})
+	})
+	return mux
+}
+
+func writeJSON(w http.ResponseWriter, status int, obj interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(obj)
+}
+
+// startServer starts core HTTP listener on given address
+func startServer(address string, logger *pluginlog.Logger) *http.Server {
+	return listen(address, newServeMux(logger), "core HTTP listener", logger)
+}
+
+func stopServer(srv *http.Server, logger *pluginlog.Logger) {
+	shutdown(srv, "core HTTP listener", logger)
+}
+
+// listen serves given handler on address in background
+func listen(address string, handler http.Handler, name string, logger *pluginlog.Logger) *http.Server {
+	srv := &http.Server{
+		Addr:              address,
+		Handler:           handler,
+		ReadHeaderTimeout: 5 * time.Second,
+	}
+	go func() {
+		if err := srv.ListenAndServe(); err != http.ErrServerClosed {
+			logger.Error(name+" failed", logging.Metadata{"address": address, "error": err})
+		}
+	}()
+	logger.Info(name+" started", logging.Metadata{"address": address})
+	return srv
+}
+
+func shutdown(srv *http.Server, name string, logger *pluginlog.Logger) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := srv.Shutdown(ctx); err != nil {
+		logger.Error("failed to shut down "+name, logging.Metadata{"error": err})
+	}
+}
@@ -0,0 +1,111 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/infrawatch/apputils/logging"
+	"github.com/infrawatch/sg-core/cmd/manager"
+	"github.com/infrawatch/sg-core/pkg/config"
+	"github.com/pkg/errors"
+)
+
+// validate subcommand loads configuration file and configures all plugins without
+// running them. Report of each plugin is printed and non-zero exit code is returned
+// when any part of the configuration is invalid
+
+func runValidate(args []string) int {
+	flags := flag.NewFlagSet("validate", flag.ExitOnError)
+	configPath := flags.String("config", "/etc/sg-core.conf.yaml", "configuration file or directory path")
+	flags.Usage = func() {
+		fmt.Printf("Usage: %s validate [OPTIONS]\n\nLoads configuration and configures all plugins without running them.\n\nAvailable options:\n", os.Args[0])
+		flags.PrintDefaults()
+	}
+	_ = flags.Parse(args)
+
+	// plugins log only errors, so that the report stays readable
+	logger, err := logging.NewLogger(logging.ERROR, "console")
+	if err != nil {
+		fmt.Printf("failed initializing logger: %s\n", err)
+		return 1
+	}
+	manager.SetLogger(logger)
+
+	if !validate(*configPath, os.Stdout) {
+		return 1
+	}
+	return 0
+}
+
+// validate prints validation report of configuration file to out. Returns false if the configuration is invalid
+func validate(path string, out io.Writer) bool {
+	conf, err := readConfiguration(path)
+	if !report(out, "configuration "+path, "", err) {
+		return false
+	}
+	manager.SetPluginDir(conf.PluginDir)
+
+	valid := true
+	for i, tConfig := range conf.Transports {
+		tPath := fmt.Sprintf("transports[%d]", i)
+		err := manager.ValidateTransport(tConfig.Name, tConfig.Config)
+		valid = report(out, part(tPath, tConfig.Name, tConfig.Instance), tPath+".config.", err) && valid
+		for j, hConfig := range tConfig.Handlers {
+			hPath := fmt.Sprintf("%s.handlers[%d]", tPath, j)
+			err := manager.ValidateHandler(hConfig.Name, hConfig.Config)
+			valid = report(out, part(hPath, hConfig.Name, hConfig.Instance), hPath+".config.", err) && valid
+		}
+		for j, pConfig := range tConfig.Processors {
+			pPath := fmt.Sprintf("%s.processors[%d]", tPath, j)
+			err := manager.ValidateProcessor(pConfig.Name, pConfig.Config)
+			valid = report(out, part(pPath, pConfig.Name, pConfig.Instance), pPath+".config.", err) && valid
+		}
+	}
+	for i, pConfig := range conf.Processors {
+		pPath := fmt.Sprintf("processors[%d]", i)
+		err := manager.ValidateProcessor(pConfig.Name, pConfig.Config)
+		valid = report(out, part(pPath, pConfig.Name, pConfig.Instance), pPath+".config.", err) && valid
+	}
+	for i, aConfig := range conf.Applications {
+		aPath := fmt.Sprintf("applications[%d]", i)
+		err := manager.ValidateApplication(aConfig.Name, aConfig.Config, aConfig.Filter)
+		valid = report(out, part(aPath, aConfig.Name, aConfig.Instance), aPath+".config.", err) && valid
+	}
+
+	if valid {
+		fmt.Fprintln(out, "configuration is valid")
+	} else {
+		fmt.Fprintln(out, "configuration is invalid")
+	}
+	return valid
+}
+
+// part describes configuration block of plugin in the report
+func part(path string, name string, instance string) string {
+	if instance != "" {
+		return fmt.Sprintf("%s %s (%s)", path, name, instance)
+	}
+	return path + " " + name
+}
+
+// report prints result of validation of one configuration part. Invalid fields are printed
+// with given path prefix. Returns true if err is nil
+func report(out io.Writer, part string, fieldPrefix string, err error) bool {
+	if err == nil {
+		fmt.Fprintf(out, "OK      %s\n", part)
+		return true
+	}
+
+	fmt.Fprintf(out, "FAILED  %s\n", part)
+	var verr *config.ValidationError
+	if errors.As(err, &verr) {
+		for _, field := range verr.Fields {
+			fmt.Fprintf(out, "        %s%s: missing or incorrect\n", fieldPrefix, field)
+		}
+		return false
+	}
+	fmt.Fprintf(out, "        %s\n", err)
+	return false
+}
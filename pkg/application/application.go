@@ -11,7 +11,7 @@ import (
 
 // Application describes application plugin interfaces.
 // Configuration bytes are passed into the Config() function as a sequence of bytes in yaml format. It is recommended to use the config.ParseConfig() method to parse the input. This is a convenience method that uses the validations library to validate the input and provide specific feedback.
-// The main process must be implemented in the Run() method and respect the context.Done() signal. If the plugin wishes to send an exit signal to sg-core, it must send a true value to the boolean channel. This should be done in the case of plugin failure.
+// The main process must be implemented in the Run() method and respect the context.Done() signal. If the plugin fails, it must send a true value to the boolean channel. sg-core then stops the plugin and restarts it by calling Run() again according to its restart policy.
 type Application interface {
 	Config([]byte) error
 	Run(context.Context, chan bool)
@@ -33,9 +33,32 @@ type MetricReceiver interface {
 	)
 }
 
+// MetricBatchReceiver Receives metrics from the internal metrics bus in batches as they were published by handlers.
+// Applications implementing both MetricReceiver and MetricBatchReceiver receive metrics only through ReceiveMetrics
+type MetricBatchReceiver interface {
+	Application
+	// ReceiveMetrics is called every time a batch of metrics is received on the internal metrics bus. The batch is shared with other applications and must not be modified
+	ReceiveMetrics([]data.Metric)
+}
+
 // EventReceiver Receive events from the internal event bus
 type EventReceiver interface {
 	Application
 	// ReceiveEvent is called whenever an event is broadcast on the event bus.
 	ReceiveEvent(data.Event)
 }
+
+// Flusher can be implemented by applications which keep data in memory. On shutdown, after all data were
+// delivered from the internal buses, Flush is called while the application is still running. Flush should
+// persist remaining data and return before the context deadline
+type Flusher interface {
+	Application
+	Flush(context.Context) error
+}
+
+// StatusReporter can be implemented by applications which are able to report their own health.
+// Status is polled by sg-core while the application is running. Returning an error marks the application as failed
+type StatusReporter interface {
+	Application
+	Status() error
+}
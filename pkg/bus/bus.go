@@ -1,12 +1,17 @@
 package bus
 
 import (
+	"context"
 	"sync"
 	"time"
 
 	"github.com/infrawatch/sg-core/pkg/data"
 )
 
+// Each subscriber of a bus gets its own bounded queue served by a fixed pool of workers.
+// Publishing to a bus only places data to subscribers' queues. What happens when
+// a queue is full is decided by overflow policy of the bus.
+
 // EventReceiveFunc callback type for receiving events from the event bus
 type EventReceiveFunc func(data.Event)
 
@@ -15,45 +20,76 @@ type EventPublishFunc func(data.Event)
 
 // EventBus bus for data.Event type
 type EventBus struct {
-	subscribers []EventReceiveFunc
+	subscribers []*queue[data.Event]
+	conf        QueueConfig
+	lastID      int
 	rw          sync.RWMutex
-	wg          sync.WaitGroup
 }
 
-// Subscribe subscribe to bus
-func (eb *EventBus) Subscribe(rf EventReceiveFunc) {
+// SetQueueConfig set configuration of queues created for subsequent subscribers
+func (eb *EventBus) SetQueueConfig(conf QueueConfig) {
+	eb.rw.Lock()
+	defer eb.rw.Unlock()
+	eb.conf = conf
+}
+
+// Subscribe subscribe to bus. Returned ID can be used to unsubscribe
+func (eb *EventBus) Subscribe(rf EventReceiveFunc) int {
 	eb.rw.Lock()
 	defer eb.rw.Unlock()
-	eb.subscribers = append(eb.subscribers, rf)
+	eb.lastID++
+	eb.subscribers = append(eb.subscribers, newQueue(eb.lastID, eb.conf, rf))
+	return eb.lastID
+}
+
+// Unsubscribe remove subscriber with given ID from bus. Data already queued for the subscriber are delivered before return
+func (eb *EventBus) Unsubscribe(id int) {
+	eb.rw.Lock()
+	var q *queue[data.Event]
+	for i := range eb.subscribers {
+		if eb.subscribers[i].id == id {
+			q = eb.subscribers[i]
+			eb.subscribers = append(eb.subscribers[:i:i], eb.subscribers[i+1:]...)
+			break
+		}
+	}
+	eb.rw.Unlock()
+	if q != nil {
+		q.close()
+	}
 }
 
 // Publish publish to bus
 func (eb *EventBus) Publish(e data.Event) {
 	eb.rw.RLock()
-
-	for _, rf := range eb.subscribers {
-		go func(rf EventReceiveFunc) {
-			rf(e)
-		}(rf)
+	defer eb.rw.RUnlock()
+	for _, q := range eb.subscribers {
+		q.push(e)
 	}
-	eb.rw.RUnlock()
 }
 
-// PublishBlocking publish to bus, but block
-// until all application plugins process the data
-// before publishing more events.
-func (eb *EventBus) PublishBlocking(e data.Event) {
-	eb.rw.RLock()
+// Drain waits until all published events are received by subscribers or context is done
+func (eb *EventBus) Drain(ctx context.Context) error {
+	return drain(ctx, func() int64 {
+		eb.rw.RLock()
+		defer eb.rw.RUnlock()
+		var res int64
+		for _, q := range eb.subscribers {
+			res += q.pending.Load()
+		}
+		return res
+	})
+}
 
-	for _, rf := range eb.subscribers {
-		eb.wg.Add(1)
-		go func(rf EventReceiveFunc, wg *sync.WaitGroup) {
-			defer wg.Done()
-			rf(e)
-		}(rf, &eb.wg)
+// Stats returns queue statistics of all subscribers
+func (eb *EventBus) Stats() []SubscriberStats {
+	eb.rw.RLock()
+	defer eb.rw.RUnlock()
+	res := make([]SubscriberStats, 0, len(eb.subscribers))
+	for _, q := range eb.subscribers {
+		res = append(res, q.stats())
 	}
-	eb.wg.Wait()
-	eb.rw.RUnlock()
+	return res
 }
 
 // MetricReceiveFunc callback type for receiving metrics
@@ -63,26 +99,132 @@ type MetricReceiveFunc func(string, float64, data.MetricType, time.Duration, flo
 // MetricPublishFunc function type for publishing to the metric bus
 type MetricPublishFunc func(string, float64, data.MetricType, time.Duration, float64, []string, []string)
 
-// MetricBus bus for data.Metric type
+// MetricBatchReceiveFunc callback type for receiving batches of metrics
+type MetricBatchReceiveFunc func([]data.Metric)
+
+// MetricBatchPublishFunc function type for publishing batches of metrics to the metric bus
+type MetricBatchPublishFunc func([]data.Metric)
+
+// Batch adapts function publishing single metric for publishing batches of metrics
+func (pf MetricPublishFunc) Batch() MetricBatchPublishFunc {
+	return func(metrics []data.Metric) {
+		for _, m := range metrics {
+			pf(m.Name, m.Time, m.Type, m.Interval, m.Value, m.LabelKeys, m.LabelVals)
+		}
+	}
+}
+
+// Single adapts function publishing batches of metrics for publishing single metric
+func (pf MetricBatchPublishFunc) Single() MetricPublishFunc {
+	return func(name string, t float64, typ data.MetricType, interval time.Duration, value float64, labelKeys []string, labelVals []string) {
+		pf([]data.Metric{{
+			Name:      name,
+			Time:      t,
+			Type:      typ,
+			Interval:  interval,
+			Value:     value,
+			LabelKeys: labelKeys,
+			LabelVals: labelVals,
+		}})
+	}
+}
+
+// MetricBus bus for data.Metric type. Metrics are queued for subscribers in batches
+// as they were published, so queue statistics of subscribers are counted in batches
 type MetricBus struct {
 	sync.RWMutex
-	subscribers []MetricReceiveFunc
+	subscribers []*queue[[]data.Metric]
+	conf        QueueConfig
+	lastID      int
 }
 
-// Subscribe subscribe to bus
-func (mb *MetricBus) Subscribe(rf MetricReceiveFunc) {
+// SetQueueConfig set configuration of queues created for subsequent subscribers
+func (mb *MetricBus) SetQueueConfig(conf QueueConfig) {
 	mb.Lock()
 	defer mb.Unlock()
-	mb.subscribers = append(mb.subscribers, rf)
+	mb.conf = conf
+}
+
+// Subscribe subscribe to bus. Returned ID can be used to unsubscribe
+func (mb *MetricBus) Subscribe(rf MetricReceiveFunc) int {
+	return mb.SubscribeBatch(func(metrics []data.Metric) {
+		for _, m := range metrics {
+			rf(m.Name, m.Time, m.Type, m.Interval, m.Value, m.LabelKeys, m.LabelVals)
+		}
+	})
+}
+
+// SubscribeBatch subscribe to bus receiving metrics in batches. Returned ID can be used to unsubscribe
+func (mb *MetricBus) SubscribeBatch(rf MetricBatchReceiveFunc) int {
+	mb.Lock()
+	defer mb.Unlock()
+	mb.lastID++
+	mb.subscribers = append(mb.subscribers, newQueue(mb.lastID, mb.conf, rf))
+	return mb.lastID
+}
+
+// Unsubscribe remove subscriber with given ID from bus. Data already queued for the subscriber are delivered before return
+func (mb *MetricBus) Unsubscribe(id int) {
+	mb.Lock()
+	var q *queue[[]data.Metric]
+	for i := range mb.subscribers {
+		if mb.subscribers[i].id == id {
+			q = mb.subscribers[i]
+			mb.subscribers = append(mb.subscribers[:i:i], mb.subscribers[i+1:]...)
+			break
+		}
+	}
+	mb.Unlock()
+	if q != nil {
+		q.close()
+	}
 }
 
 // Publish publish to bus
 func (mb *MetricBus) Publish(name string, time float64, mType data.MetricType, interval time.Duration, value float64, labelKeys []string, labelVals []string) {
+	mb.PublishBatch([]data.Metric{{
+		Name:      name,
+		Time:      time,
+		Type:      mType,
+		Interval:  interval,
+		Value:     value,
+		LabelKeys: labelKeys,
+		LabelVals: labelVals,
+	}})
+}
+
+// PublishBatch publish batch of metrics to bus. Subscribers must not modify received batch
+func (mb *MetricBus) PublishBatch(metrics []data.Metric) {
+	if len(metrics) == 0 {
+		return
+	}
+	mb.RLock()
+	defer mb.RUnlock()
+	for _, q := range mb.subscribers {
+		q.push(metrics)
+	}
+}
+
+// Drain waits until all published metrics are received by subscribers or context is done
+func (mb *MetricBus) Drain(ctx context.Context) error {
+	return drain(ctx, func() int64 {
+		mb.RLock()
+		defer mb.RUnlock()
+		var res int64
+		for _, q := range mb.subscribers {
+			res += q.pending.Load()
+		}
+		return res
+	})
+}
+
+// Stats returns queue statistics of all subscribers
+func (mb *MetricBus) Stats() []SubscriberStats {
 	mb.RLock()
-	for _, rf := range mb.subscribers {
-		go func(rf MetricReceiveFunc) {
-			rf(name, time, mType, interval, value, labelKeys, labelVals)
-		}(rf)
+	defer mb.RUnlock()
+	res := make([]SubscriberStats, 0, len(mb.subscribers))
+	for _, q := range mb.subscribers {
+		res = append(res, q.stats())
 	}
-	mb.RUnlock()
+	return res
 }
@@ -1,5 +1,13 @@
 package bus
 
+import (
+	"testing"
+	"time"
+
+	"github.com/infrawatch/sg-core/pkg/data"
+	"github.com/stretchr/testify/assert"
+)
+
 // var sampleMetrics []data.Metric = []data.Metric{
 // 	{
 // 		Name:  "collectd_metric_type0_samples_total",
@@ -106,3 +114,66 @@ package bus
 // 		mBus.Publish(sampleMetrics)
 // 	}
 // }
+
+func TestUnsubscribe(t *testing.T) {
+	t.Run("event bus", func(t *testing.T) {
+		eb := EventBus{}
+		received := make(chan string, 2)
+		first := eb.Subscribe(func(data.Event) { received <- "first" })
+		eb.Subscribe(func(data.Event) { received <- "second" })
+
+		eb.Unsubscribe(first)
+		eb.Publish(data.Event{})
+		assert.Equal(t, "second", <-received)
+		assert.Empty(t, received)
+	})
+
+	t.Run("metric bus", func(t *testing.T) {
+		mb := MetricBus{}
+		received := make(chan string, 2)
+		mb.Subscribe(func(string, float64, data.MetricType, time.Duration, float64, []string, []string) {
+			received <- "first"
+		})
+		second := mb.Subscribe(func(string, float64, data.MetricType, time.Duration, float64, []string, []string) {
+			received <- "second"
+		})
+
+		mb.Unsubscribe(second)
+		mb.Unsubscribe(second)
+		mb.Publish("metric", 0, data.GAUGE, 0, 1, nil, nil)
+		assert.Equal(t, "first", <-received)
+		time.Sleep(10 * time.Millisecond)
+		assert.Empty(t, received)
+	})
+}
+
+func TestMetricBatches(t *testing.T) {
+	mb := MetricBus{}
+	batches := make(chan []data.Metric, 2)
+	single := make(chan string, 3)
+	batchID := mb.SubscribeBatch(func(metrics []data.Metric) { batches <- metrics })
+	singleID := mb.Subscribe(func(name string, _ float64, _ data.MetricType, _ time.Duration, _ float64, _ []string, _ []string) {
+		single <- name
+	})
+
+	mb.PublishBatch([]data.Metric{{Name: "first"}, {Name: "second"}})
+	mb.PublishBatch(nil)
+	mb.Publish("third", 0, data.GAUGE, 0, 1, nil, nil)
+	mb.Unsubscribe(batchID)
+	mb.Unsubscribe(singleID)
+
+	assert.Equal(t, []data.Metric{{Name: "first"}, {Name: "second"}}, <-batches)
+	assert.Equal(t, []data.Metric{{Name: "third", Type: data.GAUGE, Value: 1}}, <-batches)
+	assert.Equal(t, "first", <-single)
+	assert.Equal(t, "second", <-single)
+	assert.Equal(t, "third", <-single)
+
+	t.Run("publish function adapter", func(t *testing.T) {
+		names := []string{}
+		var pf MetricPublishFunc = func(name string, _ float64, _ data.MetricType, _ time.Duration, _ float64, _ []string, _ []string) {
+			names = append(names, name)
+		}
+		pf.Batch()([]data.Metric{{Name: "first"}, {Name: "second"}})
+		assert.Equal(t, []string{"first", "second"}, names)
+	})
+}
@@ -0,0 +1,161 @@
+package bus
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// OverflowPolicy decides what happens to published data when subscriber's queue is full
+type OverflowPolicy int
+
+const (
+	// DropNewest discards data being published
+	DropNewest OverflowPolicy = iota
+	// DropOldest discards the oldest data waiting in the queue to make space for data being published
+	DropOldest
+	// Block blocks publisher until there is space in the queue
+	Block
+)
+
+var (
+	overflowStr = map[string]OverflowPolicy{
+		"dropnewest": DropNewest,
+		"dropoldest": DropOldest,
+		"block":      Block,
+	}
+)
+
+// String get string representation of overflow policy
+func (op OverflowPolicy) String() string {
+	return [...]string{"dropNewest", "dropOldest", "block"}[op]
+}
+
+// OverflowPolicyFromString get overflow policy from string. Unknown values result in Block, so that
+// data are dropped only when requested
+func OverflowPolicyFromString(s string) OverflowPolicy {
+	if op, ok := overflowStr[strings.ToLower(s)]; ok {
+		return op
+	}
+	return Block
+}
+
+// default queue parameters
+const (
+	DefaultQueueSize = 1024
+	DefaultWorkers   = 1
+)
+
+// QueueConfig describes queue created for each subscriber of a bus
+type QueueConfig struct {
+	QueueSize int    `yaml:"queueSize" validate:"min=0"` // maximum number of items waiting for subscriber
+	Workers   int    `yaml:"workers" validate:"min=0"`   // number of goroutines delivering data to subscriber, order is kept only with single worker
+	Overflow  string `yaml:"overflow" validate:"omitempty,oneof=block dropNewest dropOldest"`
+}
+
+// SubscriberStats queue statistics of one subscriber
+type SubscriberStats struct {
+	ID      int    `json:"id"`
+	Depth   int    `json:"depth"`
+	Dropped uint64 `json:"dropped"`
+}
+
+// queue is bounded subscriber queue served by fixed number of workers
+type queue[T any] struct {
+	id      int
+	items   chan T
+	policy  OverflowPolicy
+	dropped atomic.Uint64
+	pending atomic.Int64 // items queued or being received
+	workers sync.WaitGroup
+}
+
+func newQueue[T any](id int, conf QueueConfig, receive func(T)) *queue[T] {
+	size := conf.QueueSize
+	if size <= 0 {
+		size = DefaultQueueSize
+	}
+	workers := conf.Workers
+	if workers <= 0 {
+		workers = DefaultWorkers
+	}
+
+	q := &queue[T]{
+		id:     id,
+		items:  make(chan T, size),
+		policy: OverflowPolicyFromString(conf.Overflow),
+	}
+	for i := 0; i < workers; i++ {
+		q.workers.Add(1)
+		go func() {
+			defer q.workers.Done()
+			for item := range q.items {
+				receive(item)
+				q.pending.Add(-1)
+			}
+		}()
+	}
+	return q
+}
+
+func (q *queue[T]) push(item T) {
+	q.pending.Add(1)
+	switch q.policy {
+	case Block:
+		q.items <- item
+	case DropOldest:
+		for {
+			select {
+			case q.items <- item:
+				return
+			default:
+			}
+			select {
+			case <-q.items:
+				q.pending.Add(-1)
+				q.dropped.Add(1)
+			default:
+			}
+		}
+	default:
+		select {
+		case q.items <- item:
+		default:
+			q.pending.Add(-1)
+			q.dropped.Add(1)
+		}
+	}
+}
+
+// drainPollInterval interval in which queues are checked while draining
+const drainPollInterval = 10 * time.Millisecond
+
+// drain waits until subscribers receive all queued items or context is done
+func drain(ctx context.Context, pending func() int64) error {
+	tick := time.NewTicker(drainPollInterval)
+	defer tick.Stop()
+	for pending() > 0 {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-tick.C:
+		}
+	}
+	return nil
+}
+
+// close stops accepting data and waits until workers deliver all queued data
+func (q *queue[T]) close() {
+	close(q.items)
+	q.workers.Wait()
+}
+
+func (q *queue[T]) stats() SubscriberStats {
+	return SubscriberStats{
+		ID:      q.id,
+		Depth:   len(q.items),
+		Dropped: q.dropped.Load(),
+	}
+}
@@ -0,0 +1,143 @@
+package bus
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/infrawatch/sg-core/pkg/data"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestOverflowPolicyFromString(t *testing.T) {
+	assert.Equal(t, Block, OverflowPolicyFromString("block"))
+	assert.Equal(t, DropOldest, OverflowPolicyFromString("dropOldest"))
+	assert.Equal(t, DropNewest, OverflowPolicyFromString("dropNewest"))
+	assert.Equal(t, Block, OverflowPolicyFromString(""))
+	assert.Equal(t, "dropOldest", DropOldest.String())
+}
+
+func TestQueueOverflow(t *testing.T) {
+	// subscriber blocks until released so that queue fills up
+	subscribe := func(eb *EventBus, release chan struct{}, received chan string) int {
+		return eb.Subscribe(func(e data.Event) {
+			<-release
+			received <- e.Message
+		})
+	}
+
+	t.Run("drop newest", func(t *testing.T) {
+		eb := EventBus{}
+		eb.SetQueueConfig(QueueConfig{QueueSize: 1, Overflow: "dropNewest"})
+		release := make(chan struct{})
+		received := make(chan string, 4)
+		id := subscribe(&eb, release, received)
+
+		eb.Publish(data.Event{Message: "1"})
+		assert.Eventually(t, func() bool { return eb.Stats()[0].Depth == 0 }, time.Second, time.Millisecond)
+		eb.Publish(data.Event{Message: "2"})
+		eb.Publish(data.Event{Message: "3"})
+		assert.Equal(t, []SubscriberStats{{ID: id, Depth: 1, Dropped: 1}}, eb.Stats())
+
+		close(release)
+		eb.Unsubscribe(id)
+		assert.Equal(t, "1", <-received)
+		assert.Equal(t, "2", <-received)
+		assert.Empty(t, received)
+	})
+
+	t.Run("drop oldest", func(t *testing.T) {
+		eb := EventBus{}
+		eb.SetQueueConfig(QueueConfig{QueueSize: 1, Overflow: "dropOldest"})
+		release := make(chan struct{})
+		received := make(chan string, 4)
+		id := subscribe(&eb, release, received)
+
+		eb.Publish(data.Event{Message: "1"})
+		assert.Eventually(t, func() bool { return eb.Stats()[0].Depth == 0 }, time.Second, time.Millisecond)
+		eb.Publish(data.Event{Message: "2"})
+		eb.Publish(data.Event{Message: "3"})
+		assert.Equal(t, []SubscriberStats{{ID: id, Depth: 1, Dropped: 1}}, eb.Stats())
+
+		close(release)
+		eb.Unsubscribe(id)
+		assert.Equal(t, "1", <-received)
+		assert.Equal(t, "3", <-received)
+		assert.Empty(t, received)
+	})
+
+	t.Run("block", func(t *testing.T) {
+		eb := EventBus{}
+		eb.SetQueueConfig(QueueConfig{QueueSize: 1, Overflow: "block"})
+		release := make(chan struct{})
+		received := make(chan string, 4)
+		id := subscribe(&eb, release, received)
+
+		eb.Publish(data.Event{Message: "1"})
+		eb.Publish(data.Event{Message: "2"})
+		published := make(chan struct{})
+		go func() {
+			eb.Publish(data.Event{Message: "3"})
+			close(published)
+		}()
+		select {
+		case <-published:
+			t.Fatal("publish did not block on full queue")
+		case <-time.After(20 * time.Millisecond):
+		}
+
+		close(release)
+		<-published
+		eb.Unsubscribe(id)
+		assert.Equal(t, "1", <-received)
+		assert.Equal(t, "2", <-received)
+		assert.Equal(t, "3", <-received)
+		assert.Empty(t, eb.Stats())
+	})
+}
+
+func TestQueueWorkers(t *testing.T) {
+	mb := MetricBus{}
+	mb.SetQueueConfig(QueueConfig{Workers: 4})
+	release := make(chan struct{})
+	started := make(chan struct{}, 4)
+	id := mb.Subscribe(func(string, float64, data.MetricType, time.Duration, float64, []string, []string) {
+		started <- struct{}{}
+		<-release
+	})
+
+	for i := 0; i < 4; i++ {
+		mb.Publish("metric", 0, data.GAUGE, 0, 1, nil, nil)
+	}
+	// all four metrics are being processed concurrently
+	for i := 0; i < 4; i++ {
+		<-started
+	}
+	close(release)
+	mb.Unsubscribe(id)
+	assert.Empty(t, mb.Stats())
+}
+
+func TestDrain(t *testing.T) {
+	eb := EventBus{}
+	release := make(chan struct{})
+	received := 0
+	eb.Subscribe(func(data.Event) {
+		<-release
+		received++
+	})
+	for i := 0; i < 3; i++ {
+		eb.Publish(data.Event{})
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	assert.Equal(t, context.DeadlineExceeded, eb.Drain(ctx))
+
+	close(release)
+	assert.NoError(t, eb.Drain(context.Background()))
+	assert.Equal(t, 3, received)
+
+	mb := MetricBus{}
+	assert.NoError(t, mb.Drain(context.Background()))
+}
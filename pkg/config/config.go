@@ -16,6 +16,15 @@ var (
 	Validate = validator.New()
 )
 
+// ValidationError is returned by ParseConfig when configuration fields are missing or incorrect
+type ValidationError struct {
+	Fields []string // paths of invalid fields, eg. "connection.address"
+}
+
+func (ve *ValidationError) Error() string {
+	return fmt.Sprintf("missing or incorrect configuration fields --  %s --", strings.Join(ve.Fields, " , "))
+}
+
 // ParseConfig parses and validates input into config object
 func ParseConfig(r io.Reader, config interface{}) error {
 	configBytes, err := io.ReadAll(r)
@@ -37,7 +46,7 @@ func ParseConfig(r io.Reader, config interface{}) error {
 			for _, fe := range e {
 				missingFields = append(missingFields, setCamelCase(fe.Namespace()))
 			}
-			return fmt.Errorf("missing or incorrect configuration fields --  %s --", strings.Join(missingFields, " , "))
+			return &ValidationError{Fields: missingFields}
 		}
 		return errors.Wrap(err, "error while validating configuration")
 	}
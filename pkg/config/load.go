@@ -0,0 +1,214 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+	"gopkg.in/yaml.v3"
+)
+
+// configuration can be split into multiple files. Path given to Load is either a file or
+// a directory whose *.yaml and *.yml files are loaded in lexical order. Each file can load
+// more files through top-level include list, paths are relative to the including file and
+// can contain globs. Every file is loaded once, even when it is included multiple times
+
+// IncludeKey top-level key listing files included by configuration file
+const IncludeKey = "include"
+
+type source struct {
+	path   string
+	values map[string]interface{}
+}
+
+// Load loads configuration from file or directory, resolves references in each file (see Resolve)
+// and merges them to single YAML document. Values of listKeys are concatenated in load order,
+// other values are merged recursively and setting a key to different values in two files is an error
+func Load(path string, listKeys ...string) ([]byte, error) {
+	sources, err := loadSources(path, map[string]bool{})
+	if err != nil {
+		return nil, err
+	}
+
+	lists := map[string]bool{}
+	for _, key := range listKeys {
+		lists[key] = true
+	}
+	merged := map[string]interface{}{}
+	origins := map[string]string{}
+	for _, src := range sources {
+		err := merge(merged, src.values, "", src.path, lists, origins)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	out, err := yaml.Marshal(merged)
+	if err != nil {
+		return nil, errors.Wrap(err, "marshalling merged config yaml")
+	}
+	return out, nil
+}
+
+// configFiles lists configuration files in path
+func configFiles(path string) ([]string, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+	if !info.IsDir() {
+		return []string{path}, nil
+	}
+
+	entries, err := os.ReadDir(path)
+	if err != nil {
+		return nil, err
+	}
+	files := []string{}
+	for _, entry := range entries {
+		ext := filepath.Ext(entry.Name())
+		if entry.IsDir() || (ext != ".yaml" && ext != ".yml") {
+			continue
+		}
+		files = append(files, filepath.Join(path, entry.Name()))
+	}
+	return files, nil
+}
+
+// loadSources loads files in path followed by files they include
+func loadSources(path string, loaded map[string]bool) ([]source, error) {
+	files, err := configFiles(path)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed opening configuration file")
+	}
+
+	sources := []source{}
+	for _, file := range files {
+		abs, err := filepath.Abs(file)
+		if err != nil {
+			return nil, err
+		}
+		if loaded[abs] {
+			continue
+		}
+		loaded[abs] = true
+
+		blob, err := os.ReadFile(file)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed opening configuration file")
+		}
+		blob, err = Resolve(blob)
+		if err != nil {
+			return nil, errors.Wrap(err, file)
+		}
+		values := map[string]interface{}{}
+		err = yaml.Unmarshal(blob, &values)
+		if err != nil {
+			return nil, errors.Wrap(err, file)
+		}
+
+		includes, err := includeList(values[IncludeKey])
+		if err != nil {
+			return nil, errors.Wrap(err, file)
+		}
+		delete(values, IncludeKey)
+		sources = append(sources, source{path: file, values: values})
+
+		for _, include := range includes {
+			if !filepath.IsAbs(include) {
+				include = filepath.Join(filepath.Dir(file), include)
+			}
+			matches := []string{include}
+			if strings.ContainsAny(include, "*?[") {
+				matches, err = filepath.Glob(include)
+				if err != nil {
+					return nil, errors.Wrapf(err, "%s: invalid include pattern", file)
+				}
+			}
+			for _, match := range matches {
+				included, err := loadSources(match, loaded)
+				if err != nil {
+					return nil, err
+				}
+				sources = append(sources, included...)
+			}
+		}
+	}
+	return sources, nil
+}
+
+func includeList(value interface{}) ([]string, error) {
+	if value == nil {
+		return nil, nil
+	}
+	list, ok := value.([]interface{})
+	if !ok {
+		return nil, errors.Errorf("%s has to be a list of paths", IncludeKey)
+	}
+	includes := []string{}
+	for _, item := range list {
+		path, ok := item.(string)
+		if !ok {
+			return nil, errors.Errorf("%s has to be a list of paths", IncludeKey)
+		}
+		includes = append(includes, path)
+	}
+	return includes, nil
+}
+
+// merge merges values loaded from file to dst. Origins keep file which set each key
+func merge(dst map[string]interface{}, src map[string]interface{}, prefix string, file string, lists map[string]bool, origins map[string]string) error {
+	keys := make([]string, 0, len(src))
+	for key := range src {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		value := src[key]
+		path := prefix + key
+		if prefix == "" && lists[key] {
+			if value == nil {
+				continue
+			}
+			list, ok := value.([]interface{})
+			if !ok {
+				return errors.Errorf("%s: %s has to be a list", file, path)
+			}
+			existing, _ := dst[key].([]interface{})
+			dst[key] = append(existing, list...)
+			continue
+		}
+
+		srcMap, srcIsMap := value.(map[string]interface{})
+		current, exists := dst[key]
+		if !exists {
+			origins[path] = file
+			if srcIsMap {
+				dstMap := map[string]interface{}{}
+				dst[key] = dstMap
+				if err := merge(dstMap, srcMap, path+".", file, lists, origins); err != nil {
+					return err
+				}
+				continue
+			}
+			dst[key] = value
+			continue
+		}
+
+		dstMap, dstIsMap := current.(map[string]interface{})
+		if srcIsMap && dstIsMap {
+			if err := merge(dstMap, srcMap, path+".", file, lists, origins); err != nil {
+				return err
+			}
+			continue
+		}
+		if !reflect.DeepEqual(current, value) {
+			return errors.Errorf("conflicting values of %s in %s and %s", path, origins[path], file)
+		}
+	}
+	return nil
+}
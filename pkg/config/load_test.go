@@ -0,0 +1,127 @@
+package config
+
+import (
+	"os"
+	"path"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v3"
+)
+
+func writeFiles(t *testing.T, dir string, files map[string]string) {
+	for name, content := range files {
+		require.NoError(t, os.MkdirAll(path.Dir(path.Join(dir, name)), 0700))
+		require.NoError(t, os.WriteFile(path.Join(dir, name), []byte(content), 0600))
+	}
+}
+
+func load(t *testing.T, p string) map[string]interface{} {
+	blob, err := Load(p, "transports", "applications")
+	require.NoError(t, err)
+	values := map[string]interface{}{}
+	require.NoError(t, yaml.Unmarshal(blob, &values))
+	return values
+}
+
+func TestLoad(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"20-ceilometer.yaml": `
+transports:
+  - name: socket
+    config: {path: /tmp/ceilometer}
+applications:
+  - name: prometheus
+`,
+		"10-collectd.yml": `
+logLevel: debug
+eventBus:
+  queueSize: 10
+transports:
+  - name: socket
+    config: {path: /tmp/collectd}
+`,
+		"30-logs.yaml": `
+logLevel: debug
+eventBus:
+  workers: 2
+include: [logs.d/*.conf]
+`,
+		"logs.d/loki.conf": `
+applications:
+  - name: loki
+`,
+		"README":           "not a configuration file",
+		"ignored.d/a.yaml": "logLevel: error",
+	})
+
+	values := load(t, dir)
+	assert.Equal(t, "debug", values["logLevel"])
+	assert.Equal(t, map[string]interface{}{"queueSize": 10, "workers": 2}, values["eventBus"])
+	assert.NotContains(t, values, IncludeKey)
+
+	transports := values["transports"].([]interface{})
+	require.Len(t, transports, 2)
+	assert.Equal(t, "/tmp/collectd", transports[0].(map[string]interface{})["config"].(map[string]interface{})["path"])
+	assert.Equal(t, "/tmp/ceilometer", transports[1].(map[string]interface{})["config"].(map[string]interface{})["path"])
+
+	applications := values["applications"].([]interface{})
+	require.Len(t, applications, 2)
+	assert.Equal(t, "prometheus", applications[0].(map[string]interface{})["name"])
+	assert.Equal(t, "loki", applications[1].(map[string]interface{})["name"])
+
+	t.Run("single file with includes", func(t *testing.T) {
+		dir := t.TempDir()
+		writeFiles(t, dir, map[string]string{
+			"sg-core.yaml":     "pluginDir: /plugins\ninclude: [pipelines/a.yaml, pipelines]",
+			"pipelines/a.yaml": "applications: [{name: a}]\ninclude: [../sg-core.yaml]",
+			"pipelines/b.yaml": "applications: [{name: b}]",
+		})
+		values := load(t, path.Join(dir, "sg-core.yaml"))
+		assert.Equal(t, "/plugins", values["pluginDir"])
+		assert.Len(t, values["applications"], 2, "files are loaded once")
+	})
+}
+
+func TestLoadErrors(t *testing.T) {
+	for name, files := range map[string]map[string]string{
+		"conflicting scalar": {
+			"a.yaml": "logLevel: debug",
+			"b.yaml": "logLevel: info",
+		},
+		"conflicting nested scalar": {
+			"a.yaml": "http: {address: ':8080'}",
+			"b.yaml": "http: {address: ':9090'}",
+		},
+		"map and scalar": {
+			"a.yaml": "http: {address: ':8080'}",
+			"b.yaml": "http: none",
+		},
+		"missing include": {
+			"a.yaml": "include: [missing.yaml]",
+		},
+		"invalid include": {
+			"a.yaml": "include: missing.yaml",
+		},
+		"transports are not a list": {
+			"a.yaml": "transports: {name: socket}",
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			dir := t.TempDir()
+			writeFiles(t, dir, files)
+			_, err := Load(dir, "transports", "applications")
+			assert.Error(t, err)
+		})
+	}
+
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"a.yaml": "logLevel: debug",
+		"b.yaml": "logLevel: info",
+	})
+	_, err := Load(dir)
+	assert.EqualError(t, err, "conflicting values of logLevel in "+path.Join(dir, "a.yaml")+" and "+path.Join(dir, "b.yaml"))
+}
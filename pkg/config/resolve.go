@@ -0,0 +1,121 @@
+package config
+
+import (
+	"bytes"
+	"os"
+	"regexp"
+	"strings"
+
+	"github.com/pkg/errors"
+	"gopkg.in/yaml.v3"
+)
+
+// references resolved in configuration values:
+//
+//	${VAR}            value of environment variable VAR, unset variable is an error
+//	${VAR:-default}   value of environment variable VAR or default when VAR is unset or empty
+//	$${VAR}           literal ${VAR}, eg. named group in regex replacement
+//	!file /path       content of file, eg. mounted secret
+//	file:///path      content of file, only when it is the whole value
+//
+// ${1} is not a reference, numbered regex groups need no escaping
+
+const (
+	fileTag    = "!file"
+	fileScheme = "file://"
+)
+
+var envRef = regexp.MustCompile(`\$\$\{|\$\{([A-Za-z_][A-Za-z0-9_]*)(?::-([^}]*))?\}`)
+
+// Resolve replaces environment variable and secret file references in YAML document
+func Resolve(in []byte) ([]byte, error) {
+	doc := yaml.Node{}
+	err := yaml.Unmarshal(in, &doc)
+	if err != nil {
+		return nil, errors.Wrap(err, "unmarshalling config yaml")
+	}
+	if doc.Kind == 0 {
+		return in, nil
+	}
+
+	err = resolveNode(&doc)
+	if err != nil {
+		return nil, err
+	}
+
+	out := bytes.Buffer{}
+	enc := yaml.NewEncoder(&out)
+	enc.SetIndent(2)
+	err = enc.Encode(&doc)
+	if err != nil {
+		return nil, errors.Wrap(err, "marshalling resolved config yaml")
+	}
+	return out.Bytes(), nil
+}
+
+func resolveNode(node *yaml.Node) error {
+	if node.Kind != yaml.ScalarNode {
+		if node.Tag == fileTag {
+			return errors.Errorf("line %d: %s reference has to be a scalar", node.Line, fileTag)
+		}
+		for _, child := range node.Content {
+			err := resolveNode(child)
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	value, err := expandEnv(node.Value)
+	if err != nil {
+		return errors.Wrapf(err, "line %d", node.Line)
+	}
+	if value != node.Value && node.Style == 0 && node.Tag == "!!str" {
+		// type of unquoted value is resolved after expansion, eg. port: ${PORT} is a number
+		node.Tag = ""
+	}
+	node.Value = value
+
+	path := ""
+	switch {
+	case node.Tag == fileTag:
+		path = strings.TrimSpace(node.Value)
+	case strings.HasPrefix(node.Value, fileScheme):
+		path = strings.TrimPrefix(node.Value, fileScheme)
+	default:
+		return nil
+	}
+	secret, err := os.ReadFile(path)
+	if err != nil {
+		return errors.Wrapf(err, "line %d: failed reading secret file", node.Line)
+	}
+	// content of files is always string, eg. password "1234" does not turn into number
+	node.Tag = "!!str"
+	node.Style = yaml.DoubleQuotedStyle
+	node.Value = strings.TrimRight(string(secret), "\r\n")
+	return nil
+}
+
+// expandEnv replaces environment variable references in value
+func expandEnv(value string) (string, error) {
+	missing := []string{}
+	expanded := envRef.ReplaceAllStringFunc(value, func(ref string) string {
+		if ref == "$${" {
+			return "${"
+		}
+		match := envRef.FindStringSubmatch(ref)
+		if val, ok := os.LookupEnv(match[1]); ok && (val != "" || !strings.Contains(ref, ":-")) {
+			return val
+		}
+		if strings.Contains(ref, ":-") {
+			return match[2]
+		}
+		missing = append(missing, match[1])
+		return ref
+	})
+	if len(missing) > 0 {
+		return "", errors.Errorf("environment variables not set: %s (use $${ for literal ${)", strings.Join(missing, ", "))
+	}
+	return expanded, nil
+}
@@ -0,0 +1,103 @@
+package config
+
+import (
+	"bytes"
+	"os"
+	"path"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type resolvedConfig struct {
+	URI      string `yaml:"uri"`
+	Port     int    `yaml:"port"`
+	User     string `yaml:"user"`
+	Password string `yaml:"password"`
+	Key      string `yaml:"key"`
+	Literal  string `yaml:"literal"`
+	Plugins  []struct {
+		Config map[string]interface{} `yaml:"config"`
+	} `yaml:"plugins"`
+}
+
+func TestResolve(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(path.Join(dir, "password"), []byte("1234\n"), 0600))
+	require.NoError(t, os.WriteFile(path.Join(dir, "key"), []byte("secret key"), 0600))
+	t.Setenv("SG_TEST_HOST", "broker")
+	t.Setenv("SG_TEST_PORT", "5672")
+	t.Setenv("SG_TEST_EMPTY", "")
+	t.Setenv("SG_TEST_DIR", dir)
+
+	in := `
+uri: "amqp://${SG_TEST_HOST}:${SG_TEST_PORT}/collectd"
+port: ${SG_TEST_PORT}
+user: ${SG_TEST_EMPTY:-guest}
+password: !file ${SG_TEST_DIR}/password
+key: file://` + dir + `/key
+literal: $${SG_TEST_HOST}
+plugins:
+  - config:
+      password: !file ${SG_TEST_DIR}/password
+      timeout: ${SG_TEST_UNSET:-10}
+`
+	out, err := Resolve([]byte(in))
+	require.NoError(t, err)
+
+	conf := resolvedConfig{}
+	require.NoError(t, ParseConfig(bytes.NewReader(out), &conf))
+	assert.Equal(t, "amqp://broker:5672/collectd", conf.URI)
+	assert.Equal(t, 5672, conf.Port)
+	assert.Equal(t, "guest", conf.User)
+	assert.Equal(t, "1234", conf.Password)
+	assert.Equal(t, "secret key", conf.Key)
+	assert.Equal(t, "${SG_TEST_HOST}", conf.Literal)
+	require.Len(t, conf.Plugins, 1)
+	assert.Equal(t, "1234", conf.Plugins[0].Config["password"], "secrets are always strings")
+	assert.Equal(t, 10, conf.Plugins[0].Config["timeout"])
+
+	t.Run("empty document", func(t *testing.T) {
+		out, err := Resolve([]byte(""))
+		require.NoError(t, err)
+		assert.Empty(t, out)
+	})
+
+	t.Run("invalid references", func(t *testing.T) {
+		_, err := Resolve([]byte("uri: ${SG_TEST_UNSET}\nuser: ${SG_TEST_EMPTY}"))
+		assert.EqualError(t, err, "line 1: environment variables not set: SG_TEST_UNSET (use $${ for literal ${)")
+
+		_, err = Resolve([]byte("password: !file " + path.Join(dir, "missing")))
+		assert.Error(t, err)
+
+		_, err = Resolve([]byte("password: !file [a, b]"))
+		assert.EqualError(t, err, "line 1: !file reference has to be a scalar")
+	})
+
+	t.Run("regex replacement", func(t *testing.T) {
+		in := `
+relabel_configs:
+  - regex: "(.*)@(?P<host>.*)"
+    replacement: "${1}"
+  - replacement: "$${host}:$1"
+`
+		out, err := Resolve([]byte(in))
+		require.NoError(t, err)
+
+		conf := struct {
+			RelabelConfigs []struct {
+				Regex       string `yaml:"regex"`
+				Replacement string `yaml:"replacement"`
+			} `yaml:"relabel_configs"`
+		}{}
+		require.NoError(t, ParseConfig(bytes.NewReader(out), &conf))
+		require.Len(t, conf.RelabelConfigs, 2)
+		assert.Equal(t, "(.*)@(?P<host>.*)", conf.RelabelConfigs[0].Regex)
+		assert.Equal(t, "${1}", conf.RelabelConfigs[0].Replacement)
+		assert.Equal(t, "${host}:$1", conf.RelabelConfigs[1].Replacement)
+
+		_, err = Resolve([]byte(`replacement: "${host}"`))
+		assert.EqualError(t, err, "line 1: environment variables not set: host (use $${ for literal ${)")
+	})
+}
@@ -0,0 +1,225 @@
+package deadletter
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// package deadletter stores raw messages which handlers failed to handle, so that they
+// can be inspected and handled again once the handler is fixed. Each entry is stored as
+// a pair of files in the store directory: <id>.msg with the raw message and <id>.json
+// with metadata. Entry exists once its metadata file was written
+
+// DefaultMaxEntries number of entries kept in a store when not configured
+const DefaultMaxEntries = 1000
+
+const (
+	messageExt  = ".msg"
+	metadataExt = ".json"
+)
+
+// ErrNotFound returned for unknown entry
+var ErrNotFound = errors.New("dead letter not found")
+
+var idRegexp = regexp.MustCompile(`^[0-9]{20}-[0-9]{6}$`)
+
+// Config configuration of dead-letter store of a transport
+type Config struct {
+	Directory  string `yaml:"directory"`  // store is disabled when empty
+	MaxEntries int    `yaml:"maxEntries"` // oldest entries are removed when exceeded
+}
+
+// Entry metadata of stored message
+type Entry struct {
+	ID      string    `json:"id"`
+	Handler string    `json:"handler"`
+	Error   string    `json:"error"`
+	Time    time.Time `json:"time"`
+	Size    int       `json:"size"`
+}
+
+// Store of dead letters in a directory. Store is safe for concurrent use
+type Store struct {
+	dir        string
+	maxEntries int
+	lock       sync.Mutex
+	ids        []string // sorted from the oldest
+	seq        int
+}
+
+// New opens store in given directory, the directory is created when it does not exist
+func New(dir string, maxEntries int) (*Store, error) {
+	if maxEntries <= 0 {
+		maxEntries = DefaultMaxEntries
+	}
+	if err := os.MkdirAll(dir, 0750); err != nil {
+		return nil, errors.Wrap(err, "failed creating dead-letter directory")
+	}
+	s := &Store{dir: dir, maxEntries: maxEntries}
+	ids, err := s.readIDs()
+	if err != nil {
+		return nil, err
+	}
+	s.ids = ids
+	return s, nil
+}
+
+// Dir returns directory of the store
+func (s *Store) Dir() string {
+	return s.dir
+}
+
+func (s *Store) readIDs() ([]string, error) {
+	files, err := os.ReadDir(s.dir)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed reading dead-letter directory")
+	}
+	ids := []string{}
+	for _, f := range files {
+		if id := strings.TrimSuffix(f.Name(), metadataExt); id != f.Name() && idRegexp.MatchString(id) {
+			ids = append(ids, id)
+		}
+	}
+	sort.Strings(ids)
+	return ids, nil
+}
+
+func (s *Store) path(id string, ext string) string {
+	return filepath.Join(s.dir, id+ext)
+}
+
+// Add stores message which given handler failed to handle. Oldest entries are removed
+// when the store is full
+func (s *Store) Add(handler string, blob []byte, handleErr error) (Entry, error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	now := time.Now()
+	s.seq = (s.seq + 1) % 1000000
+	entry := Entry{
+		ID:      fmt.Sprintf("%020d-%06d", now.UnixNano(), s.seq),
+		Handler: handler,
+		Time:    now.UTC(),
+		Size:    len(blob),
+	}
+	if handleErr != nil {
+		entry.Error = handleErr.Error()
+	}
+
+	if err := os.WriteFile(s.path(entry.ID, messageExt), blob, 0640); err != nil {
+		return entry, errors.Wrap(err, "failed writing dead letter")
+	}
+	if err := s.writeMetadata(entry); err != nil {
+		_ = os.Remove(s.path(entry.ID, messageExt))
+		return entry, err
+	}
+	s.ids = append(s.ids, entry.ID)
+
+	for len(s.ids) > s.maxEntries {
+		s.remove(s.ids[0])
+		s.ids = s.ids[1:]
+	}
+	return entry, nil
+}
+
+// writeMetadata writes metadata file through temporary file, so that readers never see partial entry
+func (s *Store) writeMetadata(entry Entry) error {
+	blob, err := json.Marshal(entry)
+	if err != nil {
+		return errors.Wrap(err, "failed encoding dead letter")
+	}
+	tmp := s.path(entry.ID, metadataExt+".tmp")
+	if err := os.WriteFile(tmp, blob, 0640); err != nil {
+		return errors.Wrap(err, "failed writing dead letter")
+	}
+	return errors.Wrap(os.Rename(tmp, s.path(entry.ID, metadataExt)), "failed writing dead letter")
+}
+
+// List returns metadata of stored entries from the oldest
+func (s *Store) List() ([]Entry, error) {
+	s.lock.Lock()
+	ids := append([]string{}, s.ids...)
+	s.lock.Unlock()
+
+	entries := make([]Entry, 0, len(ids))
+	for _, id := range ids {
+		entry, err := s.entry(id)
+		if err != nil {
+			if errors.Is(err, ErrNotFound) {
+				continue
+			}
+			return nil, err
+		}
+		entries = append(entries, entry)
+	}
+	return entries, nil
+}
+
+func (s *Store) entry(id string) (Entry, error) {
+	entry := Entry{}
+	if !idRegexp.MatchString(id) {
+		return entry, ErrNotFound
+	}
+	blob, err := os.ReadFile(s.path(id, metadataExt))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return entry, ErrNotFound
+		}
+		return entry, errors.Wrap(err, "failed reading dead letter")
+	}
+	return entry, errors.Wrap(json.Unmarshal(blob, &entry), "failed decoding dead letter")
+}
+
+// Get returns metadata and raw message of entry
+func (s *Store) Get(id string) (Entry, []byte, error) {
+	entry, err := s.entry(id)
+	if err != nil {
+		return entry, nil, err
+	}
+	blob, err := os.ReadFile(s.path(id, messageExt))
+	if err != nil {
+		return entry, nil, errors.Wrap(err, "failed reading dead letter")
+	}
+	return entry, blob, nil
+}
+
+// Update replaces error of entry, eg. when it failed to be handled again
+func (s *Store) Update(id string, handleErr error) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	entry, err := s.entry(id)
+	if err != nil {
+		return err
+	}
+	entry.Error = handleErr.Error()
+	return s.writeMetadata(entry)
+}
+
+// Remove removes entry from the store
+func (s *Store) Remove(id string) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	for i, stored := range s.ids {
+		if stored == id {
+			s.ids = append(s.ids[:i], s.ids[i+1:]...)
+			s.remove(id)
+			return nil
+		}
+	}
+	return ErrNotFound
+}
+
+func (s *Store) remove(id string) {
+	// metadata goes first, so that entry disappears even if removing of message fails
+	_ = os.Remove(s.path(id, metadataExt))
+	_ = os.Remove(s.path(id, messageExt))
+}
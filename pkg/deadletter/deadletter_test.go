@@ -0,0 +1,82 @@
+package deadletter
+
+import (
+	"fmt"
+	"os"
+	"path"
+	"testing"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStore(t *testing.T) {
+	dir := path.Join(t.TempDir(), "transport")
+	s, err := New(dir, 3)
+	require.NoError(t, err)
+	assert.Equal(t, dir, s.Dir())
+
+	ids := []string{}
+	for i := 0; i < 4; i++ {
+		entry, err := s.Add("handler", []byte(fmt.Sprintf("msg%d", i)), fmt.Errorf("error%d", i))
+		require.NoError(t, err)
+		assert.Equal(t, 4, entry.Size)
+		ids = append(ids, entry.ID)
+	}
+
+	t.Run("oldest entries are rotated out", func(t *testing.T) {
+		entries, err := s.List()
+		require.NoError(t, err)
+		require.Len(t, entries, 3)
+		for i, entry := range entries {
+			assert.Equal(t, ids[i+1], entry.ID)
+			assert.Equal(t, "handler", entry.Handler)
+			assert.Equal(t, fmt.Sprintf("error%d", i+1), entry.Error)
+		}
+		_, _, err = s.Get(ids[0])
+		assert.True(t, errors.Is(err, ErrNotFound))
+		files, err := os.ReadDir(dir)
+		require.NoError(t, err)
+		assert.Len(t, files, 6)
+	})
+
+	t.Run("get and update", func(t *testing.T) {
+		entry, blob, err := s.Get(ids[1])
+		require.NoError(t, err)
+		assert.Equal(t, "msg1", string(blob))
+		assert.Equal(t, "error1", entry.Error)
+
+		require.NoError(t, s.Update(ids[1], fmt.Errorf("failed again")))
+		entry, _, err = s.Get(ids[1])
+		require.NoError(t, err)
+		assert.Equal(t, "failed again", entry.Error)
+
+		_, _, err = s.Get("../../etc/passwd")
+		assert.True(t, errors.Is(err, ErrNotFound))
+	})
+
+	t.Run("remove", func(t *testing.T) {
+		require.NoError(t, s.Remove(ids[2]))
+		assert.True(t, errors.Is(s.Remove(ids[2]), ErrNotFound))
+		entries, err := s.List()
+		require.NoError(t, err)
+		assert.Len(t, entries, 2)
+	})
+
+	t.Run("existing entries are loaded", func(t *testing.T) {
+		require.NoError(t, os.WriteFile(path.Join(dir, "unrelated.json"), []byte("{}"), 0600))
+		reopened, err := New(dir, 2)
+		require.NoError(t, err)
+		entries, err := reopened.List()
+		require.NoError(t, err)
+		require.Len(t, entries, 2)
+		assert.Equal(t, []string{ids[1], ids[3]}, []string{entries[0].ID, entries[1].ID})
+
+		_, err = reopened.Add("handler", []byte("msg4"), nil)
+		require.NoError(t, err)
+		entries, err = reopened.List()
+		require.NoError(t, err)
+		assert.Equal(t, ids[3], entries[0].ID, "entries loaded from directory are rotated out too")
+	})
+}
@@ -0,0 +1,148 @@
+package dump
+
+import (
+	"bufio"
+	"bytes"
+	"io"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// package dump writes raw messages received by transports to a file and reads them back for
+// replay. Each message is written as a record:
+//
+//	[time TAB] length TAB message LF
+//
+// where time of reception is in RFC 3339 format and length is the number of bytes of the message,
+// so that messages can contain newlines. Lines without length, as written by older versions,
+// are read as single messages
+
+const timeFormat = time.RFC3339Nano
+
+// Config configuration of message dumping shared by transports
+type Config struct {
+	Enabled    bool
+	Path       string
+	Timestamps bool `yaml:"timestamps"` // prefix messages with time of reception, needed for replay with original pacing
+}
+
+// Writer appends messages to dump file. Writer is safe for concurrent use
+type Writer struct {
+	file       *os.File
+	buf        *bufio.Writer
+	timestamps bool
+	lock       sync.Mutex
+	now        func() time.Time
+}
+
+// NewWriter opens dump file for appending
+func NewWriter(conf Config) (*Writer, error) {
+	file, err := os.OpenFile(conf.Path, os.O_RDWR|os.O_CREATE|os.O_APPEND, 0666)
+	if err != nil {
+		return nil, err
+	}
+	return &Writer{
+		file:       file,
+		buf:        bufio.NewWriter(file),
+		timestamps: conf.Timestamps,
+		now:        time.Now,
+	}, nil
+}
+
+// Write writes single message to the dump file
+func (w *Writer) Write(msg []byte) error {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+	if w.timestamps {
+		_, _ = w.buf.WriteString(w.now().UTC().Format(timeFormat))
+		_ = w.buf.WriteByte('\t')
+	}
+	_, _ = w.buf.WriteString(strconv.Itoa(len(msg)))
+	_ = w.buf.WriteByte('\t')
+	_, _ = w.buf.Write(msg)
+	_ = w.buf.WriteByte('\n')
+	// bufio.Writer keeps the first error, so it is enough to check flush
+	return errors.Wrap(w.buf.Flush(), "writing to dump file")
+}
+
+// Close closes the dump file
+func (w *Writer) Close() error {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+	return w.file.Close()
+}
+
+// Record message read from dump file
+type Record struct {
+	Time    time.Time // zero when message was dumped without timestamp
+	Message []byte
+}
+
+// Reader reads messages from dump file
+type Reader struct {
+	r *bufio.Reader
+}
+
+// NewReader creates reader of dump file content
+func NewReader(r io.Reader) *Reader {
+	return &Reader{r: bufio.NewReader(r)}
+}
+
+// Next returns next message, io.EOF is returned when there are no more messages. Empty lines are skipped
+func (r *Reader) Next() (Record, error) {
+	for {
+		line, err := r.r.ReadBytes('\n')
+		if err != nil && (err != io.EOF || len(line) == 0) {
+			return Record{}, err
+		}
+		if len(bytes.TrimSuffix(line, []byte{'\n'})) == 0 {
+			continue
+		}
+		return r.parseRecord(line)
+	}
+}
+
+// parseRecord parses record starting with given line, rest of message containing newlines is read
+// from the file
+func (r *Reader) parseRecord(line []byte) (Record, error) {
+	rec := Record{}
+	if i := bytes.IndexByte(line, '\t'); i > 0 && i <= len(timeFormat) {
+		if ts, err := time.Parse(timeFormat, string(line[:i])); err == nil {
+			rec.Time = ts
+			line = line[i+1:]
+		}
+	}
+
+	i := bytes.IndexByte(line, '\t')
+	length := -1
+	if i > 0 {
+		if n, err := strconv.Atoi(string(line[:i])); err == nil {
+			length = n
+		}
+	}
+	if length < 0 {
+		// message without length
+		rec.Message = bytes.TrimSuffix(line, []byte{'\n'})
+		return rec, nil
+	}
+	msg := line[i+1:]
+	if len(msg) < length {
+		// message contains newlines
+		rest := make([]byte, length-len(msg))
+		if _, err := io.ReadFull(r.r, rest); err != nil {
+			return Record{}, errors.Wrap(io.ErrUnexpectedEOF, "truncated dump record")
+		}
+		msg = append(msg, rest...)
+		if b, err := r.r.ReadByte(); err == nil && b != '\n' {
+			return Record{}, errors.New("invalid length of dump record")
+		}
+	} else if len(msg) > length && msg[length] != '\n' {
+		return Record{}, errors.New("invalid length of dump record")
+	}
+	rec.Message = msg[:length]
+	return rec, nil
+}
@@ -0,0 +1,77 @@
+package dump
+
+import (
+	"io"
+	"os"
+	"path"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func readAll(t *testing.T, r *Reader) []Record {
+	records := []Record{}
+	for {
+		rec, err := r.Next()
+		if err == io.EOF {
+			return records
+		}
+		require.NoError(t, err)
+		records = append(records, rec)
+	}
+}
+
+func TestWriteAndRead(t *testing.T) {
+	file := path.Join(t.TempDir(), "dump.txt")
+	start := time.Date(2026, 10, 17, 10, 0, 0, 123456789, time.UTC)
+
+	for _, timestamps := range []bool{false, true} {
+		w, err := NewWriter(Config{Enabled: true, Path: file, Timestamps: timestamps})
+		require.NoError(t, err)
+		now := start
+		w.now = func() time.Time {
+			now = now.Add(time.Second)
+			return now
+		}
+		require.NoError(t, w.Write([]byte(`{"values": [1]}`)))
+		require.NoError(t, w.Write([]byte("a\tb")))
+		require.NoError(t, w.Write([]byte("{\n  \"values\": [1]\n}\n")))
+		require.NoError(t, w.Close())
+	}
+
+	f, err := os.Open(file)
+	require.NoError(t, err)
+	defer f.Close()
+	assert.Equal(t, []Record{
+		{Message: []byte(`{"values": [1]}`)},
+		{Message: []byte("a\tb")},
+		{Message: []byte("{\n  \"values\": [1]\n}\n")},
+		{Time: start.Add(time.Second), Message: []byte(`{"values": [1]}`)},
+		{Time: start.Add(2 * time.Second), Message: []byte("a\tb")},
+		{Time: start.Add(3 * time.Second), Message: []byte("{\n  \"values\": [1]\n}\n")},
+	}, readAll(t, NewReader(f)))
+}
+
+func TestReader(t *testing.T) {
+	long := strings.Repeat("x", 200000)
+	r := NewReader(strings.NewReader("\nfirst\n\nnot-a-time\tsecond\n" + long))
+	assert.Equal(t, []Record{
+		{Message: []byte("first")},
+		{Message: []byte("not-a-time\tsecond")},
+		{Message: []byte(long)},
+	}, readAll(t, r), "empty lines are skipped and last line does not need newline")
+
+	r = NewReader(strings.NewReader("5\ta\nb\nc\n3\tend"))
+	assert.Equal(t, []Record{
+		{Message: []byte("a\nb\nc")},
+		{Message: []byte("end")},
+	}, readAll(t, r), "length prefixed records can contain newlines")
+
+	for _, invalid := range []string{"10\tshort\n", "2\tlong\n", "3\ta\nbcd\n"} {
+		_, err := NewReader(strings.NewReader(invalid)).Next()
+		assert.Error(t, err, invalid)
+	}
+}
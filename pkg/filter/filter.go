@@ -0,0 +1,249 @@
+package filter
+
+import (
+	"fmt"
+	"path"
+	"regexp"
+
+	"github.com/infrawatch/sg-core/pkg/data"
+	"github.com/pkg/errors"
+)
+
+// package filter implements content based filtering of data delivered from internal buses to applications
+
+// Rules allow or deny list of patterns for single attribute of metrics or events. Attribute passes
+// when allow list is empty or any of allow patterns matches and none of deny patterns matches
+type Rules struct {
+	Allow []string `yaml:"allow"`
+	Deny  []string `yaml:"deny"`
+}
+
+// LabelMatcher matches value of metric or event label. Missing label has empty value
+type LabelMatcher struct {
+	Name  string `yaml:"name" validate:"required"`
+	Op    string `yaml:"op" validate:"omitempty,oneof== != =~ !~"` // defaults to "="
+	Value string `yaml:"value"`
+}
+
+// Config describes filter block of application configuration
+type Config struct {
+	Metrics struct {
+		Name Rules `yaml:"name"` // regular expressions matching whole metric name
+	} `yaml:"metrics"`
+	Events struct {
+		Index    Rules `yaml:"index"`    // glob patterns matching event index
+		Type     Rules `yaml:"type"`     // event types: error, event, log, result, task
+		Severity Rules `yaml:"severity"` // event severities: unknown, debug, info, warning, critical
+	} `yaml:"events"`
+	Labels []LabelMatcher `yaml:"labels" validate:"dive"` // all matchers have to match for both metrics and events
+}
+
+// Filter compiled filter configuration. Nil filter passes everything
+type Filter struct {
+	names      compiledRules[*regexp.Regexp]
+	indexes    compiledRules[string]
+	types      compiledRules[data.EventType]
+	severities compiledRules[data.EventSeverity]
+	labels     []labelMatcher
+}
+
+type compiledRules[T any] struct {
+	allow []T
+	deny  []T
+}
+
+func (cr compiledRules[T]) pass(match func(T) bool) bool {
+	for _, d := range cr.deny {
+		if match(d) {
+			return false
+		}
+	}
+	if len(cr.allow) == 0 {
+		return true
+	}
+	for _, a := range cr.allow {
+		if match(a) {
+			return true
+		}
+	}
+	return false
+}
+
+type labelMatcher struct {
+	name   string
+	negate bool
+	value  string
+	regex  *regexp.Regexp
+}
+
+func (lm labelMatcher) matches(value string) bool {
+	var res bool
+	if lm.regex != nil {
+		res = lm.regex.MatchString(value)
+	} else {
+		res = value == lm.value
+	}
+	return res != lm.negate
+}
+
+var (
+	eventTypes = map[string]data.EventType{}
+	severities = map[string]data.EventSeverity{}
+)
+
+func init() {
+	for et := data.ERROR; et <= data.TASK; et++ {
+		eventTypes[et.String()] = et
+	}
+	for es := data.UNKNOWN; es <= data.CRITICAL; es++ {
+		severities[es.String()] = es
+	}
+}
+
+// New compiles filter configuration. Returns nil filter for empty configuration
+func New(conf Config) (*Filter, error) {
+	f := &Filter{}
+	var err error
+	if f.names, err = compile(conf.Metrics.Name, anchoredRegexp); err != nil {
+		return nil, errors.Wrap(err, "invalid metric name rule")
+	}
+	if f.indexes, err = compile(conf.Events.Index, glob); err != nil {
+		return nil, errors.Wrap(err, "invalid event index rule")
+	}
+	if f.types, err = compile(conf.Events.Type, lookup(eventTypes)); err != nil {
+		return nil, errors.Wrap(err, "invalid event type rule")
+	}
+	if f.severities, err = compile(conf.Events.Severity, lookup(severities)); err != nil {
+		return nil, errors.Wrap(err, "invalid event severity rule")
+	}
+	for _, lm := range conf.Labels {
+		m := labelMatcher{name: lm.Name, value: lm.Value}
+		switch lm.Op {
+		case "!=":
+			m.negate = true
+		case "=~", "!~":
+			m.negate = lm.Op == "!~"
+			if m.regex, err = anchoredRegexp(lm.Value); err != nil {
+				return nil, errors.Wrapf(err, "invalid matcher of label '%s'", lm.Name)
+			}
+		}
+		f.labels = append(f.labels, m)
+	}
+
+	if len(f.names.allow)+len(f.names.deny)+len(f.indexes.allow)+len(f.indexes.deny)+len(f.types.allow)+
+		len(f.types.deny)+len(f.severities.allow)+len(f.severities.deny)+len(f.labels) == 0 {
+		return nil, nil
+	}
+	return f, nil
+}
+
+func compile[T any](rules Rules, fn func(string) (T, error)) (compiledRules[T], error) {
+	res := compiledRules[T]{}
+	for _, a := range rules.Allow {
+		c, err := fn(a)
+		if err != nil {
+			return res, err
+		}
+		res.allow = append(res.allow, c)
+	}
+	for _, d := range rules.Deny {
+		c, err := fn(d)
+		if err != nil {
+			return res, err
+		}
+		res.deny = append(res.deny, c)
+	}
+	return res, nil
+}
+
+func anchoredRegexp(expr string) (*regexp.Regexp, error) {
+	return regexp.Compile("^(?:" + expr + ")$")
+}
+
+func glob(pattern string) (string, error) {
+	_, err := path.Match(pattern, "")
+	return pattern, err
+}
+
+func lookup[T any](values map[string]T) func(string) (T, error) {
+	return func(s string) (T, error) {
+		v, ok := values[s]
+		if !ok {
+			return v, fmt.Errorf("unknown value '%s'", s)
+		}
+		return v, nil
+	}
+}
+
+// Metric returns true if metric passes the filter
+func (f *Filter) Metric(m data.Metric) bool {
+	if f == nil {
+		return true
+	}
+	if !f.names.pass(func(re *regexp.Regexp) bool { return re.MatchString(m.Name) }) {
+		return false
+	}
+	for _, lm := range f.labels {
+		value := ""
+		for i, key := range m.LabelKeys {
+			if key == lm.name && i < len(m.LabelVals) {
+				value = m.LabelVals[i]
+				break
+			}
+		}
+		if !lm.matches(value) {
+			return false
+		}
+	}
+	return true
+}
+
+// Metrics returns metrics passing the filter. Given slice is returned when all metrics pass
+func (f *Filter) Metrics(metrics []data.Metric) []data.Metric {
+	if f == nil {
+		return metrics
+	}
+	for i := range metrics {
+		if f.Metric(metrics[i]) {
+			continue
+		}
+		// copy so that batch shared with other subscribers is not modified
+		res := append(make([]data.Metric, 0, len(metrics)-1), metrics[:i]...)
+		for _, m := range metrics[i+1:] {
+			if f.Metric(m) {
+				res = append(res, m)
+			}
+		}
+		return res
+	}
+	return metrics
+}
+
+// Event returns true if event passes the filter
+func (f *Filter) Event(e data.Event) bool {
+	if f == nil {
+		return true
+	}
+	if !f.indexes.pass(func(pattern string) bool {
+		ok, _ := path.Match(pattern, e.Index)
+		return ok
+	}) {
+		return false
+	}
+	if !f.types.pass(func(et data.EventType) bool { return et == e.Type }) {
+		return false
+	}
+	if !f.severities.pass(func(es data.EventSeverity) bool { return es == e.Severity }) {
+		return false
+	}
+	for _, lm := range f.labels {
+		value := ""
+		if v, ok := e.Labels[lm.name]; ok {
+			value = fmt.Sprint(v)
+		}
+		if !lm.matches(value) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,146 @@
+package filter
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/infrawatch/sg-core/pkg/config"
+	"github.com/infrawatch/sg-core/pkg/data"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func parse(t *testing.T, conf string) *Filter {
+	c := Config{}
+	require.NoError(t, config.ParseConfig(bytes.NewBufferString(conf), &c))
+	f, err := New(c)
+	require.NoError(t, err)
+	return f
+}
+
+func TestEmptyFilter(t *testing.T) {
+	f, err := New(Config{})
+	require.NoError(t, err)
+	assert.Nil(t, f)
+	assert.True(t, f.Metric(data.Metric{Name: "metric"}))
+	assert.True(t, f.Event(data.Event{Index: "index"}))
+	assert.Equal(t, []data.Metric{{Name: "metric"}}, f.Metrics([]data.Metric{{Name: "metric"}}))
+}
+
+func TestMetricFilter(t *testing.T) {
+	f := parse(t, `
+metrics:
+  name:
+    allow: ["collectd_.*"]
+    deny: [".*_total"]
+labels:
+  - name: host
+    op: "=~"
+    value: "compute-.*"
+  - name: plugin_instance
+    op: "!="
+    value: lo
+`)
+	metric := func(name string, host string, instance string) data.Metric {
+		return data.Metric{
+			Name:      name,
+			LabelKeys: []string{"host", "plugin_instance"},
+			LabelVals: []string{host, instance},
+		}
+	}
+
+	assert.True(t, f.Metric(metric("collectd_cpu", "compute-0", "base")))
+	assert.False(t, f.Metric(metric("ceilometer_cpu", "compute-0", "base")), "name not allowed")
+	assert.False(t, f.Metric(metric("xcollectd_cpu", "compute-0", "base")), "name regex is anchored")
+	assert.False(t, f.Metric(metric("collectd_cpu_total", "compute-0", "base")), "name denied")
+	assert.False(t, f.Metric(metric("collectd_cpu", "controller-0", "base")), "host does not match")
+	assert.False(t, f.Metric(metric("collectd_cpu", "compute-0", "lo")), "instance matches")
+	assert.False(t, f.Metric(data.Metric{Name: "collectd_cpu"}), "missing label has empty value")
+
+	t.Run("batch", func(t *testing.T) {
+		batch := []data.Metric{
+			metric("collectd_cpu", "compute-0", "base"),
+			metric("collectd_cpu_total", "compute-0", "base"),
+			metric("collectd_memory", "compute-1", "base"),
+		}
+		assert.Equal(t, []data.Metric{batch[0], batch[2]}, f.Metrics(batch))
+		assert.Equal(t, "collectd_cpu_total", batch[1].Name, "original batch is not modified")
+
+		passing := batch[:1]
+		assert.Equal(t, passing, f.Metrics(passing))
+	})
+
+	t.Run("events are filtered by labels only", func(t *testing.T) {
+		assert.True(t, f.Event(data.Event{Labels: map[string]interface{}{"host": "compute-0"}}))
+		assert.False(t, f.Event(data.Event{Labels: map[string]interface{}{"host": "controller-0"}}))
+	})
+}
+
+func TestEventFilter(t *testing.T) {
+	f := parse(t, `
+events:
+  index:
+    allow: ["collectd_*", "ceilometer_*"]
+    deny: ["collectd_elasticsearch"]
+  type:
+    deny: [task, error]
+  severity:
+    allow: [warning, critical]
+labels:
+  - name: check
+    value: "42"
+`)
+	event := data.Event{
+		Index:    "collectd_interface",
+		Type:     data.EVENT,
+		Severity: data.WARNING,
+		Labels:   map[string]interface{}{"check": 42},
+	}
+	assert.True(t, f.Event(event))
+
+	e := event
+	e.Index = "sensubility_check"
+	assert.False(t, f.Event(e), "index not allowed")
+	e.Index = "collectd_elasticsearch"
+	assert.False(t, f.Event(e), "index denied")
+
+	e = event
+	e.Type = data.TASK
+	assert.False(t, f.Event(e), "type denied")
+
+	e = event
+	e.Severity = data.INFO
+	assert.False(t, f.Event(e), "severity not allowed")
+
+	e = event
+	e.Labels = map[string]interface{}{"check": 41}
+	assert.False(t, f.Event(e), "label does not match")
+
+	assert.True(t, f.Metric(data.Metric{Name: "metric", LabelKeys: []string{"check"}, LabelVals: []string{"42"}}))
+}
+
+func TestInvalidFilter(t *testing.T) {
+	for _, conf := range []Config{
+		{Metrics: struct {
+			Name Rules `yaml:"name"`
+		}{Name: Rules{Allow: []string{"("}}}},
+		{Labels: []LabelMatcher{{Name: "host", Op: "=~", Value: "["}}},
+	} {
+		_, err := New(conf)
+		assert.Error(t, err)
+	}
+
+	c := Config{}
+	c.Events.Type.Allow = []string{"unknown"}
+	_, err := New(c)
+	assert.EqualError(t, err, "invalid event type rule: unknown value 'unknown'")
+
+	c = Config{}
+	c.Events.Index.Deny = []string{"["}
+	_, err = New(c)
+	assert.Error(t, err)
+
+	c = Config{}
+	err = config.ParseConfig(bytes.NewBufferString("labels: [{name: host, op: '=='}]"), &c)
+	assert.Error(t, err)
+}
@@ -0,0 +1,165 @@
+package forward
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/infrawatch/sg-core/pkg/data"
+	"github.com/pkg/errors"
+)
+
+// package forward defines format of events and metrics forwarded by transports implementing
+// transport.Forwarder, so that sg-core receiving them can publish them to its buses again.
+// Each event and metric is encoded as JSON object
+
+// Event JSON representation of forwarded event
+type Event struct {
+	Index       string                 `json:"index"`
+	Time        float64                `json:"time"`
+	Type        string                 `json:"type"`
+	Publisher   string                 `json:"publisher"`
+	Severity    string                 `json:"severity"`
+	Labels      map[string]interface{} `json:"labels"`
+	Annotations map[string]interface{} `json:"annotations"`
+	Message     string                 `json:"message"`
+}
+
+// Metric JSON representation of forwarded metric
+type Metric struct {
+	Name     string            `json:"name"`
+	Time     float64           `json:"time"`
+	Type     string            `json:"type"`
+	Interval float64           `json:"interval"` // seconds
+	Value    float64           `json:"value"`
+	Labels   map[string]string `json:"labels"`
+}
+
+var (
+	eventTypes = map[string]data.EventType{
+		data.ERROR.String():  data.ERROR,
+		data.EVENT.String():  data.EVENT,
+		data.LOG.String():    data.LOG,
+		data.RESULT.String(): data.RESULT,
+		data.TASK.String():   data.TASK,
+	}
+	severities = map[string]data.EventSeverity{
+		data.UNKNOWN.String():  data.UNKNOWN,
+		data.DEBUG.String():    data.DEBUG,
+		data.INFO.String():     data.INFO,
+		data.WARNING.String():  data.WARNING,
+		data.CRITICAL.String(): data.CRITICAL,
+	}
+	metricTypes = map[string]data.MetricType{
+		data.UNTYPED.String(): data.UNTYPED,
+		data.COUNTER.String(): data.COUNTER,
+		data.GAUGE.String():   data.GAUGE,
+	}
+)
+
+// EncodeEvent encodes event to be forwarded
+func EncodeEvent(e data.Event) ([]byte, error) {
+	msg, err := json.Marshal(Event{
+		Index:       e.Index,
+		Time:        e.Time,
+		Type:        e.Type.String(),
+		Publisher:   e.Publisher,
+		Severity:    e.Severity.String(),
+		Labels:      e.Labels,
+		Annotations: e.Annotations,
+		Message:     e.Message,
+	})
+	return msg, errors.Wrap(err, "failed encoding event")
+}
+
+// EncodeMetric encodes metric to be forwarded
+func EncodeMetric(m data.Metric) ([]byte, error) {
+	labels := make(map[string]string, len(m.LabelKeys))
+	for i, key := range m.LabelKeys {
+		if i < len(m.LabelVals) {
+			labels[key] = m.LabelVals[i]
+		}
+	}
+	msg, err := json.Marshal(Metric{
+		Name:     m.Name,
+		Time:     m.Time,
+		Type:     m.Type.String(),
+		Interval: m.Interval.Seconds(),
+		Value:    m.Value,
+		Labels:   labels,
+	})
+	return msg, errors.Wrap(err, "failed encoding metric")
+}
+
+// Decode decodes forwarded message. Exactly one of returned event and metric is set when error is nil.
+// Labels of decoded metric are sorted by their names
+func Decode(msg []byte) (*data.Event, *data.Metric, error) {
+	fields := map[string]json.RawMessage{}
+	if err := json.Unmarshal(msg, &fields); err != nil {
+		return nil, nil, errors.Wrap(err, "failed decoding forwarded message")
+	}
+	if _, ok := fields["name"]; ok {
+		m, err := decodeMetric(msg)
+		return nil, m, err
+	}
+	if _, ok := fields["index"]; ok {
+		e, err := decodeEvent(msg)
+		return e, nil, err
+	}
+	return nil, nil, errors.New("forwarded message is neither event nor metric")
+}
+
+func decodeEvent(msg []byte) (*data.Event, error) {
+	fe := Event{}
+	if err := json.Unmarshal(msg, &fe); err != nil {
+		return nil, errors.Wrap(err, "failed decoding forwarded event")
+	}
+	typ, ok := eventTypes[fe.Type]
+	if !ok {
+		return nil, fmt.Errorf("unknown type of forwarded event: '%s'", fe.Type)
+	}
+	severity, ok := severities[fe.Severity]
+	if !ok {
+		return nil, fmt.Errorf("unknown severity of forwarded event: '%s'", fe.Severity)
+	}
+	return &data.Event{
+		Index:       fe.Index,
+		Time:        fe.Time,
+		Type:        typ,
+		Publisher:   fe.Publisher,
+		Severity:    severity,
+		Labels:      fe.Labels,
+		Annotations: fe.Annotations,
+		Message:     fe.Message,
+	}, nil
+}
+
+func decodeMetric(msg []byte) (*data.Metric, error) {
+	fm := Metric{}
+	if err := json.Unmarshal(msg, &fm); err != nil {
+		return nil, errors.Wrap(err, "failed decoding forwarded metric")
+	}
+	typ, ok := metricTypes[fm.Type]
+	if !ok {
+		return nil, fmt.Errorf("unknown type of forwarded metric: '%s'", fm.Type)
+	}
+	keys := make([]string, 0, len(fm.Labels))
+	for key := range fm.Labels {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	vals := make([]string, len(keys))
+	for i, key := range keys {
+		vals[i] = fm.Labels[key]
+	}
+	return &data.Metric{
+		Name:      fm.Name,
+		Time:      fm.Time,
+		Type:      typ,
+		Interval:  time.Duration(fm.Interval * float64(time.Second)),
+		Value:     fm.Value,
+		LabelKeys: keys,
+		LabelVals: vals,
+	}, nil
+}
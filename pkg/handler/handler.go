@@ -8,7 +8,10 @@ import (
 
 // package handler contains the interface description for handler plugins
 
-// Handler mangle messages to place on metric bus
+// Handler mangle messages to place on metric bus. Handlers must be safe for concurrent use: Handle (or HandleBatch)
+// is called concurrently from multiple goroutines when the transport receives messages on multiple connections or has
+// a worker pool, and concurrently with Run. Internal counters shared between calls have to be updated atomically or
+// under a lock. Message passed to Handle must not be retained after Handle returns
 type Handler interface {
 	// Run should only be used to send metrics or events apart from those being parsed from the transport. For example, this process could send metrics tracking the number of arrived messages and send them to the bus on a time delayed interval
 	Run(context.Context, bus.MetricPublishFunc, bus.EventPublishFunc)
@@ -22,3 +25,12 @@ type Handler interface {
 	// Config a yaml object from the config file associated with this plugin is passed into this function. The plugin is responsible for handling this data
 	Config([]byte) error
 }
+
+// BatchHandler can be implemented by handlers which parse multiple metrics from single message. When implemented,
+// HandleBatch is used instead of Handle so that all metrics parsed from a message are published to the metric bus at once
+type BatchHandler interface {
+	Handler
+
+	// HandleBatch parse incoming messages from the transport and write resulting metrics or events to the corresponding bus
+	HandleBatch([]byte, bool, bus.MetricBatchPublishFunc, bus.EventPublishFunc) error
+}
@@ -0,0 +1,62 @@
+package pluginlog
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/infrawatch/apputils/logging"
+)
+
+// keys of record fields, metadata with the same keys are not written
+const (
+	TimeKey    = "time"
+	LevelKey   = "level"
+	MessageKey = "message"
+)
+
+// Writer writes records as JSON objects, one per line. Metadata of the record become its fields
+type Writer struct {
+	enc  *json.Encoder
+	lock sync.Mutex
+}
+
+// NewJSONWriter creates writer of JSON records to given output
+func NewJSONWriter(out io.Writer) *Writer {
+	enc := json.NewEncoder(out)
+	enc.SetEscapeHTML(false)
+	return &Writer{enc: enc}
+}
+
+// Write writes one record
+func (w *Writer) Write(level logging.LogLevel, message string, metadata logging.Metadata) error {
+	record := make(map[string]interface{}, len(metadata)+3)
+	for key, value := range metadata {
+		record[key] = jsonValue(value)
+	}
+	record[TimeKey] = time.Now().Format(time.RFC3339Nano)
+	record[LevelKey] = strings.ToLower(level.String())
+	record[MessageKey] = message
+
+	w.lock.Lock()
+	defer w.lock.Unlock()
+	return w.enc.Encode(record)
+}
+
+// jsonValue returns value which keeps its JSON type when possible. Errors are written as their
+// messages and values which cannot be encoded as their default format
+func jsonValue(value interface{}) interface{} {
+	switch v := value.(type) {
+	case nil:
+		return nil
+	case error:
+		return v.Error()
+	}
+	if _, err := json.Marshal(value); err != nil {
+		return fmt.Sprint(value)
+	}
+	return value
+}
@@ -0,0 +1,172 @@
+package pluginlog
+
+import (
+	"sync"
+	"sync/atomic"
+
+	"github.com/infrawatch/apputils/logging"
+)
+
+// Logger logs records of one plugin instance. Type, name and instance of the plugin are attached
+// to every record, so that logs of sg-core can be filtered by plugin without parsing messages
+type Logger struct {
+	logger *logging.Logger
+	fields logging.Metadata
+}
+
+// Identity of plugin instance
+type Identity struct {
+	Type     string
+	Name     string
+	Instance string
+}
+
+// metadata keys attached to every record
+const (
+	TypeKey     = "pluginType"
+	NameKey     = "plugin"
+	InstanceKey = "instance"
+)
+
+var (
+	identities     = map[*logging.Logger]Identity{}
+	identitiesLock sync.RWMutex
+	// Metadata and logging call pair of logging.Logger is not atomic, level of loggers is also
+	// read and changed under the lock
+	writeLock sync.RWMutex
+	// records are written by writer instead of logging.Logger when it is set
+	writer atomic.Pointer[Writer]
+)
+
+// SetWriter sets writer of records of all loggers, nil restores writing through logging.Logger
+func SetWriter(w *Writer) {
+	writer.Store(w)
+}
+
+// Register records identity of plugin instance which receives given logger. sg-core registers
+// logger of each transport and application before the plugin is constructed
+func Register(l *logging.Logger, id Identity) {
+	identitiesLock.Lock()
+	defer identitiesLock.Unlock()
+	identities[l] = id
+}
+
+// Unregister removes identity of logger of unloaded plugin
+func Unregister(l *logging.Logger) {
+	identitiesLock.Lock()
+	defer identitiesLock.Unlock()
+	delete(identities, l)
+}
+
+// Copy returns copy of logger, eg. to be registered for a plugin. Level of the logger is read
+// under the same lock as it is changed
+func Copy(l *logging.Logger) *logging.Logger {
+	writeLock.RLock()
+	defer writeLock.RUnlock()
+	c := *l
+	return &c
+}
+
+// SetLevel sets log level of all registered loggers
+func SetLevel(level logging.LogLevel) {
+	identitiesLock.RLock()
+	defer identitiesLock.RUnlock()
+	writeLock.Lock()
+	defer writeLock.Unlock()
+	for l := range identities {
+		l.SetLogLevel(level)
+	}
+}
+
+// New creates logger of plugin. Identity registered by sg-core takes precedence, given type and
+// name are used for loggers which were not registered, eg. in unit tests
+func New(l *logging.Logger, typ string, name string) *Logger {
+	identitiesLock.RLock()
+	id, ok := identities[l]
+	identitiesLock.RUnlock()
+	if !ok {
+		id = Identity{Type: typ, Name: name, Instance: name}
+	}
+	return &Logger{
+		logger: l,
+		fields: logging.Metadata{TypeKey: id.Type, NameKey: id.Name, InstanceKey: id.Instance},
+	}
+}
+
+// NewCore creates logger of sg-core itself, its records carry no plugin identity
+func NewCore(l *logging.Logger) *Logger {
+	return &Logger{logger: l, fields: logging.Metadata{}}
+}
+
+// Logger returns underlying logger
+func (pl *Logger) Logger() *logging.Logger {
+	return pl.logger
+}
+
+// Level returns log level of underlying logger
+func (pl *Logger) Level() logging.LogLevel {
+	writeLock.RLock()
+	defer writeLock.RUnlock()
+	return pl.logger.Level
+}
+
+// SetLevel sets log level of underlying logger. Unlike logging.Logger.SetLogLevel it is safe
+// to call while other goroutines log
+func (pl *Logger) SetLevel(level logging.LogLevel) {
+	writeLock.Lock()
+	defer writeLock.Unlock()
+	pl.logger.SetLogLevel(level)
+}
+
+// Debug logs record on debug level
+func (pl *Logger) Debug(message string, metadata ...logging.Metadata) {
+	pl.log(logging.DEBUG, message, metadata)
+}
+
+// Info logs record on info level
+func (pl *Logger) Info(message string, metadata ...logging.Metadata) {
+	pl.log(logging.INFO, message, metadata)
+}
+
+// Warn logs record on warning level
+func (pl *Logger) Warn(message string, metadata ...logging.Metadata) {
+	pl.log(logging.WARN, message, metadata)
+}
+
+// Error logs record on error level
+func (pl *Logger) Error(message string, metadata ...logging.Metadata) {
+	pl.log(logging.ERROR, message, metadata)
+}
+
+func (pl *Logger) log(level logging.LogLevel, message string, metadata []logging.Metadata) {
+	md := logging.Metadata{}
+	for _, m := range metadata {
+		for key, value := range m {
+			md[key] = value
+		}
+	}
+	for key, value := range pl.fields {
+		md[key] = value
+	}
+
+	if w := writer.Load(); w != nil {
+		if level >= pl.Level() {
+			_ = w.Write(level, message, md)
+		}
+		return
+	}
+
+	writeLock.Lock()
+	defer writeLock.Unlock()
+	pl.logger.Metadata(md)
+	switch level {
+	case logging.DEBUG:
+		_ = pl.logger.Debug(message)
+	case logging.INFO:
+		_ = pl.logger.Info(message)
+	case logging.WARN:
+		_ = pl.logger.Warn(message)
+	default:
+		_ = pl.logger.Error(message)
+	}
+}
@@ -0,0 +1,113 @@
+package pluginlog
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/infrawatch/apputils/logging"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestLogger(t *testing.T) (*logging.Logger, string) {
+	logpath := path.Join(t.TempDir(), "test.log")
+	l, err := logging.NewLogger(logging.DEBUG, logpath)
+	require.NoError(t, err)
+	return l, logpath
+}
+
+func TestLogger(t *testing.T) {
+	t.Run("unregistered logger", func(t *testing.T) {
+		l, logpath := newTestLogger(t)
+		pl := New(l, "application", "prometheus")
+		pl.Error("failed", logging.Metadata{"error": errors.New("boom"), NameKey: "overridden"})
+
+		out, err := os.ReadFile(logpath)
+		require.NoError(t, err)
+		assert.Contains(t, string(out), "[ERROR] failed [")
+		for _, field := range []string{"error: boom", "pluginType: application", "plugin: prometheus", "instance: prometheus"} {
+			assert.Contains(t, string(out), field)
+		}
+		assert.NotContains(t, string(out), "overridden")
+	})
+
+	t.Run("registered logger", func(t *testing.T) {
+		l, logpath := newTestLogger(t)
+		Register(l, Identity{Type: "transport", Name: "socket", Instance: "socket1"})
+		defer Unregister(l)
+
+		pl := New(l, "transport", "unknown")
+		pl.Info("listening")
+		SetLevel(logging.WARN)
+		pl.Info("filtered")
+		pl.Warn("warning")
+
+		out, err := os.ReadFile(logpath)
+		require.NoError(t, err)
+		assert.Contains(t, string(out), "[INFO] listening [")
+		assert.Contains(t, string(out), "instance: socket1")
+		assert.Contains(t, string(out), "plugin: socket")
+		assert.NotContains(t, string(out), "filtered")
+		assert.Contains(t, string(out), "[WARN] warning")
+	})
+}
+
+func TestSetLevel(t *testing.T) {
+	l, logpath := newTestLogger(t)
+	pl := NewCore(l)
+
+	// level is changed while other goroutines log, run with -race
+	wg := sync.WaitGroup{}
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				pl.Debug("debug")
+			}
+		}()
+	}
+	for _, level := range []logging.LogLevel{logging.INFO, logging.DEBUG, logging.WARN} {
+		pl.SetLevel(level)
+	}
+	wg.Wait()
+	assert.Equal(t, logging.WARN, pl.Level())
+
+	pl.Info("filtered")
+	out, err := os.ReadFile(logpath)
+	require.NoError(t, err)
+	assert.NotContains(t, string(out), "filtered")
+}
+
+func TestJSONWriter(t *testing.T) {
+	l, _ := newTestLogger(t)
+	l.SetLogLevel(logging.INFO)
+	out := &strings.Builder{}
+	SetWriter(NewJSONWriter(out))
+	defer SetWriter(nil)
+
+	pl := New(l, "application", "loki")
+	pl.Debug("filtered")
+	pl.Info("pushed <logs>", logging.Metadata{"count": 2, "labels": map[string]string{"a": "b"}, "done": make(chan bool), MessageKey: "overridden"})
+
+	record := map[string]interface{}{}
+	require.NoError(t, json.Unmarshal([]byte(out.String()), &record))
+	assert.Contains(t, record, TimeKey)
+	delete(record, TimeKey)
+	assert.Equal(t, map[string]interface{}{
+		LevelKey:    "info",
+		MessageKey:  "pushed <logs>",
+		"count":     float64(2),
+		"labels":    map[string]interface{}{"a": "b"},
+		"done":      record["done"],
+		TypeKey:     "application",
+		NameKey:     "loki",
+		InstanceKey: "loki",
+	}, record)
+	assert.IsType(t, "", record["done"], "values which cannot be encoded are formatted")
+}
@@ -0,0 +1,45 @@
+package processor
+
+import (
+	"context"
+
+	"github.com/infrawatch/sg-core/pkg/data"
+)
+
+// package processor defines the interfaces for processor plugins. Processors transform metrics
+// and events between handlers and applications. They are chained either per transport, where
+// they receive data published by handlers of the transport, or globally, where they receive
+// all data published to the internal buses before it is delivered to applications
+
+// Processor describes processor plugin interface. Configuration bytes are passed into the Config()
+// function in yaml format. A processor must implement MetricProcessor, EventProcessor or both
+type Processor interface {
+	Config([]byte) error
+}
+
+// MetricProcessor processes metrics
+type MetricProcessor interface {
+	Processor
+	// ProcessMetrics is called with each batch of metrics published by handlers. Returned metrics are passed
+	// to the next processor in the chain instead of the given batch, so that processor can modify, drop or
+	// fan out metrics. Processor may modify the batch and its metrics, but LabelKeys and LabelVals slices can
+	// be shared between metrics and must be copied before being changed. Calls can be concurrent
+	ProcessMetrics([]data.Metric) []data.Metric
+}
+
+// EventProcessor processes events
+type EventProcessor interface {
+	Processor
+	// ProcessEvent is called with each published event. Returned events are passed to the next processor in
+	// the chain instead of the given event, returning no event drops it. Labels and Annotations maps can be
+	// shared and must be copied before being changed. Calls can be concurrent
+	ProcessEvent(data.Event) []data.Event
+}
+
+// Runner can be implemented by processors which need a background process, eg. for reloading data
+// or expiring state. Run is called in a separate goroutine once the processor is loaded and it must
+// return when the context is done
+type Runner interface {
+	Processor
+	Run(context.Context)
+}
@@ -0,0 +1,109 @@
+package registry
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/infrawatch/apputils/logging"
+	"github.com/infrawatch/sg-core/pkg/application"
+	"github.com/infrawatch/sg-core/pkg/bus"
+	"github.com/infrawatch/sg-core/pkg/handler"
+	"github.com/infrawatch/sg-core/pkg/processor"
+	"github.com/infrawatch/sg-core/pkg/transport"
+)
+
+// package registry holds constructors of plugins compiled into the sg-core binary. Plugins register
+// their New() functions from an init() function, so the same plugin source works both when it is
+// built with -buildmode=plugin and when it is linked statically into sg-core
+
+var (
+	mutex        sync.RWMutex
+	transports   = map[string]func(*logging.Logger) transport.Transport{}
+	handlers     = map[string]func() handler.Handler{}
+	applications = map[string]func(*logging.Logger, bus.EventPublishFunc) application.Application{}
+	processors   = map[string]func(*logging.Logger) processor.Processor{}
+)
+
+// RegisterTransport makes transport constructor available under given name. Registering the same name twice panics
+func RegisterTransport(name string, new func(*logging.Logger) transport.Transport) {
+	mutex.Lock()
+	defer mutex.Unlock()
+	if new == nil {
+		panic(fmt.Sprintf("registry: transport constructor for '%s' is nil", name))
+	}
+	if _, ok := transports[name]; ok {
+		panic(fmt.Sprintf("registry: transport '%s' registered twice", name))
+	}
+	transports[name] = new
+}
+
+// RegisterHandler makes handler constructor available under given name. Registering the same name twice panics
+func RegisterHandler(name string, new func() handler.Handler) {
+	mutex.Lock()
+	defer mutex.Unlock()
+	if new == nil {
+		panic(fmt.Sprintf("registry: handler constructor for '%s' is nil", name))
+	}
+	if _, ok := handlers[name]; ok {
+		panic(fmt.Sprintf("registry: handler '%s' registered twice", name))
+	}
+	handlers[name] = new
+}
+
+// RegisterApplication makes application constructor available under given name. Registering the same name twice panics
+func RegisterApplication(name string, new func(*logging.Logger, bus.EventPublishFunc) application.Application) {
+	mutex.Lock()
+	defer mutex.Unlock()
+	if new == nil {
+		panic(fmt.Sprintf("registry: application constructor for '%s' is nil", name))
+	}
+	if _, ok := applications[name]; ok {
+		panic(fmt.Sprintf("registry: application '%s' registered twice", name))
+	}
+	applications[name] = new
+}
+
+// RegisterProcessor makes processor constructor available under given name. Registering the same name twice panics
+func RegisterProcessor(name string, new func(*logging.Logger) processor.Processor) {
+	mutex.Lock()
+	defer mutex.Unlock()
+	if new == nil {
+		panic(fmt.Sprintf("registry: processor constructor for '%s' is nil", name))
+	}
+	if _, ok := processors[name]; ok {
+		panic(fmt.Sprintf("registry: processor '%s' registered twice", name))
+	}
+	processors[name] = new
+}
+
+// Transport returns registered transport constructor
+func Transport(name string) (func(*logging.Logger) transport.Transport, bool) {
+	mutex.RLock()
+	defer mutex.RUnlock()
+	new, ok := transports[name]
+	return new, ok
+}
+
+// Handler returns registered handler constructor
+func Handler(name string) (func() handler.Handler, bool) {
+	mutex.RLock()
+	defer mutex.RUnlock()
+	new, ok := handlers[name]
+	return new, ok
+}
+
+// Application returns registered application constructor
+func Application(name string) (func(*logging.Logger, bus.EventPublishFunc) application.Application, bool) {
+	mutex.RLock()
+	defer mutex.RUnlock()
+	new, ok := applications[name]
+	return new, ok
+}
+
+// Processor returns registered processor constructor
+func Processor(name string) (func(*logging.Logger) processor.Processor, bool) {
+	mutex.RLock()
+	defer mutex.RUnlock()
+	new, ok := processors[name]
+	return new, ok
+}
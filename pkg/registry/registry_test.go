@@ -0,0 +1,104 @@
+package registry
+
+import (
+	"context"
+	"testing"
+
+	"github.com/infrawatch/apputils/logging"
+	"github.com/infrawatch/sg-core/pkg/application"
+	"github.com/infrawatch/sg-core/pkg/bus"
+	"github.com/infrawatch/sg-core/pkg/handler"
+	"github.com/infrawatch/sg-core/pkg/processor"
+	"github.com/infrawatch/sg-core/pkg/transport"
+	"github.com/stretchr/testify/assert"
+)
+
+type testTransport struct{}
+
+func (t *testTransport) Config([]byte) error                               { return nil }
+func (t *testTransport) Run(context.Context, transport.WriteFn, chan bool) {}
+
+type testHandler struct{}
+
+func (h *testHandler) Run(context.Context, bus.MetricPublishFunc, bus.EventPublishFunc) {}
+func (h *testHandler) Identify() string                                                 { return "test" }
+func (h *testHandler) Handle([]byte, bool, bus.MetricPublishFunc, bus.EventPublishFunc) error {
+	return nil
+}
+func (h *testHandler) Config([]byte) error { return nil }
+
+type testApplication struct{}
+
+func (a *testApplication) Config([]byte) error            { return nil }
+func (a *testApplication) Run(context.Context, chan bool) {}
+
+type testProcessor struct{}
+
+func (p *testProcessor) Config([]byte) error { return nil }
+
+func TestRegistry(t *testing.T) {
+	t.Run("transport", func(t *testing.T) {
+		defer delete(transports, "test")
+		_, ok := Transport("test")
+		assert.False(t, ok)
+
+		RegisterTransport("test", func(*logging.Logger) transport.Transport { return &testTransport{} })
+		new, ok := Transport("test")
+		assert.True(t, ok)
+		assert.IsType(t, &testTransport{}, new(nil))
+
+		assert.Panics(t, func() {
+			RegisterTransport("test", func(*logging.Logger) transport.Transport { return &testTransport{} })
+		})
+	})
+
+	t.Run("handler", func(t *testing.T) {
+		defer delete(handlers, "test")
+		_, ok := Handler("test")
+		assert.False(t, ok)
+
+		RegisterHandler("test", func() handler.Handler { return &testHandler{} })
+		new, ok := Handler("test")
+		assert.True(t, ok)
+		assert.IsType(t, &testHandler{}, new())
+
+		assert.Panics(t, func() { RegisterHandler("test", func() handler.Handler { return &testHandler{} }) })
+	})
+
+	t.Run("application", func(t *testing.T) {
+		defer delete(applications, "test")
+		_, ok := Application("test")
+		assert.False(t, ok)
+
+		RegisterApplication("test", func(*logging.Logger, bus.EventPublishFunc) application.Application { return &testApplication{} })
+		new, ok := Application("test")
+		assert.True(t, ok)
+		assert.IsType(t, &testApplication{}, new(nil, nil))
+
+		assert.Panics(t, func() {
+			RegisterApplication("test", func(*logging.Logger, bus.EventPublishFunc) application.Application { return &testApplication{} })
+		})
+	})
+
+	t.Run("processor", func(t *testing.T) {
+		defer delete(processors, "test")
+		_, ok := Processor("test")
+		assert.False(t, ok)
+
+		RegisterProcessor("test", func(*logging.Logger) processor.Processor { return &testProcessor{} })
+		new, ok := Processor("test")
+		assert.True(t, ok)
+		assert.IsType(t, &testProcessor{}, new(nil))
+
+		assert.Panics(t, func() {
+			RegisterProcessor("test", func(*logging.Logger) processor.Processor { return &testProcessor{} })
+		})
+	})
+
+	t.Run("nil constructor", func(t *testing.T) {
+		assert.Panics(t, func() { RegisterTransport("nil", nil) })
+		assert.Panics(t, func() { RegisterHandler("nil", nil) })
+		assert.Panics(t, func() { RegisterApplication("nil", nil) })
+		assert.Panics(t, func() { RegisterProcessor("nil", nil) })
+	})
+}
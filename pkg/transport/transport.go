@@ -3,6 +3,9 @@ package transport
 import (
 	"context"
 	"strings"
+
+	"github.com/infrawatch/sg-core/pkg/data"
+	"github.com/infrawatch/sg-core/pkg/filter"
 )
 
 // package transport defines the interfaces for interacting with transport
@@ -35,12 +38,53 @@ func (m *Mode) FromString(s string) {
 	*m = modStr[strings.ToLower(s)]
 }
 
-// WriteFn func type for writing from transport to handlers
+// WriteFn func type for writing from transport to handlers. It is safe to call WriteFn concurrently and the
+// transport may reuse the message buffer once WriteFn returns, WriteFn must not be called once Run of the
+// transport returns
 type WriteFn func([]byte)
 
 // Transport type listens on one interface and delivers data to core
-// TODO: listen for events internally
+// Run must respect the context.Done() signal. Transport failing to receive data should send true value to the boolean
+// channel, sg-core then stops it and restarts it by calling Run again according to its restart policy
 type Transport interface {
 	Config([]byte) error
 	Run(context.Context, WriteFn, chan bool)
 }
+
+// StatusReporter can be implemented by transports which are able to report their own health.
+// Status is polled by sg-core while the transport is running. Returning an error marks the transport as failed
+type StatusReporter interface {
+	Transport
+	Status() error
+}
+
+// TaskTransportLabel label of TASK events holding instance name of the transport which should send them
+const TaskTransportLabel = "transport"
+
+// Sender can be implemented by transports able to send messages outbound. sg-core passes TASK events
+// addressed to the transport by TaskTransportLabel to Send while the transport is running. Payload to send
+// is the event Message, labels and annotations can carry transport specific parameters. Send is never called
+// concurrently, returned error is logged and reported by the status endpoint
+type Sender interface {
+	Transport
+	Send(data.Event) error
+}
+
+// ForwardConfig describes data forwarded from internal buses by a Forwarder. Nothing is forwarded by default
+type ForwardConfig struct {
+	Events  bool          `yaml:"events"`  // forward events passing filter
+	Metrics bool          `yaml:"metrics"` // forward metrics passing filter
+	Filter  filter.Config `yaml:"filter"`
+}
+
+// Forwarder can be implemented by senders able to forward data from internal buses. While the transport is
+// running, sg-core subscribes it to buses enabled by its Forward configuration and passes data passing the
+// filter to ForwardEvent and ForwardMetrics. TASK events addressed to the transport are passed to Send instead.
+// Send and forwarding functions are never called concurrently, returned error is logged and reported by
+// the status endpoint
+type Forwarder interface {
+	Sender
+	Forward() ForwardConfig
+	ForwardEvent(data.Event) error
+	ForwardMetrics([]data.Metric) error
+}
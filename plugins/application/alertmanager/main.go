@@ -7,12 +7,16 @@ import (
 	"io"
 	"net/http"
 	"sync"
+	"sync/atomic"
+	"time"
 
 	"github.com/infrawatch/apputils/logging"
 	"github.com/infrawatch/sg-core/pkg/application"
 	"github.com/infrawatch/sg-core/pkg/bus"
 	"github.com/infrawatch/sg-core/pkg/config"
 	"github.com/infrawatch/sg-core/pkg/data"
+	"github.com/infrawatch/sg-core/pkg/pluginlog"
+	"github.com/infrawatch/sg-core/pkg/registry"
 
 	"github.com/infrawatch/sg-core/plugins/application/alertmanager/pkg/lib"
 )
@@ -24,8 +28,13 @@ const (
 // AlertManager plugin suites for reporting alerts for Prometheus' alert manager
 type AlertManager struct {
 	configuration lib.AppConfig
-	logger        *logging.Logger
+	logger        *pluginlog.Logger
 	dump          chan lib.PrometheusAlert
+	sending       atomic.Int64 // number of alerts being sent
+}
+
+func init() {
+	registry.RegisterApplication("alertmanager", New)
 }
 
 // New constructor
@@ -35,7 +44,7 @@ func New(logger *logging.Logger, sendEvent bus.EventPublishFunc) application.App
 			AlertManagerURL: "http://localhost",
 			GeneratorURL:    "http://sg.localhost.localdomain",
 		},
-		logger: logger,
+		logger: pluginlog.New(logger, "application", appname),
 		dump:   make(chan lib.PrometheusAlert, 100),
 	}
 }
@@ -67,53 +76,80 @@ func (am *AlertManager) Run(ctx context.Context, done chan bool) {
 			goto done
 		case dumped := <-am.dump:
 			wg.Add(1)
+			am.sending.Add(1)
 			go func(dumped lib.PrometheusAlert, wg *sync.WaitGroup) {
 				defer wg.Done()
-				alert, err := json.Marshal(dumped)
-				if err != nil {
-					am.logger.Metadata(logging.Metadata{"plugin": appname, "alert": dumped})
-					am.logger.Warn("failed to marshal alert - disregarding")
-				} else {
-					buff := bytes.NewBufferString("[")
-					buff.Write(alert)
-					buff.WriteString("]")
-
-					req, err := http.NewRequest("POST", am.configuration.AlertManagerURL, buff)
-					if err != nil {
-						am.logger.Metadata(logging.Metadata{"plugin": appname, "error": err})
-						am.logger.Error("failed to create http request")
-					}
-					req = req.WithContext(ctx)
-					req.Header.Set("X-Custom-Header", "smartgateway")
-					req.Header.Set("Content-Type", "application/json")
-
-					client := &http.Client{}
-					resp, err := client.Do(req)
-					if err != nil {
-						am.logger.Metadata(logging.Metadata{"plugin": appname, "error": err, "alert": buff.String()})
-						am.logger.Error("failed to report alert to AlertManager")
-					} else if resp.StatusCode != http.StatusOK {
-						// https://github.com/prometheus/alertmanager/blob/master/api/v2/openapi.yaml#L170
-						body, _ := io.ReadAll(resp.Body)
-						resp.Body.Close()
-						am.logger.Metadata(logging.Metadata{
-							"plugin": appname,
-							"status": resp.Status,
-							"header": resp.Header,
-							"body":   string(body)})
-						am.logger.Error("failed to report alert to AlertManager")
-					}
-				}
+				defer am.sending.Add(-1)
+				am.send(ctx, dumped)
 			}(dumped, &wg)
 		}
 	}
 
 done:
 	wg.Wait()
-	am.logger.Metadata(logging.Metadata{"plugin": appname})
 	am.logger.Info("exited")
 }
 
+// Flush implements application.Flusher. Alerts waiting in queue are sent and alerts being sent are waited for
+func (am *AlertManager) Flush(ctx context.Context) error {
+queue:
+	for {
+		select {
+		case dumped := <-am.dump:
+			am.send(ctx, dumped)
+		case <-ctx.Done():
+			return ctx.Err()
+		default:
+			break queue
+		}
+	}
+
+	tick := time.NewTicker(10 * time.Millisecond)
+	defer tick.Stop()
+	for am.sending.Load() > 0 {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-tick.C:
+		}
+	}
+	return nil
+}
+
+func (am *AlertManager) send(ctx context.Context, dumped lib.PrometheusAlert) {
+	alert, err := json.Marshal(dumped)
+	if err != nil {
+		am.logger.Warn("failed to marshal alert - disregarding", logging.Metadata{"alert": dumped})
+		return
+	}
+	buff := bytes.NewBufferString("[")
+	buff.Write(alert)
+	buff.WriteString("]")
+
+	req, err := http.NewRequest("POST", am.configuration.AlertManagerURL, buff)
+	if err != nil {
+		am.logger.Error("failed to create http request", logging.Metadata{"error": err})
+		return
+	}
+	req = req.WithContext(ctx)
+	req.Header.Set("X-Custom-Header", "smartgateway")
+	req.Header.Set("Content-Type", "application/json")
+
+	client := &http.Client{}
+	resp, err := client.Do(req)
+	if err != nil {
+		am.logger.Error("failed to report alert to AlertManager", logging.Metadata{"error": err, "alert": buff.String()})
+	} else if resp.StatusCode != http.StatusOK {
+		// https://github.com/prometheus/alertmanager/blob/master/api/v2/openapi.yaml#L170
+		body, _ := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		am.logger.Error("failed to report alert to AlertManager", logging.Metadata{
+			"status": resp.Status,
+			"header": resp.Header,
+			"body":   string(body)})
+	}
+}
+
 // Config implements application.Application
 func (am *AlertManager) Config(c []byte) error {
 	am.configuration = lib.AppConfig{
@@ -1,6 +1,10 @@
 package main
 
 import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
 	"os"
 	"path"
 	"testing"
@@ -8,6 +12,7 @@ import (
 	"github.com/infrawatch/apputils/logging"
 	"github.com/infrawatch/sg-core/pkg/bus"
 	"github.com/infrawatch/sg-core/pkg/data"
+	"github.com/infrawatch/sg-core/pkg/pluginlog"
 	"github.com/infrawatch/sg-core/plugins/application/alertmanager/pkg/lib"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -186,7 +191,7 @@ func TestAlertmanagerApp(t *testing.T) {
 	t.Run("Test alert generation", func(t *testing.T) {
 		results := make(chan lib.PrometheusAlert, len(alertCases))
 		app := &AlertManager{
-			logger: logger,
+			logger: pluginlog.New(logger, "application", appname),
 			dump:   results,
 		}
 		err := app.Config([]byte(testConf))
@@ -200,4 +205,24 @@ func TestAlertmanagerApp(t *testing.T) {
 			assert.Equal(t, tstCase.Result.GeneratorURL, res.GeneratorURL)
 		}
 	})
+	t.Run("Test flush", func(t *testing.T) {
+		received := make(chan string, len(alertCases))
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			body, _ := io.ReadAll(r.Body)
+			received <- string(body)
+		}))
+		defer srv.Close()
+
+		app := New(logger, nil).(*AlertManager)
+		err := app.Config([]byte("alertManagerUrl: " + srv.URL))
+		require.NoError(t, err)
+		for _, tstCase := range alertCases {
+			app.ReceiveEvent(tstCase.Event)
+		}
+
+		// alerts queued before Run was started are sent by Flush
+		require.NoError(t, app.Flush(context.Background()))
+		assert.Len(t, received, len(alertCases))
+		assert.Empty(t, app.dump)
+	})
 }
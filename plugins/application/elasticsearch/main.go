@@ -12,6 +12,8 @@ import (
 	"github.com/infrawatch/sg-core/pkg/bus"
 	"github.com/infrawatch/sg-core/pkg/config"
 	"github.com/infrawatch/sg-core/pkg/data"
+	"github.com/infrawatch/sg-core/pkg/pluginlog"
+	"github.com/infrawatch/sg-core/pkg/registry"
 	jsoniter "github.com/json-iterator/go"
 	"github.com/pkg/errors"
 
@@ -51,17 +53,23 @@ type log struct {
 // Elasticsearch plugin saves events to Elasticsearch database
 type Elasticsearch struct {
 	configuration *lib.AppConfig
-	logger        *logging.Logger
+	logger        *pluginlog.Logger
 	client        *lib.Client
 	buffer        map[string][]string
 	bufferMutex   sync.RWMutex
 	dump          chan *esIndex
+	indexErr      error // error of the last indexing attempt
+	indexErrMutex sync.RWMutex
+}
+
+func init() {
+	registry.RegisterApplication("elasticsearch", New)
 }
 
 // New constructor
 func New(logger *logging.Logger, sendEvent bus.EventPublishFunc) application.Application {
 	return &Elasticsearch{
-		logger: logger,
+		logger: pluginlog.New(logger, "application", appname),
 		buffer: make(map[string][]string),
 		dump:   make(chan *esIndex, 512),
 	}
@@ -80,13 +88,11 @@ func (es *Elasticsearch) ReceiveEvent(event data.Event) {
 		// eg. case data.TASK: this app does not respond on task request events
 		//     case data.ERROR: TODO: save internal error
 		//     case data.RESULT: TODO: save task result
-		es.logger.Metadata(logging.Metadata{"plugin": appname, "event": event})
-		es.logger.Debug("received unknown event")
+		es.logger.Debug("received unknown event", logging.Metadata{"event": event})
 		return
 	}
 	if err != nil {
-		es.logger.Metadata(logging.Metadata{"plugin": appname, "event": event})
-		es.logger.Error("failed formating record")
+		es.logger.Error("failed formating record", logging.Metadata{"event": event})
 		return
 	}
 
@@ -102,8 +108,7 @@ func (es *Elasticsearch) ReceiveEvent(event data.Event) {
 		if len(es.buffer[event.Index]) < es.configuration.BufferSize {
 			es.bufferMutex.Unlock()
 			// buffer is not full, don't send
-			es.logger.Metadata(logging.Metadata{"plugin": appname, "record": record})
-			es.logger.Debug("buffering record")
+			es.logger.Debug("buffering record", logging.Metadata{"record": record})
 			return
 		}
 		recordList = es.buffer[event.Index]
@@ -118,19 +123,16 @@ func (es *Elasticsearch) ReceiveEvent(event data.Event) {
 
 // Run plugin process
 func (es *Elasticsearch) Run(ctx context.Context, done chan bool) {
-	es.logger.Metadata(logging.Metadata{"plugin": appname, "url": es.configuration.HostURL})
-	es.logger.Info("storing events and(or) logs to Elasticsearch.")
+	es.logger.Info("storing events and(or) logs to Elasticsearch.", logging.Metadata{"url": es.configuration.HostURL})
 
 	if es.configuration.ResetIndices != nil {
 		err := es.client.IndicesDelete(es.configuration.ResetIndices)
 		if err != nil {
-			es.logger.Metadata(logging.Metadata{"plugin": appname, "error": err})
-			es.logger.Error("failed removing indices")
+			es.logger.Error("failed removing indices", logging.Metadata{"error": err})
 			done <- true
 			return
 		}
-		es.logger.Metadata(logging.Metadata{"plugin": appname, "indices": es.configuration.ResetIndices})
-		es.logger.Info("removed indices")
+		es.logger.Info("removed indices", logging.Metadata{"indices": es.configuration.ResetIndices})
 	}
 
 	wg := sync.WaitGroup{}
@@ -144,18 +146,17 @@ func (es *Elasticsearch) Run(ctx context.Context, done chan bool) {
 			select {
 			case <-ctx.Done():
 				tick.Stop()
-				es.logger.Metadata(logging.Metadata{"plugin": appname})
 				es.logger.Debug("shutting down buffer flusher")
 				return
 			case <-tick.C:
 				es.bufferMutex.Lock()
 				for index, record := range es.buffer {
-					if err := es.client.Index(index, record, es.configuration.BulkIndex); err != nil {
-						es.logger.Metadata(logging.Metadata{"plugin": appname, "records": len(record), "index": index, "error": err})
-						es.logger.Error("failed to flush buffer - disregarding")
+					err := es.client.Index(index, record, es.configuration.BulkIndex)
+					es.setIndexErr(err)
+					if err != nil {
+						es.logger.Error("failed to flush buffer - disregarding", logging.Metadata{"records": len(record), "index": index, "error": err})
 					} else {
-						es.logger.Metadata(logging.Metadata{"plugin": appname, "records": len(record), "index": index})
-						es.logger.Debug("successfully flushed buffer")
+						es.logger.Debug("successfully flushed buffer", logging.Metadata{"records": len(record), "index": index})
 					}
 					delete(es.buffer, index)
 				}
@@ -166,8 +167,7 @@ func (es *Elasticsearch) Run(ctx context.Context, done chan bool) {
 
 	// spawn index workers
 	for i := 0; i < es.configuration.IndexWorkers; i++ {
-		es.logger.Metadata(logging.Metadata{"plugin": appname, "worker-id": i})
-		es.logger.Debug("spawning index worker")
+		es.logger.Debug("spawning index worker", logging.Metadata{"worker-id": i})
 		wg.Add(1)
 
 		go func(es *Elasticsearch, ctx context.Context, wg *sync.WaitGroup, i int) {
@@ -175,16 +175,15 @@ func (es *Elasticsearch) Run(ctx context.Context, done chan bool) {
 			for {
 				select {
 				case <-ctx.Done():
-					es.logger.Metadata(logging.Metadata{"plugin": appname, "worker-id": i})
-					es.logger.Debug("shutting down index worker")
+					es.logger.Debug("shutting down index worker", logging.Metadata{"worker-id": i})
 					return
 				case dumped := <-es.dump:
-					if err := es.client.Index(dumped.index, dumped.record, es.configuration.BulkIndex); err != nil {
-						es.logger.Metadata(logging.Metadata{"plugin": appname, "event": dumped.record, "error": err})
-						es.logger.Error("failed to index event - disregarding")
+					err := es.client.Index(dumped.index, dumped.record, es.configuration.BulkIndex)
+					es.setIndexErr(err)
+					if err != nil {
+						es.logger.Error("failed to index event - disregarding", logging.Metadata{"event": dumped.record, "error": err})
 					} else {
-						es.logger.Metadata(logging.Metadata{"plugin": appname, "records": len(dumped.record)})
-						es.logger.Debug("successfully indexed document(s)")
+						es.logger.Debug("successfully indexed document(s)", logging.Metadata{"records": len(dumped.record)})
 					}
 				}
 			}
@@ -193,10 +192,65 @@ func (es *Elasticsearch) Run(ctx context.Context, done chan bool) {
 	}
 
 	wg.Wait()
-	es.logger.Metadata(logging.Metadata{"plugin": appname})
 	es.logger.Info("exited")
 }
 
+// Flush implements application.Flusher. Buffered records and records waiting for index workers are indexed
+func (es *Elasticsearch) Flush(ctx context.Context) error {
+	es.bufferMutex.Lock()
+	buffered := es.buffer
+	es.buffer = make(map[string][]string)
+	es.bufferMutex.Unlock()
+
+	var err error
+	for index, records := range buffered {
+		if e := es.flushRecords(index, records); e != nil {
+			err = e
+		}
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+	}
+	for {
+		select {
+		case dumped := <-es.dump:
+			if e := es.flushRecords(dumped.index, dumped.record); e != nil {
+				err = e
+			}
+		case <-ctx.Done():
+			return ctx.Err()
+		default:
+			return err
+		}
+	}
+}
+
+func (es *Elasticsearch) flushRecords(index string, records []string) error {
+	err := es.client.Index(index, records, es.configuration.BulkIndex)
+	es.setIndexErr(err)
+	if err != nil {
+		return errors.Wrapf(err, "failed to flush %d records to index %s", len(records), index)
+	}
+	es.logger.Debug("flushed records", logging.Metadata{"records": len(records), "index": index})
+	return nil
+}
+
+// Status implements application.StatusReporter. Elasticsearch is reported as failed while indexing fails
+func (es *Elasticsearch) Status() error {
+	es.indexErrMutex.RLock()
+	defer es.indexErrMutex.RUnlock()
+	if es.indexErr != nil {
+		return errors.Wrap(es.indexErr, "failed to index documents")
+	}
+	return nil
+}
+
+func (es *Elasticsearch) setIndexErr(err error) {
+	es.indexErrMutex.Lock()
+	defer es.indexErrMutex.Unlock()
+	es.indexErr = err
+}
+
 // Config implements application.Application
 func (es *Elasticsearch) Config(c []byte) error {
 	es.configuration = &lib.AppConfig{
@@ -220,7 +274,6 @@ func (es *Elasticsearch) Config(c []byte) error {
 	}
 
 	if es.configuration.UseBasicAuth && !es.configuration.UseTLS {
-		es.logger.Metadata(logging.Metadata{"plugin": appname})
 		es.logger.Warn("insecure: using basic authentication without TLS enabled")
 	}
 
@@ -9,6 +9,7 @@ import (
 	"github.com/infrawatch/apputils/logging"
 	"github.com/infrawatch/sg-core/pkg/bus"
 	"github.com/infrawatch/sg-core/pkg/data"
+	"github.com/infrawatch/sg-core/pkg/pluginlog"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -248,7 +249,7 @@ func TestElasticsearchApp(t *testing.T) {
 	t.Run("Test event message processing", func(t *testing.T) {
 		results := make(chan *esIndex, len(eventCases))
 		app := &Elasticsearch{
-			logger: logger,
+			logger: pluginlog.New(logger, "application", appname),
 			buffer: make(map[string][]string),
 			dump:   results,
 		}
@@ -270,7 +271,7 @@ func TestElasticsearchApp(t *testing.T) {
 	t.Run("Test log message processing", func(t *testing.T) {
 		results := make(chan *esIndex, len(logCases))
 		app := &Elasticsearch{
-			logger: logger,
+			logger: pluginlog.New(logger, "application", appname),
 			buffer: make(map[string][]string),
 			dump:   results,
 		}
@@ -11,6 +11,8 @@ import (
 	"github.com/infrawatch/sg-core/pkg/bus"
 	"github.com/infrawatch/sg-core/pkg/config"
 	"github.com/infrawatch/sg-core/pkg/data"
+	"github.com/infrawatch/sg-core/pkg/pluginlog"
+	"github.com/infrawatch/sg-core/pkg/registry"
 	"github.com/pkg/errors"
 
 	"github.com/infrawatch/sg-core/plugins/application/loki/pkg/lib"
@@ -27,14 +29,18 @@ type LokiConfig struct {
 type Loki struct {
 	config     *LokiConfig
 	client     *loki.LokiConnector
-	logger     *logging.Logger
+	logger     *pluginlog.Logger
 	logChannel chan interface{}
 }
 
+func init() {
+	registry.RegisterApplication("loki", New)
+}
+
 // New constructor
 func New(logger *logging.Logger, sendEvent bus.EventPublishFunc) application.Application {
 	return &Loki{
-		logger:     logger,
+		logger:     pluginlog.New(logger, "application", "loki"),
 		logChannel: make(chan interface{}, 100),
 	}
 }
@@ -45,30 +51,41 @@ func (l *Loki) ReceiveEvent(log data.Event) {
 	case data.LOG:
 		lokiLog, err := lib.CreateLokiLog(log)
 		if err != nil {
-			l.logger.Metadata(logging.Metadata{"plugin": "loki", "log": log, "error": err})
-			l.logger.Error("failed to parse the data in event bus - disregarding")
+			l.logger.Error("failed to parse the data in event bus - disregarding", logging.Metadata{"log": log, "error": err})
 			return
 		}
 		l.logChannel <- lokiLog
 	default:
-		l.logger.Metadata(logging.Metadata{"plugin": "loki", "event": log})
-		l.logger.Error("received event data (instead of log data) in event bus - disregarding")
+		l.logger.Error("received event data (instead of log data) in event bus - disregarding", logging.Metadata{"event": log})
 	}
 }
 
 // Run run loki application plugin
 func (l *Loki) Run(ctx context.Context, done chan bool) {
-	l.logger.Metadata(logging.Metadata{"plugin": "loki", "url": l.config.Connection})
-	l.logger.Info("storing logs to Loki.")
+	l.logger.Info("storing logs to Loki.", logging.Metadata{"url": l.config.Connection})
 	l.client.Start(nil, l.logChannel)
 
 	<-ctx.Done()
 	l.client.Disconnect()
 
-	l.logger.Metadata(logging.Metadata{"plugin": "loki"})
 	l.logger.Info("exited")
 }
 
+// Flush implements application.Flusher. Waits until logs queued for Loki are batched,
+// the last batch is sent when Run exits
+func (l *Loki) Flush(ctx context.Context) error {
+	tick := time.NewTicker(10 * time.Millisecond)
+	defer tick.Stop()
+	for len(l.logChannel) > 0 {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-tick.C:
+		}
+	}
+	return nil
+}
+
 // Config implements application.Application
 func (l *Loki) Config(c []byte) error {
 	l.config = &LokiConfig{
@@ -81,7 +98,7 @@ func (l *Loki) Config(c []byte) error {
 		return err
 	}
 
-	l.client, err = loki.CreateLokiConnector(l.logger,
+	l.client, err = loki.CreateLokiConnector(l.logger.Logger(),
 		l.config.Connection,
 		l.config.MaxWaitTime,
 		l.config.BatchSize,
@@ -10,6 +10,7 @@ import (
 	"github.com/infrawatch/apputils/logging"
 	"github.com/infrawatch/sg-core/pkg/bus"
 	"github.com/infrawatch/sg-core/pkg/data"
+	"github.com/infrawatch/sg-core/pkg/pluginlog"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -155,7 +156,7 @@ func TestLokiApp(t *testing.T) {
 	t.Run("Test log message processing", func(t *testing.T) {
 		results := make(chan interface{}, 100)
 		app := &Loki{
-			logger:     logger,
+			logger:     pluginlog.New(logger, "application", "loki"),
 			logChannel: results,
 		}
 
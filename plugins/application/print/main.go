@@ -13,6 +13,8 @@ import (
 	"github.com/infrawatch/sg-core/pkg/bus"
 	"github.com/infrawatch/sg-core/pkg/config"
 	"github.com/infrawatch/sg-core/pkg/data"
+	"github.com/infrawatch/sg-core/pkg/pluginlog"
+	"github.com/infrawatch/sg-core/pkg/registry"
 )
 
 type configT struct {
@@ -33,11 +35,15 @@ type eventOutput struct {
 // Print plugin suites for logging both internal buses to a file.
 type Print struct {
 	configuration configT
-	logger        *logging.Logger
+	logger        *pluginlog.Logger
 	eChan         chan data.Event
 	mChan         chan data.Metric
 }
 
+func init() {
+	registry.RegisterApplication("print", New)
+}
+
 // New constructor
 func New(logger *logging.Logger, sendEvent bus.EventPublishFunc) application.Application {
 	return &Print{
@@ -45,7 +51,7 @@ func New(logger *logging.Logger, sendEvent bus.EventPublishFunc) application.App
 			MetricOutput: "/dev/stdout",
 			EventsOutput: "/dev/stdout",
 		},
-		logger: logger,
+		logger: pluginlog.New(logger, "application", "print"),
 		eChan:  make(chan data.Event, 5),
 		mChan:  make(chan data.Metric, 5),
 	}
@@ -75,23 +81,20 @@ func (p *Print) Run(ctx context.Context, done chan bool) {
 
 	metrF, err := os.OpenFile(p.configuration.MetricOutput, os.O_RDWR|os.O_CREATE|os.O_APPEND, 0666)
 	if err != nil {
-		p.logger.Metadata(logging.Metadata{"plugin": "print", "error": err})
-		p.logger.Error("failed to open metrics data output file")
+		p.logger.Error("failed to open metrics data output file", logging.Metadata{"error": err})
 	} else {
 		defer metrF.Close()
 	}
 
 	evtsF, errr := os.OpenFile(p.configuration.EventsOutput, os.O_RDWR|os.O_CREATE|os.O_APPEND, 0666)
 	if err != nil {
-		p.logger.Metadata(logging.Metadata{"plugin": "print", "error": err})
-		p.logger.Error("failed to open events data output file")
+		p.logger.Error("failed to open events data output file", logging.Metadata{"error": err})
 	} else {
 		defer evtsF.Close()
 	}
 
 	if err == nil && errr == nil {
-		p.logger.Metadata(logging.Metadata{"plugin": "print", "events": p.configuration.EventsOutput, "metrics": p.configuration.MetricOutput})
-		p.logger.Info("writing processed data to files.")
+		p.logger.Info("writing processed data to files.", logging.Metadata{"events": p.configuration.EventsOutput, "metrics": p.configuration.MetricOutput})
 
 		for {
 			select {
@@ -109,22 +112,19 @@ func (p *Print) Run(ctx context.Context, done chan bool) {
 				}
 				encoded, err := json.MarshalIndent(eo, "", "  ")
 				if err != nil {
-					p.logger.Metadata(logging.Metadata{"plugin": "print", "data": event})
-					p.logger.Warn("failed to marshal event data")
+					p.logger.Warn("failed to marshal event data", logging.Metadata{"data": event})
 				}
 				evtsF.WriteString(fmt.Sprintf("Processed event:\n%s\n", string(encoded)))
 			case metrics := <-p.mChan:
 				encoded, err := json.MarshalIndent(metrics, "", "  ")
 				if err != nil {
-					p.logger.Metadata(logging.Metadata{"plugin": "print", "data": metrics})
-					p.logger.Warn("failed to marshal metric data")
+					p.logger.Warn("failed to marshal metric data", logging.Metadata{"data": metrics})
 				}
 				metrF.WriteString(fmt.Sprintf("Processed metric:\n%s\n", string(encoded)))
 			}
 		}
 	}
 done:
-	p.logger.Metadata(logging.Metadata{"plugin": "print"})
 	p.logger.Info("exited")
 }
 
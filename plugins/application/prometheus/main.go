@@ -14,6 +14,8 @@ import (
 	"github.com/infrawatch/sg-core/pkg/bus"
 	"github.com/infrawatch/sg-core/pkg/config"
 	"github.com/infrawatch/sg-core/pkg/data"
+	"github.com/infrawatch/sg-core/pkg/pluginlog"
+	"github.com/infrawatch/sg-core/pkg/registry"
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/client_golang/prometheus/promhttp"
 	"gopkg.in/errgo.v2/fmt/errors"
@@ -74,27 +76,26 @@ func (ce *collectorExpiry) Delete() bool {
 }
 
 type logWrapper struct {
-	l      *logging.Logger
-	plugin string
+	l *pluginlog.Logger
+}
+
+func newLogWrapper(l *logging.Logger) *logWrapper {
+	return &logWrapper{l: pluginlog.New(l, "application", "prometheus")}
 }
 
 func (lw *logWrapper) Error(msg string, err error) {
-	lw.l.Metadata(logging.Metadata{"plugin": lw.plugin, "error": err})
-	lw.l.Error(msg)
+	lw.l.Error(msg, logging.Metadata{"error": err})
 }
 
 func (lw *logWrapper) Warn(msg string) {
-	lw.l.Metadata(logging.Metadata{"plugin": lw.plugin})
 	lw.l.Warn(msg)
 }
 
 func (lw *logWrapper) Infof(format string, a ...interface{}) {
-	lw.l.Metadata(logging.Metadata{"plugin": lw.plugin})
 	lw.l.Info(fmt.Sprintf(format, a...))
 }
 
 func (lw *logWrapper) Debugf(format string, a ...interface{}) {
-	lw.l.Metadata(logging.Metadata{"plugin": lw.plugin})
 	lw.l.Debug(fmt.Sprintf(format, a...))
 }
 
@@ -233,6 +234,10 @@ type Prometheus struct {
 	sync.RWMutex
 }
 
+func init() {
+	registry.RegisterApplication("prometheus", New)
+}
+
 // New constructor
 func New(l *logging.Logger, sendEvent bus.EventPublishFunc) application.Application {
 	return &Prometheus{
@@ -241,10 +246,7 @@ func New(l *logging.Logger, sendEvent bus.EventPublishFunc) application.Applicat
 			Port:               3000,
 			ExpirationMultiple: 2,
 		},
-		logger: &logWrapper{
-			l:      l,
-			plugin: "Prometheus",
-		},
+		logger:              newLogWrapper(l),
 		collectors:          sync.Map{},
 		metricExpiryProcs:   sync.Map{},
 		collectorExpiryProc: newExpiryProc(time.Duration(10) * time.Second),
@@ -97,7 +97,7 @@ func TestNewPromCollector(t *testing.T) {
 	logger, err := logging.NewLogger(logging.DEBUG, logpath)
 	require.NoError(t, err)
 
-	lw := &logWrapper{l: logger, plugin: "test"}
+	lw := newLogWrapper(logger)
 
 	t.Run("create collector with timestamp", func(t *testing.T) {
 		pc := NewPromCollector(lw, 2, true)
@@ -124,7 +124,7 @@ func TestPromCollector_Dimensions(t *testing.T) {
 	logger, err := logging.NewLogger(logging.DEBUG, logpath)
 	require.NoError(t, err)
 
-	lw := &logWrapper{l: logger, plugin: "test"}
+	lw := newLogWrapper(logger)
 	pc := NewPromCollector(lw, 5, false)
 
 	assert.Equal(t, 5, pc.Dimensions())
@@ -183,7 +183,7 @@ func TestCollectorExpiry(t *testing.T) {
 	logger, err := logging.NewLogger(logging.DEBUG, logpath)
 	require.NoError(t, err)
 
-	lw := &logWrapper{l: logger, plugin: "test"}
+	lw := newLogWrapper(logger)
 
 	t.Run("Expired returns true when collector is empty", func(t *testing.T) {
 		pc := NewPromCollector(lw, 2, false)
@@ -248,7 +248,7 @@ func TestPromCollector_UpdateMetrics(t *testing.T) {
 	logger, err := logging.NewLogger(logging.DEBUG, logpath)
 	require.NoError(t, err)
 
-	lw := &logWrapper{l: logger, plugin: "test"}
+	lw := newLogWrapper(logger)
 
 	t.Run("add new metric", func(t *testing.T) {
 		pc := NewPromCollector(lw, 2, false)
@@ -363,7 +363,7 @@ func TestPromCollector_Describe(t *testing.T) {
 	logger, err := logging.NewLogger(logging.DEBUG, logpath)
 	require.NoError(t, err)
 
-	lw := &logWrapper{l: logger, plugin: "test"}
+	lw := newLogWrapper(logger)
 	pc := NewPromCollector(lw, 2, false)
 	ep := newExpiryProc(10 * time.Second)
 
@@ -413,7 +413,7 @@ func TestPromCollector_Collect(t *testing.T) {
 	logger, err := logging.NewLogger(logging.DEBUG, logpath)
 	require.NoError(t, err)
 
-	lw := &logWrapper{l: logger, plugin: "test"}
+	lw := newLogWrapper(logger)
 
 	t.Run("collect without timestamp", func(t *testing.T) {
 		pc := NewPromCollector(lw, 1, false)
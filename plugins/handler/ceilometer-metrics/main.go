@@ -6,12 +6,14 @@ import (
 	"errors"
 	"fmt"
 	"strings"
+	"sync/atomic"
 	"time"
 
 	"github.com/infrawatch/sg-core/pkg/bus"
 	"github.com/infrawatch/sg-core/pkg/config"
 	"github.com/infrawatch/sg-core/pkg/data"
 	"github.com/infrawatch/sg-core/pkg/handler"
+	"github.com/infrawatch/sg-core/pkg/registry"
 	"github.com/infrawatch/sg-core/plugins/handler/ceilometer-metrics/pkg/ceilometer"
 )
 
@@ -27,11 +29,12 @@ var (
 	}
 )
 
+// ceilometerMetricHandler is safe for concurrent use, counters are updated atomically
 type ceilometerMetricHandler struct {
 	ceilo                 *ceilometer.Ceilometer
-	totalMetricsDecoded   uint64
-	totalDecodeErrors     uint64
-	totalMessagesReceived uint64
+	totalMetricsDecoded   atomic.Uint64
+	totalDecodeErrors     atomic.Uint64
+	totalMessagesReceived atomic.Uint64
 	config                ceilometerConfig
 }
 
@@ -53,7 +56,7 @@ func (c *ceilometerMetricHandler) Run(ctx context.Context, mpf bus.MetricPublish
 				0,
 				data.COUNTER,
 				0,
-				float64(c.totalMetricsDecoded),
+				float64(c.totalMetricsDecoded.Load()),
 				[]string{"source"},
 				[]string{"SG"},
 			)
@@ -62,7 +65,7 @@ func (c *ceilometerMetricHandler) Run(ctx context.Context, mpf bus.MetricPublish
 				0,
 				data.COUNTER,
 				0,
-				float64(c.totalDecodeErrors),
+				float64(c.totalDecodeErrors.Load()),
 				[]string{"source"},
 				[]string{"SG"},
 			)
@@ -71,7 +74,7 @@ func (c *ceilometerMetricHandler) Run(ctx context.Context, mpf bus.MetricPublish
 				0,
 				data.COUNTER,
 				0,
-				float64(c.totalMessagesReceived),
+				float64(c.totalMessagesReceived.Load()),
 				[]string{"source"},
 				[]string{"SG"},
 			)
@@ -81,7 +84,12 @@ func (c *ceilometerMetricHandler) Run(ctx context.Context, mpf bus.MetricPublish
 }
 
 func (c *ceilometerMetricHandler) Handle(blob []byte, reportErrs bool, mpf bus.MetricPublishFunc, epf bus.EventPublishFunc) error {
-	c.totalMessagesReceived++
+	return c.HandleBatch(blob, reportErrs, mpf.Batch(), epf)
+}
+
+// HandleBatch publishes all metrics from payload of ceilometer message in single batch
+func (c *ceilometerMetricHandler) HandleBatch(blob []byte, reportErrs bool, mpf bus.MetricBatchPublishFunc, epf bus.EventPublishFunc) error {
+	c.totalMessagesReceived.Add(1)
 	var msg *ceilometer.Message
 	var err error
 	switch c.config.Source {
@@ -100,7 +108,7 @@ func (c *ceilometerMetricHandler) Handle(blob []byte, reportErrs bool, mpf bus.M
 
 	err = validateMessage(msg)
 	if err != nil {
-		c.totalDecodeErrors++
+		c.totalDecodeErrors.Add(1)
 		if reportErrs {
 			epf(data.Event{ // THIS IS EXTREMELY SLOW
 				Index:    c.Identify(),
@@ -121,6 +129,7 @@ func (c *ceilometerMetricHandler) Handle(blob []byte, reportErrs bool, mpf bus.M
 
 	var gTime time.Time
 	var t float64
+	metrics := make([]data.Metric, 0, len(msg.Payload))
 	for _, m := range msg.Payload {
 		gTime, _ = time.Parse(time.RFC3339, m.Timestamp)
 		t = float64(gTime.Unix())
@@ -130,7 +139,7 @@ func (c *ceilometerMetricHandler) Handle(blob []byte, reportErrs bool, mpf bus.M
 
 		mType := ceilTypeToMetricType[m.CounterType] // zero value is UNTYPED
 		if m.CounterName == "" {
-			c.totalDecodeErrors++
+			c.totalDecodeErrors.Add(1)
 			if reportErrs {
 				epf(data.Event{
 					Index:    c.Identify(),
@@ -146,23 +155,26 @@ func (c *ceilometerMetricHandler) Handle(blob []byte, reportErrs bool, mpf bus.M
 					},
 				})
 			}
+			// metrics preceding the invalid one are still published
+			mpf(metrics)
 			return errors.New("missing 'counter_name' in metric payload")
 		}
 
-		c.totalMetricsDecoded++
+		c.totalMetricsDecoded.Add(1)
 		cNameShards := strings.Split(m.CounterName, ".")
 		labelKeys, labelVals := genLabels(m, msg.Publisher, cNameShards)
-		mpf(
-			genName(cNameShards),
-			t,
-			mType,
-			time.Second*metricTimeout,
-			m.CounterVolume,
-			labelKeys,
-			labelVals,
-		)
+		metrics = append(metrics, data.Metric{
+			Name:      genName(cNameShards),
+			Time:      t,
+			Type:      mType,
+			Interval:  time.Second * metricTimeout,
+			Value:     m.CounterVolume,
+			LabelKeys: labelKeys,
+			LabelVals: labelVals,
+		})
 	}
 
+	mpf(metrics)
 	return nil
 }
 
@@ -311,6 +323,10 @@ func (c *ceilometerMetricHandler) Config(blob []byte) error {
 	return nil
 }
 
+func init() {
+	registry.RegisterHandler("ceilometer-metrics", New)
+}
+
 // New ceilometer metric handler constructor
 func New() handler.Handler {
 	return &ceilometerMetricHandler{
@@ -65,14 +65,16 @@ func New() *Ceilometer {
 	}
 }
 
-// ParseInputJSON parse blob into list of metrics
+// ParseInputJSON parse blob into list of metrics. It is safe to call concurrently
 func (c *Ceilometer) ParseInputJSON(blob []byte) (*Message, error) {
 	msg := &Message{}
-	err := json.Unmarshal(blob, &c.schema)
+	// schema is decoded into local parser, so that concurrent calls do not share it
+	parser := Ceilometer{}
+	err := json.Unmarshal(blob, &parser.schema)
 	if err != nil {
 		return nil, err
 	}
-	sanitized := c.sanitize()
+	sanitized := parser.sanitize()
 	err = json.Unmarshal([]byte(sanitized), &msg)
 	if err != nil {
 		return nil, err
@@ -2,12 +2,14 @@ package main
 
 import (
 	"context"
+	"sync/atomic"
 	"time"
 
 	"github.com/go-openapi/errors"
 	"github.com/infrawatch/sg-core/pkg/bus"
 	"github.com/infrawatch/sg-core/pkg/data"
 	"github.com/infrawatch/sg-core/pkg/handler"
+	"github.com/infrawatch/sg-core/pkg/registry"
 	"github.com/infrawatch/sg-core/plugins/handler/collectd-metrics/pkg/collectd"
 )
 
@@ -20,10 +22,11 @@ var (
 	}
 )
 
+// collectdMetricsHandler is safe for concurrent use, counters are updated atomically
 type collectdMetricsHandler struct {
-	totalMetricsDecoded   uint64 // total number of collectd metrics decoded from messages
-	totalMessagesReceived uint64
-	totalDecodeErrors     uint64
+	totalMetricsDecoded   atomic.Uint64 // total number of collectd metrics decoded from messages
+	totalMessagesReceived atomic.Uint64
+	totalDecodeErrors     atomic.Uint64
 }
 
 func (c *collectdMetricsHandler) Run(ctx context.Context, mpf bus.MetricPublishFunc, epf bus.EventPublishFunc) {
@@ -37,7 +40,7 @@ func (c *collectdMetricsHandler) Run(ctx context.Context, mpf bus.MetricPublishF
 				0,
 				data.COUNTER,
 				0,
-				float64(c.totalMetricsDecoded),
+				float64(c.totalMetricsDecoded.Load()),
 				[]string{"source"},
 				[]string{"SG"},
 			)
@@ -46,7 +49,7 @@ func (c *collectdMetricsHandler) Run(ctx context.Context, mpf bus.MetricPublishF
 				0,
 				data.COUNTER,
 				0,
-				float64(c.totalDecodeErrors),
+				float64(c.totalDecodeErrors.Load()),
 				[]string{"source"},
 				[]string{"SG"},
 			)
@@ -55,7 +58,7 @@ func (c *collectdMetricsHandler) Run(ctx context.Context, mpf bus.MetricPublishF
 				0,
 				data.COUNTER,
 				0,
-				float64(c.totalMessagesReceived),
+				float64(c.totalMessagesReceived.Load()),
 				[]string{"source"},
 				[]string{"SG"},
 			)
@@ -64,14 +67,19 @@ func (c *collectdMetricsHandler) Run(ctx context.Context, mpf bus.MetricPublishF
 }
 
 func (c *collectdMetricsHandler) Handle(blob []byte, reportErrors bool, pf bus.MetricPublishFunc, epf bus.EventPublishFunc) error {
-	c.totalMessagesReceived++
+	return c.HandleBatch(blob, reportErrors, pf.Batch(), epf)
+}
+
+// HandleBatch publishes all metrics parsed from collectd message in single batch
+func (c *collectdMetricsHandler) HandleBatch(blob []byte, reportErrors bool, pf bus.MetricBatchPublishFunc, epf bus.EventPublishFunc) error {
+	c.totalMessagesReceived.Add(1)
 	var err error
 	var cdmetrics *[]collectd.Metric
 
 	cdmetrics, err = collectd.ParseInputByte(blob)
 
 	if err != nil {
-		c.totalDecodeErrors++
+		c.totalDecodeErrors.Add(1)
 		if reportErrors {
 			epf(data.Event{
 				Index:    c.Identify(),
@@ -90,10 +98,11 @@ func (c *collectdMetricsHandler) Handle(blob []byte, reportErrors bool, pf bus.M
 		return nil
 	}
 
+	metrics := make([]data.Metric, 0, len(*cdmetrics))
 	for _, cdmetric := range *cdmetrics {
-		err = c.writeMetrics(cdmetric, pf)
+		metrics, err = c.writeMetrics(cdmetric, metrics)
 		if err != nil {
-			c.totalDecodeErrors++
+			c.totalDecodeErrors.Add(1)
 			if reportErrors {
 				epf(data.Event{
 					Index:    c.Identify(),
@@ -111,6 +120,7 @@ func (c *collectdMetricsHandler) Handle(blob []byte, reportErrors bool, pf bus.M
 			}
 		}
 	}
+	pf(metrics)
 	return nil
 }
 
@@ -118,9 +128,10 @@ func (c *collectdMetricsHandler) Identify() string {
 	return "collectd-metrics"
 }
 
-func (c *collectdMetricsHandler) writeMetrics(cdmetric collectd.Metric, pf bus.MetricPublishFunc) error {
+// writeMetrics appends metrics parsed from collectd metric to given slice
+func (c *collectdMetricsHandler) writeMetrics(cdmetric collectd.Metric, metrics []data.Metric) ([]data.Metric, error) {
 	if !validateMetric(&cdmetric) {
-		return errors.New(0, "")
+		return metrics, errors.New(0, "")
 	}
 	pluginInstance := cdmetric.PluginInstance
 	if pluginInstance == "" {
@@ -136,18 +147,18 @@ func (c *collectdMetricsHandler) writeMetrics(cdmetric collectd.Metric, pf bus.M
 		if !found {
 			mType = data.UNTYPED
 		}
-		pf(
-			genMetricName(&cdmetric, index),
-			cdmetric.Time.Float(),
-			mType,
-			time.Duration(cdmetric.Interval)*time.Second,
-			cdmetric.Values[index],
-			[]string{"host", "plugin_instance", "type_instance"},
-			[]string{cdmetric.Host, pluginInstance, typeInstance},
-		)
-		c.totalMetricsDecoded++
+		metrics = append(metrics, data.Metric{
+			Name:      genMetricName(&cdmetric, index),
+			Time:      cdmetric.Time.Float(),
+			Type:      mType,
+			Interval:  time.Duration(cdmetric.Interval) * time.Second,
+			Value:     cdmetric.Values[index],
+			LabelKeys: []string{"host", "plugin_instance", "type_instance"},
+			LabelVals: []string{cdmetric.Host, pluginInstance, typeInstance},
+		})
+		c.totalMetricsDecoded.Add(1)
 	}
-	return nil
+	return metrics, nil
 }
 
 func (c *collectdMetricsHandler) Config(blob []byte) error {
@@ -189,6 +200,10 @@ func genMetricName(cdmetric *collectd.Metric, index int) (name string) {
 	return
 }
 
+func init() {
+	registry.RegisterHandler("collectd-metrics", New)
+}
+
 // New create new collectdMetricsHandler object
 func New() handler.Handler {
 	return &collectdMetricsHandler{}
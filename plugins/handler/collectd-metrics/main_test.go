@@ -187,13 +187,13 @@ func TestMessageParsing(t *testing.T) {
 	metricHandler := New().(*collectdMetricsHandler)
 	t.Run("Invalid Messages", func(t *testing.T) {
 		for _, blob := range testMsgsInvalid {
-			metricHandler.totalDecodeErrors = 0
+			metricHandler.totalDecodeErrors.Store(0)
 			_ = metricHandler.Handle([]byte(blob), false, MetricReceive, EventReceive)
-			assert.Equal(t, uint64(1), metricHandler.totalDecodeErrors)
+			assert.Equal(t, uint64(1), metricHandler.totalDecodeErrors.Load())
 		}
 	})
 
-	metricHandler.totalDecodeErrors = 0
+	metricHandler.totalDecodeErrors.Store(0)
 	t.Run("Valid Messages", func(t *testing.T) {
 		for test, blob := range testMsgsValid {
 			metricsUT = []data.Metric{}
@@ -201,10 +201,24 @@ func TestMessageParsing(t *testing.T) {
 			if err != nil {
 				t.Error(err)
 			}
-			assert.Equal(t, uint64(0), metricHandler.totalDecodeErrors)
+			assert.Equal(t, uint64(0), metricHandler.totalDecodeErrors.Load())
 			assert.ElementsMatchf(t, validResults[test], metricsUT, "Failed: %s", test)
 		}
 	})
+
+	t.Run("Valid Messages in batch", func(t *testing.T) {
+		for test, blob := range testMsgsValid {
+			batches := [][]data.Metric{}
+			err := metricHandler.HandleBatch([]byte(blob), false, func(metrics []data.Metric) {
+				batches = append(batches, metrics)
+			}, EventReceive)
+			if err != nil {
+				t.Error(err)
+			}
+			assert.Len(t, batches, 1)
+			assert.ElementsMatchf(t, validResults[test], batches[0], "Failed: %s", test)
+		}
+	})
 }
 
 // func BenchmarkParsing(b *testing.B) {
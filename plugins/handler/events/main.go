@@ -4,12 +4,14 @@ import (
 	"bytes"
 	"context"
 	"fmt"
+	"sync"
 	"time"
 
 	"github.com/infrawatch/sg-core/pkg/bus"
 	"github.com/infrawatch/sg-core/pkg/config"
 	"github.com/infrawatch/sg-core/pkg/data"
 	"github.com/infrawatch/sg-core/pkg/handler"
+	"github.com/infrawatch/sg-core/pkg/registry"
 	"github.com/infrawatch/sg-core/plugins/handler/events/handlers"
 	"github.com/infrawatch/sg-core/plugins/handler/events/pkg/lib"
 )
@@ -17,6 +19,7 @@ import (
 // EventsHandler is processing event messages
 type EventsHandler struct {
 	eventsReceived map[string]uint64
+	statsLock      sync.Mutex
 	configuration  *lib.HandlerConfig
 }
 
@@ -31,10 +34,9 @@ func (eh *EventsHandler) Handle(msg []byte, reportErrors bool, sendMetric bus.Me
 		source.SetFromMessage(msg)
 	}
 
-	if _, ok := eh.eventsReceived[source.String()]; !ok {
-		eh.eventsReceived[source.String()] = uint64(0)
-	}
+	eh.statsLock.Lock()
 	eh.eventsReceived[source.String()]++
+	eh.statsLock.Unlock()
 
 	err := handlers.EventHandlers[source.String()](msg, sendEvent)
 	if err != nil {
@@ -66,7 +68,13 @@ func (eh *EventsHandler) Run(ctx context.Context, sendMetric bus.MetricPublishFu
 			goto done
 		case <-time.After(time.Second):
 			total := uint64(0)
+			eh.statsLock.Lock()
+			received := make(map[string]uint64, len(eh.eventsReceived))
 			for source, value := range eh.eventsReceived {
+				received[source] = value
+			}
+			eh.statsLock.Unlock()
+			for source, value := range received {
 				sendMetric(
 					fmt.Sprintf("sg_%s_events_received", source),
 					0,
@@ -103,6 +111,10 @@ func (eh *EventsHandler) Config(blob []byte) error {
 	return config.ParseConfig(bytes.NewReader(blob), eh.configuration)
 }
 
+func init() {
+	registry.RegisterHandler("events", New)
+}
+
 // New create new eventsHandler object
 func New() handler.Handler {
 	return &EventsHandler{eventsReceived: make(map[string]uint64)}
@@ -0,0 +1,94 @@
+package main
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/infrawatch/sg-core/pkg/bus"
+	"github.com/infrawatch/sg-core/pkg/data"
+	"github.com/infrawatch/sg-core/pkg/forward"
+	"github.com/infrawatch/sg-core/pkg/handler"
+	"github.com/infrawatch/sg-core/pkg/registry"
+)
+
+// forwardedHandler publishes events and metrics forwarded by another sg-core, eg. by amqp1 transport in write mode
+type forwardedHandler struct {
+	eventsReceived  uint64
+	metricsReceived uint64
+	statsLock       sync.Mutex
+}
+
+// Handle implements the handler.Handler interface
+func (fh *forwardedHandler) Handle(msg []byte, reportErrors bool, mpf bus.MetricPublishFunc, epf bus.EventPublishFunc) error {
+	e, m, err := forward.Decode(msg)
+	if err != nil {
+		if reportErrors {
+			epf(data.Event{
+				Index:    fh.Identify(),
+				Type:     data.ERROR,
+				Severity: data.CRITICAL,
+				Time:     0.0,
+				Labels: map[string]interface{}{
+					"error":   err.Error(),
+					"context": string(msg),
+					"message": "failed to decode forwarded data - disregarding",
+				},
+				Annotations: map[string]interface{}{
+					"description": "internal smartgateway forwarded data handler error",
+				},
+			})
+		}
+		return err
+	}
+
+	fh.statsLock.Lock()
+	if e != nil {
+		fh.eventsReceived++
+	} else {
+		fh.metricsReceived++
+	}
+	fh.statsLock.Unlock()
+
+	if e != nil {
+		epf(*e)
+		return nil
+	}
+	mpf(m.Name, m.Time, m.Type, m.Interval, m.Value, m.LabelKeys, m.LabelVals)
+	return nil
+}
+
+// Run send internal metrics to bus
+func (fh *forwardedHandler) Run(ctx context.Context, mpf bus.MetricPublishFunc, epf bus.EventPublishFunc) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(time.Second):
+			fh.statsLock.Lock()
+			events, metrics := fh.eventsReceived, fh.metricsReceived
+			fh.statsLock.Unlock()
+			mpf("sg_total_forwarded_events_received", 0, data.COUNTER, 0, float64(events), []string{"source"}, []string{"SG"})
+			mpf("sg_total_forwarded_metrics_received", 0, data.COUNTER, 0, float64(metrics), []string{"source"}, []string{"SG"})
+		}
+	}
+}
+
+// Identify returns handler's name
+func (fh *forwardedHandler) Identify() string {
+	return "forwarded"
+}
+
+// Config handler has no configuration
+func (fh *forwardedHandler) Config(c []byte) error {
+	return nil
+}
+
+func init() {
+	registry.RegisterHandler("forwarded", New)
+}
+
+// New create new forwardedHandler object
+func New() handler.Handler {
+	return &forwardedHandler{}
+}
@@ -0,0 +1,74 @@
+package main
+
+import (
+	"testing"
+	"time"
+
+	"github.com/infrawatch/sg-core/pkg/data"
+	"github.com/infrawatch/sg-core/pkg/forward"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestForwardedHandler(t *testing.T) {
+	h := New()
+	require.NoError(t, h.Config([]byte{}))
+
+	var events []data.Event
+	var metrics []data.Metric
+	epf := func(e data.Event) { events = append(events, e) }
+	mpf := func(name string, t float64, typ data.MetricType, interval time.Duration, value float64, labelKeys []string, labelVals []string) {
+		metrics = append(metrics, data.Metric{Name: name, Time: t, Type: typ, Interval: interval, Value: value, LabelKeys: labelKeys, LabelVals: labelVals})
+	}
+
+	t.Run("Test forwarded data are published again", func(t *testing.T) {
+		events, metrics = nil, nil
+		event := data.Event{
+			Index:       "collectd",
+			Time:        1690000000,
+			Type:        data.EVENT,
+			Publisher:   "edge-1",
+			Severity:    data.WARNING,
+			Labels:      map[string]interface{}{"host": "edge-1", "count": float64(3)},
+			Annotations: map[string]interface{}{"summary": "disk full"},
+			Message:     "line\nbreak",
+		}
+		metric := data.Metric{
+			Name:      "collectd_cpu_percent",
+			Time:      1690000000,
+			Type:      data.GAUGE,
+			Interval:  10 * time.Second,
+			Value:     1.5,
+			LabelKeys: []string{"host", "cpu"},
+			LabelVals: []string{"edge-1", "0"},
+		}
+
+		msg, err := forward.EncodeEvent(event)
+		require.NoError(t, err)
+		require.NoError(t, h.Handle(msg, true, mpf, epf))
+		msg, err = forward.EncodeMetric(metric)
+		require.NoError(t, err)
+		require.NoError(t, h.Handle(msg, true, mpf, epf))
+
+		assert.Equal(t, []data.Event{event}, events)
+		metric.LabelKeys, metric.LabelVals = []string{"cpu", "host"}, []string{"0", "edge-1"}
+		assert.Equal(t, []data.Metric{metric}, metrics, "labels are sorted by name")
+	})
+
+	t.Run("Test invalid data are reported", func(t *testing.T) {
+		for _, msg := range []string{
+			`not json`,
+			`{"value": 1}`,
+			`{"index": "collectd", "type": "alarm", "severity": "info"}`,
+			`{"index": "collectd", "type": "event", "severity": "fatal"}`,
+			`{"name": "collectd_cpu_percent", "type": "histogram"}`,
+		} {
+			events, metrics = nil, nil
+			assert.Error(t, h.Handle([]byte(msg), true, mpf, epf), msg)
+			require.Len(t, events, 1, msg)
+			assert.Equal(t, data.ERROR, events[0].Type)
+			assert.Equal(t, msg, events[0].Labels["context"])
+			assert.Empty(t, metrics)
+		}
+	})
+}
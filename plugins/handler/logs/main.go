@@ -13,6 +13,7 @@ import (
 	"github.com/infrawatch/sg-core/pkg/config"
 	"github.com/infrawatch/sg-core/pkg/data"
 	"github.com/infrawatch/sg-core/pkg/handler"
+	"github.com/infrawatch/sg-core/pkg/registry"
 	"github.com/infrawatch/sg-core/plugins/handler/logs/pkg/lib"
 )
 
@@ -75,12 +76,12 @@ func (l *logHandler) parse(log []byte) (data.Event, error) {
 
 // Handle implements the data.EventsHandler interface
 func (l *logHandler) Handle(msg []byte, reportErrors bool, mpf bus.MetricPublishFunc, epf bus.EventPublishFunc) error {
-	var err error
 	l.statsLock.Lock()
 	l.totalLogsReceived++
 	l.statsLock.Unlock()
 
-	if log, err := l.parse(msg); err == nil {
+	log, err := l.parse(msg)
+	if err == nil {
 		epf(log)
 	} else if reportErrors {
 		epf(data.Event{
@@ -129,6 +130,10 @@ func (l *logHandler) Identify() string {
 	return "log"
 }
 
+func init() {
+	registry.RegisterHandler("logs", New)
+}
+
 // New create new logHandler object
 func New() handler.Handler {
 	return &logHandler{
@@ -4,6 +4,7 @@ import (
 	"testing"
 
 	"github.com/infrawatch/sg-core/pkg/data"
+	"github.com/infrawatch/sg-core/pkg/deadletter"
 	"github.com/infrawatch/sg-core/plugins/handler/logs/pkg/lib"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -108,4 +109,32 @@ func TestLogs(t *testing.T) {
 		}
 	})
 
+	t.Run("Test malformed log is stored as dead letter", func(t *testing.T) {
+		l := logHandler{
+			config: testConfig,
+		}
+		store, err := deadletter.New(t.TempDir(), 10)
+		require.NoError(t, err)
+
+		malformed := []byte(`{"host":"localhost", "message":"truncated`)
+		errorEvents := 0
+		// transport pipeline stores messages which handlers fail to handle
+		if err := l.Handle(malformed, true, nil, func(evt data.Event) {
+			assert.Equal(t, data.ERROR, evt.Type)
+			errorEvents++
+		}); err != nil {
+			_, err = store.Add("logs", malformed, err)
+			require.NoError(t, err)
+		}
+		assert.Equal(t, 1, errorEvents)
+
+		entries, err := store.List()
+		require.NoError(t, err)
+		require.Len(t, entries, 1)
+		assert.Equal(t, "logs", entries[0].Handler)
+		assert.NotEmpty(t, entries[0].Error)
+		_, blob, err := store.Get(entries[0].ID)
+		require.NoError(t, err)
+		assert.Equal(t, malformed, blob)
+	})
 }
@@ -4,12 +4,14 @@ import (
 	"bytes"
 	"context"
 	"fmt"
+	"sync/atomic"
 	"time"
 
 	"github.com/infrawatch/sg-core/pkg/bus"
 	"github.com/infrawatch/sg-core/pkg/config"
 	"github.com/infrawatch/sg-core/pkg/data"
 	"github.com/infrawatch/sg-core/pkg/handler"
+	"github.com/infrawatch/sg-core/pkg/registry"
 	"github.com/infrawatch/sg-core/plugins/handler/events/pkg/lib"
 	"github.com/infrawatch/sg-core/plugins/handler/sensubility-metrics/pkg/sensu"
 	jsoniter "github.com/json-iterator/go"
@@ -24,10 +26,11 @@ type configT struct {
 	MetricInterval int `yaml:"metricInterval"` // interval at which metrics are expected to arrive. Default 10s
 }
 
+// sensubilityMetrics is safe for concurrent use, counters are updated atomically
 type sensubilityMetrics struct {
-	totalMetricsDecoded   int64
-	totalDecodeErrors     int64
-	totalMessagesReceived int64
+	totalMetricsDecoded   atomic.Int64
+	totalDecodeErrors     atomic.Int64
+	totalMessagesReceived atomic.Int64
 	configuration         *configT
 }
 
@@ -42,7 +45,7 @@ func (sm *sensubilityMetrics) Run(ctx context.Context, mpf bus.MetricPublishFunc
 				0,
 				data.COUNTER,
 				0,
-				float64(sm.totalMetricsDecoded),
+				float64(sm.totalMetricsDecoded.Load()),
 				[]string{"source"},
 				[]string{"SG"},
 			)
@@ -51,7 +54,7 @@ func (sm *sensubilityMetrics) Run(ctx context.Context, mpf bus.MetricPublishFunc
 				0,
 				data.COUNTER,
 				0,
-				float64(sm.totalDecodeErrors),
+				float64(sm.totalDecodeErrors.Load()),
 				[]string{"source"},
 				[]string{"SG"},
 			)
@@ -60,7 +63,7 @@ func (sm *sensubilityMetrics) Run(ctx context.Context, mpf bus.MetricPublishFunc
 				0,
 				data.COUNTER,
 				0,
-				float64(sm.totalMessagesReceived),
+				float64(sm.totalMessagesReceived.Load()),
 				[]string{"source"},
 				[]string{"SG"},
 			)
@@ -69,7 +72,7 @@ func (sm *sensubilityMetrics) Run(ctx context.Context, mpf bus.MetricPublishFunc
 }
 
 func (sm *sensubilityMetrics) Handle(blob []byte, reportErrors bool, mpf bus.MetricPublishFunc, epf bus.EventPublishFunc) error {
-	sm.totalMessagesReceived++
+	sm.totalMessagesReceived.Add(1)
 	sensuMsg := sensu.Message{}
 	err := json.Unmarshal(blob, &sensuMsg)
 	if err != nil {
@@ -78,7 +81,7 @@ func (sm *sensubilityMetrics) Handle(blob []byte, reportErrors bool, mpf bus.Met
 
 	// validate top level fields in sensu message
 	if !sensu.IsMsgValid(sensuMsg) {
-		sm.totalDecodeErrors++
+		sm.totalDecodeErrors.Add(1)
 		err := sensu.BuildMsgErr(sensuMsg)
 		if reportErrors {
 			sm.publishErrEvent(err, epf)
@@ -96,12 +99,12 @@ func (sm *sensubilityMetrics) Handle(blob []byte, reportErrors bool, mpf bus.Met
 
 	// validate healthcheck messages
 	if !sensu.IsOutputValid(outputs) {
-		sm.totalDecodeErrors++
+		sm.totalDecodeErrors.Add(1)
 		err := sensu.BuildOutputsErr(outputs)
 		if reportErrors {
 			sm.publishErrEvent(err, epf)
 		}
-		sm.totalDecodeErrors += int64(len(err.(*sensu.ErrMissingFields).Fields))
+		sm.totalDecodeErrors.Add(int64(len(err.(*sensu.ErrMissingFields).Fields)))
 		return err
 	}
 
@@ -113,7 +116,7 @@ func (sm *sensubilityMetrics) Handle(blob []byte, reportErrors bool, mpf bus.Met
 
 	// send each healthcheck result as a new metric to the internal metric bus
 	for _, output := range outputs {
-		sm.totalMetricsDecoded++
+		sm.totalMetricsDecoded.Add(1)
 		mpf(
 			metricName,
 			float64(epoc),
@@ -138,6 +141,10 @@ func (sm *sensubilityMetrics) Config(blob []byte) error {
 	return config.ParseConfig(bytes.NewReader(blob), sm.configuration)
 }
 
+func init() {
+	registry.RegisterHandler("sensubility-metrics", New)
+}
+
 func New() handler.Handler {
 	return &sensubilityMetrics{}
 }
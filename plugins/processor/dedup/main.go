@@ -0,0 +1,148 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/infrawatch/apputils/logging"
+	"github.com/infrawatch/sg-core/pkg/config"
+	"github.com/infrawatch/sg-core/pkg/data"
+	"github.com/infrawatch/sg-core/pkg/pluginlog"
+	"github.com/infrawatch/sg-core/pkg/processor"
+	"github.com/infrawatch/sg-core/pkg/registry"
+)
+
+type configT struct {
+	Keys       []string      `yaml:"keys" validate:"required,min=1"` // labels identifying repeated events
+	Window     time.Duration `yaml:"window"`                         // repeats are suppressed for this long after an event was emitted
+	Annotation string        `yaml:"annotation" validate:"required"` // annotation holding count of suppressed repeats
+}
+
+// fingerprint state of events with the same fingerprint
+type state struct {
+	emitted    time.Time
+	seen       time.Time
+	severity   data.EventSeverity
+	suppressed int
+}
+
+// Dedup suppresses repeated events within a time window
+type Dedup struct {
+	configuration configT
+	logger        *pluginlog.Logger
+	now           func() time.Time
+	mutex         sync.Mutex
+	states        map[string]*state
+}
+
+func init() {
+	registry.RegisterProcessor("dedup", New)
+}
+
+// New constructor
+func New(l *logging.Logger) processor.Processor {
+	return &Dedup{
+		configuration: configT{
+			Window:     5 * time.Minute,
+			Annotation: "repeats",
+		},
+		logger: pluginlog.New(l, "processor", "dedup"),
+		now:    time.Now,
+		states: map[string]*state{},
+	}
+}
+
+// Config implements processor.Processor
+func (d *Dedup) Config(c []byte) error {
+	err := config.ParseConfig(bytes.NewReader(c), &d.configuration)
+	if err != nil {
+		return err
+	}
+	if d.configuration.Window <= 0 {
+		return fmt.Errorf("window has to be positive")
+	}
+	return nil
+}
+
+// fingerprint identifies event by its index and values of configured labels
+func (d *Dedup) fingerprint(e data.Event) string {
+	var sb strings.Builder
+	sb.WriteString(e.Index)
+	for _, key := range d.configuration.Keys {
+		sb.WriteByte(0)
+		if value, ok := e.Labels[key]; ok {
+			sb.WriteString(fmt.Sprint(value))
+		}
+	}
+	return sb.String()
+}
+
+// ProcessEvent implements processor.EventProcessor. Event is emitted when no event with the same
+// fingerprint was emitted within the window or when its severity changed. Emitted event carries
+// count of repeats suppressed since the previous emission
+func (d *Dedup) ProcessEvent(e data.Event) []data.Event {
+	fp := d.fingerprint(e)
+	now := d.now()
+
+	d.mutex.Lock()
+	st, ok := d.states[fp]
+	if ok && now.Sub(st.emitted) < d.configuration.Window && st.severity == e.Severity {
+		st.suppressed++
+		st.seen = now
+		d.mutex.Unlock()
+		return nil
+	}
+	suppressed := 0
+	if ok {
+		suppressed = st.suppressed
+	}
+	d.states[fp] = &state{emitted: now, seen: now, severity: e.Severity}
+	d.mutex.Unlock()
+
+	if suppressed > 0 {
+		// annotations map can be shared between events
+		annotations := make(map[string]interface{}, len(e.Annotations)+1)
+		for k, v := range e.Annotations {
+			annotations[k] = v
+		}
+		annotations[d.configuration.Annotation] = suppressed
+		e.Annotations = annotations
+	}
+	return []data.Event{e}
+}
+
+// Run implements processor.Runner. States of fingerprints not seen within the window are expired
+func (d *Dedup) Run(ctx context.Context) {
+	ticker := time.NewTicker(d.configuration.Window)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if expired := d.expire(); expired > 0 {
+				d.logger.Debug("expired event fingerprints", logging.Metadata{"count": expired})
+			}
+		}
+	}
+}
+
+// expire removes fingerprints not seen for the whole window. Repeats suppressed before are
+// not reported, since no later event carries them
+func (d *Dedup) expire() int {
+	now := d.now()
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+	expired := 0
+	for fp, st := range d.states {
+		if now.Sub(st.seen) >= d.configuration.Window {
+			delete(d.states, fp)
+			expired++
+		}
+	}
+	return expired
+}
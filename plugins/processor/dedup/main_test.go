@@ -0,0 +1,80 @@
+package main
+
+import (
+	"testing"
+	"time"
+
+	"github.com/infrawatch/sg-core/pkg/data"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDedup(t *testing.T) {
+	d := New(nil).(*Dedup)
+	require.NoError(t, d.Config([]byte(`
+keys: [alertname, instance]
+window: 1m
+`)))
+	now := time.Unix(0, 0)
+	d.now = func() time.Time { return now }
+
+	alert := func(instance string, severity data.EventSeverity) data.Event {
+		return data.Event{
+			Index:       "alerts",
+			Severity:    severity,
+			Labels:      map[string]interface{}{"alertname": "DiskFull", "instance": instance, "value": now.Unix()},
+			Annotations: map[string]interface{}{"summary": "disk is full"},
+		}
+	}
+
+	t.Run("first event is emitted", func(t *testing.T) {
+		assert.Equal(t, []data.Event{alert("compute-0", data.WARNING)}, d.ProcessEvent(alert("compute-0", data.WARNING)))
+		assert.Len(t, d.ProcessEvent(alert("compute-1", data.WARNING)), 1, "different fingerprint")
+	})
+
+	t.Run("repeats within window are suppressed", func(t *testing.T) {
+		for i := 0; i < 3; i++ {
+			now = now.Add(10 * time.Second)
+			assert.Empty(t, d.ProcessEvent(alert("compute-0", data.WARNING)))
+		}
+	})
+
+	t.Run("severity change is emitted with repeat count", func(t *testing.T) {
+		now = now.Add(10 * time.Second)
+		shared := alert("compute-0", data.CRITICAL)
+		events := d.ProcessEvent(shared)
+		require.Len(t, events, 1)
+		assert.Equal(t, map[string]interface{}{"summary": "disk is full", "repeats": 3}, events[0].Annotations)
+		assert.Equal(t, map[string]interface{}{"summary": "disk is full"}, shared.Annotations, "shared annotations are not changed")
+		assert.Empty(t, d.ProcessEvent(alert("compute-0", data.CRITICAL)))
+	})
+
+	t.Run("event is emitted after window", func(t *testing.T) {
+		now = now.Add(time.Minute)
+		events := d.ProcessEvent(alert("compute-0", data.CRITICAL))
+		require.Len(t, events, 1)
+		assert.Equal(t, 1, events[0].Annotations["repeats"])
+	})
+
+	t.Run("fingerprints not seen within window expire", func(t *testing.T) {
+		now = now.Add(30 * time.Second)
+		assert.Empty(t, d.ProcessEvent(alert("compute-0", data.CRITICAL)))
+		now = now.Add(40 * time.Second)
+		assert.Equal(t, 1, d.expire(), "compute-1 expired")
+		now = now.Add(10 * time.Second)
+		assert.Equal(t, 0, d.expire(), "compute-0 was seen within window")
+		now = now.Add(20 * time.Second)
+		assert.Equal(t, 1, d.expire())
+		assert.Empty(t, d.states)
+	})
+}
+
+func TestInvalidConfig(t *testing.T) {
+	for name, c := range map[string]string{
+		"missing keys":     "window: 1m",
+		"negative window":  "keys: [alertname]\nwindow: -1s",
+		"empty annotation": "keys: [alertname]\nannotation: \"\"",
+	} {
+		assert.Error(t, New(nil).Config([]byte(c)), name)
+	}
+}
@@ -0,0 +1,294 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/infrawatch/apputils/logging"
+	"github.com/infrawatch/sg-core/pkg/config"
+	"github.com/infrawatch/sg-core/pkg/data"
+	"github.com/infrawatch/sg-core/pkg/pluginlog"
+	"github.com/infrawatch/sg-core/pkg/processor"
+	"github.com/infrawatch/sg-core/pkg/registry"
+	"github.com/pkg/errors"
+	"gopkg.in/yaml.v2"
+)
+
+// publisherKey refers to Publisher field of events when the label is missing
+const publisherKey = "publisher"
+
+type lookupConfig struct {
+	File     string        `yaml:"file"`     // csv or yaml file, format is decided by the extension
+	Keys     []string      `yaml:"keys"`     // labels whose values are looked up, first found key is used
+	Column   string        `yaml:"column"`   // key column of csv file, first column by default
+	Interval time.Duration `yaml:"interval"` // how often the file is checked for changes
+}
+
+type configT struct {
+	Labels    map[string]string `yaml:"labels"` // static labels added to all metrics and events
+	Lookup    lookupConfig      `yaml:"lookup"`
+	Overwrite bool              `yaml:"overwrite"` // replace values of existing labels
+}
+
+// table maps lookup key to labels
+type table map[string]map[string]string
+
+// Enrich adds static labels and labels from lookup table to metrics and events
+type Enrich struct {
+	configuration configT
+	logger        *pluginlog.Logger
+	table         atomic.Pointer[table]
+	modTime       time.Time
+	size          int64
+}
+
+func init() {
+	registry.RegisterProcessor("enrich", New)
+}
+
+// New constructor
+func New(l *logging.Logger) processor.Processor {
+	return &Enrich{
+		configuration: configT{
+			Lookup: lookupConfig{
+				Interval: 10 * time.Second,
+			},
+		},
+		logger: pluginlog.New(l, "processor", "enrich"),
+	}
+}
+
+// Config implements processor.Processor
+func (e *Enrich) Config(c []byte) error {
+	err := config.ParseConfig(bytes.NewReader(c), &e.configuration)
+	if err != nil {
+		return err
+	}
+	if e.configuration.Lookup.File == "" {
+		return nil
+	}
+	if len(e.configuration.Lookup.Keys) == 0 {
+		return fmt.Errorf("lookup keys are required with lookup file")
+	}
+	if e.configuration.Lookup.Interval <= 0 {
+		return fmt.Errorf("lookup interval has to be positive")
+	}
+	// first load has to succeed, so that misconfiguration is found early
+	_, err = e.reload()
+	return err
+}
+
+// Run implements processor.Runner. Lookup file is reloaded when it changes
+func (e *Enrich) Run(ctx context.Context) {
+	if e.configuration.Lookup.File == "" {
+		return
+	}
+	ticker := time.NewTicker(e.configuration.Lookup.Interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			reloaded, err := e.reload()
+			if err != nil {
+				e.logger.Error("failed reloading lookup file, keeping current table", logging.Metadata{"file": e.configuration.Lookup.File, "error": err})
+				continue
+			}
+			if reloaded {
+				e.logger.Info("reloaded lookup file", logging.Metadata{"file": e.configuration.Lookup.File, "entries": len(*e.table.Load())})
+			}
+		}
+	}
+}
+
+// reload loads lookup file if it changed since last load
+func (e *Enrich) reload() (bool, error) {
+	info, err := os.Stat(e.configuration.Lookup.File)
+	if err != nil {
+		return false, err
+	}
+	if info.ModTime().Equal(e.modTime) && info.Size() == e.size {
+		return false, nil
+	}
+	t, err := loadTable(e.configuration.Lookup.File, e.configuration.Lookup.Column)
+	if err != nil {
+		return false, err
+	}
+	e.table.Store(&t)
+	e.modTime = info.ModTime()
+	e.size = info.Size()
+	return true, nil
+}
+
+// loadTable reads lookup table from csv file with header or from yaml file mapping keys to labels
+func loadTable(path string, column string) (table, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	t := table{}
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		err = yaml.Unmarshal(content, &t)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed parsing lookup file")
+		}
+	case ".csv":
+		records, err := csv.NewReader(bytes.NewReader(content)).ReadAll()
+		if err != nil {
+			return nil, errors.Wrap(err, "failed parsing lookup file")
+		}
+		if len(records) == 0 {
+			return t, nil
+		}
+		header := records[0]
+		keyCol := 0
+		if column != "" {
+			keyCol = -1
+			for i, name := range header {
+				if name == column {
+					keyCol = i
+				}
+			}
+			if keyCol < 0 {
+				return nil, fmt.Errorf("key column %s not found in lookup file", column)
+			}
+		}
+		for _, record := range records[1:] {
+			labels := map[string]string{}
+			for i, value := range record {
+				if i != keyCol && value != "" {
+					labels[header[i]] = value
+				}
+			}
+			t[record[keyCol]] = labels
+		}
+	default:
+		return nil, fmt.Errorf("unsupported format of lookup file %s, expected csv or yaml", path)
+	}
+	return t, nil
+}
+
+// labels returns labels to add, labels from lookup table take precedence over static labels
+func (e *Enrich) labels(get func(string) (string, bool)) map[string]string {
+	var found map[string]string
+	if t := e.table.Load(); t != nil {
+		for _, key := range e.configuration.Lookup.Keys {
+			if value, ok := get(key); ok {
+				if labels, ok := (*t)[value]; ok {
+					found = labels
+					break
+				}
+			}
+		}
+	}
+	if len(found) == 0 {
+		return e.configuration.Labels
+	}
+	if len(e.configuration.Labels) == 0 {
+		return found
+	}
+	res := make(map[string]string, len(found)+len(e.configuration.Labels))
+	for name, value := range e.configuration.Labels {
+		res[name] = value
+	}
+	for name, value := range found {
+		res[name] = value
+	}
+	return res
+}
+
+// ProcessMetrics implements processor.MetricProcessor
+func (e *Enrich) ProcessMetrics(metrics []data.Metric) []data.Metric {
+	for i := range metrics {
+		m := &metrics[i]
+		get := func(name string) (string, bool) {
+			for j, key := range m.LabelKeys {
+				if key == name && j < len(m.LabelVals) {
+					return m.LabelVals[j], true
+				}
+			}
+			return "", false
+		}
+
+		added := e.labels(get)
+		if len(added) == 0 {
+			continue
+		}
+		// label slices can be shared between metrics
+		keys := append([]string{}, m.LabelKeys...)
+		vals := append([]string{}, m.LabelVals...)
+		for _, name := range sortedKeys(added) {
+			j := indexOf(keys, name)
+			switch {
+			case j < 0:
+				keys = append(keys, name)
+				vals = append(vals, added[name])
+			case e.configuration.Overwrite:
+				vals[j] = added[name]
+			}
+		}
+		m.LabelKeys = keys
+		m.LabelVals = vals
+	}
+	return metrics
+}
+
+// ProcessEvent implements processor.EventProcessor
+func (e *Enrich) ProcessEvent(event data.Event) []data.Event {
+	get := func(name string) (string, bool) {
+		if value, ok := event.Labels[name]; ok {
+			return fmt.Sprint(value), true
+		}
+		if name == publisherKey && event.Publisher != "" {
+			return event.Publisher, true
+		}
+		return "", false
+	}
+
+	added := e.labels(get)
+	if len(added) == 0 {
+		return []data.Event{event}
+	}
+	// labels map can be shared between events
+	labels := make(map[string]interface{}, len(event.Labels))
+	for k, v := range event.Labels {
+		labels[k] = v
+	}
+	for name, value := range added {
+		if _, ok := labels[name]; !ok || e.configuration.Overwrite {
+			labels[name] = value
+		}
+	}
+	event.Labels = labels
+	return []data.Event{event}
+}
+
+func indexOf(items []string, item string) int {
+	for i, it := range items {
+		if it == item {
+			return i
+		}
+	}
+	return -1
+}
+
+// sortedKeys returns keys in stable order, so that metrics get labels in the same order each time
+func sortedKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,128 @@
+package main
+
+import (
+	"context"
+	"os"
+	"path"
+	"testing"
+	"time"
+
+	"github.com/infrawatch/apputils/logging"
+	"github.com/infrawatch/sg-core/pkg/data"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newEnrich(t *testing.T, config string) *Enrich {
+	logger, err := logging.NewLogger(logging.DEBUG, path.Join(t.TempDir(), "test.log"))
+	require.NoError(t, err)
+	e := New(logger).(*Enrich)
+	require.NoError(t, e.Config([]byte(config)))
+	return e
+}
+
+func TestStaticLabels(t *testing.T) {
+	e := newEnrich(t, `
+labels:
+  site: brno
+  cluster: overcloud
+`)
+	keys := []string{"host", "site"}
+	vals := []string{"compute-0", "prague"}
+	metrics := e.ProcessMetrics([]data.Metric{{Name: "m", LabelKeys: keys, LabelVals: vals}})
+	assert.Equal(t, []data.Metric{{
+		Name:      "m",
+		LabelKeys: []string{"host", "site", "cluster"},
+		LabelVals: []string{"compute-0", "prague", "overcloud"},
+	}}, metrics, "existing labels are not overwritten")
+	assert.Equal(t, []string{"host", "site"}, keys)
+
+	labels := map[string]interface{}{"host": "compute-0"}
+	events := e.ProcessEvent(data.Event{Index: "i", Labels: labels})
+	assert.Equal(t, []data.Event{{Index: "i", Labels: map[string]interface{}{
+		"host": "compute-0", "site": "brno", "cluster": "overcloud",
+	}}}, events)
+	assert.Equal(t, map[string]interface{}{"host": "compute-0"}, labels, "shared labels map is not changed")
+}
+
+func TestLookup(t *testing.T) {
+	dir := t.TempDir()
+	csvPath := path.Join(dir, "hosts.csv")
+	require.NoError(t, os.WriteFile(csvPath, []byte("rack,host,owner\nr1,compute-0,team-a\nr2,compute-1,\n"), 0600))
+	yamlPath := path.Join(dir, "hosts.yaml")
+	require.NoError(t, os.WriteFile(yamlPath, []byte("compute-0:\n  rack: r1\n  owner: team-a\n"), 0600))
+
+	for _, file := range []string{csvPath, yamlPath} {
+		t.Run(path.Ext(file), func(t *testing.T) {
+			e := newEnrich(t, `
+labels:
+  site: brno
+  owner: nobody
+lookup:
+  file: `+file+`
+  column: host
+  keys: [host, publisher]
+`)
+			metrics := e.ProcessMetrics([]data.Metric{
+				{Name: "m", LabelKeys: []string{"host"}, LabelVals: []string{"compute-0"}},
+				{Name: "m", LabelKeys: []string{"host"}, LabelVals: []string{"unknown"}},
+			})
+			assert.Equal(t, []data.Metric{
+				{Name: "m", LabelKeys: []string{"host", "owner", "rack", "site"}, LabelVals: []string{"compute-0", "team-a", "r1", "brno"}},
+				{Name: "m", LabelKeys: []string{"host", "owner", "site"}, LabelVals: []string{"unknown", "nobody", "brno"}},
+			}, metrics)
+
+			events := e.ProcessEvent(data.Event{Publisher: "compute-0", Labels: map[string]interface{}{"type": "log"}})
+			assert.Equal(t, map[string]interface{}{"type": "log", "rack": "r1", "owner": "team-a", "site": "brno"}, events[0].Labels)
+		})
+	}
+}
+
+func TestLookupReload(t *testing.T) {
+	file := path.Join(t.TempDir(), "hosts.yaml")
+	require.NoError(t, os.WriteFile(file, []byte("compute-0: {rack: r1}\n"), 0600))
+	e := newEnrich(t, `
+lookup:
+  file: `+file+`
+  keys: [host]
+  interval: 10ms
+`)
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		e.Run(ctx)
+		close(done)
+	}()
+	defer func() {
+		cancel()
+		<-done
+	}()
+
+	event := data.Event{Labels: map[string]interface{}{"host": "compute-0"}}
+	assert.Equal(t, "r1", e.ProcessEvent(event)[0].Labels["rack"])
+
+	require.NoError(t, os.WriteFile(file, []byte("compute-0: {rack: r2}\n"), 0600))
+	assert.Eventually(t, func() bool {
+		return e.ProcessEvent(event)[0].Labels["rack"] == "r2"
+	}, time.Second, 10*time.Millisecond)
+
+	// invalid file keeps current table
+	require.NoError(t, os.WriteFile(file, []byte("compute-0: [\n"), 0600))
+	time.Sleep(50 * time.Millisecond)
+	assert.Equal(t, "r2", e.ProcessEvent(event)[0].Labels["rack"])
+}
+
+func TestInvalidLookup(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(path.Join(dir, "hosts.json"), []byte("{}"), 0600))
+	require.NoError(t, os.WriteFile(path.Join(dir, "hosts.csv"), []byte("host,rack\n"), 0600))
+	for name, c := range map[string]string{
+		"missing file":       "lookup: {file: " + path.Join(dir, "missing.csv") + ", keys: [host]}",
+		"missing keys":       "lookup: {file: " + path.Join(dir, "hosts.csv") + "}",
+		"unsupported format": "lookup: {file: " + path.Join(dir, "hosts.json") + ", keys: [host]}",
+		"unknown column":     "lookup: {file: " + path.Join(dir, "hosts.csv") + ", keys: [host], column: name}",
+	} {
+		e := New(nil)
+		assert.Error(t, e.Config([]byte(c)), name)
+	}
+}
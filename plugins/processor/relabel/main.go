@@ -0,0 +1,266 @@
+package main
+
+import (
+	"bytes"
+	"crypto/md5" //nolint:gosec // used for hashmod compatible with Prometheus, not for security
+	"encoding/binary"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/infrawatch/apputils/logging"
+	"github.com/infrawatch/sg-core/pkg/config"
+	"github.com/infrawatch/sg-core/pkg/data"
+	"github.com/infrawatch/sg-core/pkg/processor"
+	"github.com/infrawatch/sg-core/pkg/registry"
+	"github.com/pkg/errors"
+)
+
+// nameLabel refers to metric name in source_labels and target_label, same as in Prometheus
+const nameLabel = "__name__"
+
+// relabel actions
+const (
+	actionReplace   = "replace"
+	actionKeep      = "keep"
+	actionDrop      = "drop"
+	actionLabelDrop = "labeldrop"
+	actionLabelKeep = "labelkeep"
+	actionLabelMap  = "labelmap"
+	actionHashMod   = "hashmod"
+)
+
+// Regexp is regular expression anchored on both ends, as in Prometheus
+type Regexp struct {
+	*regexp.Regexp
+	original string
+}
+
+func newRegexp(s string) (Regexp, error) {
+	re, err := regexp.Compile("^(?:" + s + ")$")
+	return Regexp{Regexp: re, original: s}, err
+}
+
+// UnmarshalYAML implements yaml.Unmarshaler
+func (re *Regexp) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	var s string
+	if err := unmarshal(&s); err != nil {
+		return err
+	}
+	r, err := newRegexp(s)
+	if err != nil {
+		return err
+	}
+	*re = r
+	return nil
+}
+
+// MarshalYAML implements yaml.Marshaler
+func (re Regexp) MarshalYAML() (interface{}, error) {
+	return re.original, nil
+}
+
+// relabelConfig uses vocabulary and defaults of Prometheus relabel_config
+type relabelConfig struct {
+	SourceLabels []string `yaml:"source_labels"`
+	Separator    string   `yaml:"separator"`
+	Regex        Regexp   `yaml:"regex"`
+	Modulus      uint64   `yaml:"modulus"`
+	TargetLabel  string   `yaml:"target_label"`
+	Replacement  string   `yaml:"replacement"`
+	Action       string   `yaml:"action" validate:"oneof=replace keep drop labeldrop labelkeep labelmap hashmod"`
+}
+
+// UnmarshalYAML sets defaults of a relabel config
+func (rc *relabelConfig) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	type plain relabelConfig
+	re, _ := newRegexp("(.*)")
+	*rc = relabelConfig{
+		Separator:   ";",
+		Regex:       re,
+		Replacement: "$1",
+		Action:      actionReplace,
+	}
+	return unmarshal((*plain)(rc))
+}
+
+func (rc *relabelConfig) check() error {
+	switch rc.Action {
+	case actionReplace, actionHashMod:
+		if rc.TargetLabel == "" {
+			return fmt.Errorf("target_label is required for %s action", rc.Action)
+		}
+		if rc.Action == actionHashMod && rc.Modulus == 0 {
+			return fmt.Errorf("modulus is required for %s action", rc.Action)
+		}
+	case actionLabelDrop, actionLabelKeep:
+		if len(rc.SourceLabels) > 0 || rc.TargetLabel != "" {
+			return fmt.Errorf("source_labels and target_label are not allowed for %s action", rc.Action)
+		}
+	}
+	return nil
+}
+
+type configT struct {
+	RelabelConfigs []*relabelConfig `yaml:"relabel_configs" validate:"dive"`
+}
+
+// Relabel rewrites metric labels and names with Prometheus relabel_configs semantics
+type Relabel struct {
+	configuration configT
+}
+
+func init() {
+	registry.RegisterProcessor("relabel", New)
+}
+
+// New constructor
+func New(l *logging.Logger) processor.Processor {
+	return &Relabel{}
+}
+
+// Config implements processor.Processor
+func (r *Relabel) Config(c []byte) error {
+	err := config.ParseConfig(bytes.NewReader(c), &r.configuration)
+	if err != nil {
+		return err
+	}
+	for i, rc := range r.configuration.RelabelConfigs {
+		if err := rc.check(); err != nil {
+			return errors.Wrapf(err, "invalid relabel_configs[%d]", i)
+		}
+	}
+	return nil
+}
+
+// ProcessMetrics implements processor.MetricProcessor
+func (r *Relabel) ProcessMetrics(metrics []data.Metric) []data.Metric {
+	res := metrics[:0]
+	for _, m := range metrics {
+		if r.relabel(&m) {
+			res = append(res, m)
+		}
+	}
+	return res
+}
+
+// relabel applies relabel configs to metric. Returns false if metric should be dropped
+func (r *Relabel) relabel(m *data.Metric) bool {
+	if len(r.configuration.RelabelConfigs) == 0 {
+		return true
+	}
+	lbls := newLabels(m)
+	for _, rc := range r.configuration.RelabelConfigs {
+		if !apply(rc, lbls) {
+			return false
+		}
+	}
+	m.Name = lbls.get(nameLabel)
+	m.LabelKeys = m.LabelKeys[:0:0]
+	m.LabelVals = m.LabelVals[:0:0]
+	for i, key := range lbls.keys {
+		if key != nameLabel {
+			m.LabelKeys = append(m.LabelKeys, key)
+			m.LabelVals = append(m.LabelVals, lbls.vals[i])
+		}
+	}
+	return true
+}
+
+// apply applies single relabel config to labels. Returns false if metric should be dropped
+func apply(rc *relabelConfig, lbls *labels) bool {
+	vals := make([]string, len(rc.SourceLabels))
+	for i, name := range rc.SourceLabels {
+		vals[i] = lbls.get(name)
+	}
+	val := strings.Join(vals, rc.Separator)
+
+	switch rc.Action {
+	case actionKeep:
+		return rc.Regex.MatchString(val)
+	case actionDrop:
+		return !rc.Regex.MatchString(val)
+	case actionReplace:
+		indexes := rc.Regex.FindStringSubmatchIndex(val)
+		if indexes == nil {
+			break
+		}
+		target := string(rc.Regex.ExpandString([]byte{}, rc.TargetLabel, val, indexes))
+		if target == "" {
+			break
+		}
+		lbls.set(target, string(rc.Regex.ExpandString([]byte{}, rc.Replacement, val, indexes)))
+	case actionHashMod:
+		sum := md5.Sum([]byte(val)) //nolint:gosec
+		lbls.set(rc.TargetLabel, fmt.Sprintf("%d", binary.BigEndian.Uint64(sum[8:])%rc.Modulus))
+	case actionLabelMap:
+		// labels added by the mapping are not mapped again
+		n := len(lbls.keys)
+		for i := 0; i < n; i++ {
+			if lbls.keys[i] != nameLabel && rc.Regex.MatchString(lbls.keys[i]) {
+				lbls.set(rc.Regex.ReplaceAllString(lbls.keys[i], rc.Replacement), lbls.vals[i])
+			}
+		}
+	case actionLabelDrop, actionLabelKeep:
+		keep := rc.Action == actionLabelKeep
+		for i := 0; i < len(lbls.keys); i++ {
+			if lbls.keys[i] != nameLabel && rc.Regex.MatchString(lbls.keys[i]) != keep {
+				lbls.del(i)
+				i--
+			}
+		}
+	}
+	return true
+}
+
+// labels is a mutable copy of metric name and labels, LabelKeys and LabelVals can be shared between metrics
+type labels struct {
+	keys []string
+	vals []string
+}
+
+func newLabels(m *data.Metric) *labels {
+	l := &labels{
+		keys: make([]string, 0, len(m.LabelKeys)+2),
+		vals: make([]string, 0, len(m.LabelVals)+2),
+	}
+	l.keys = append(append(l.keys, nameLabel), m.LabelKeys...)
+	l.vals = append(append(l.vals, m.Name), m.LabelVals...)
+	return l
+}
+
+func (l *labels) index(name string) int {
+	for i, key := range l.keys {
+		if key == name {
+			return i
+		}
+	}
+	return -1
+}
+
+// get returns value of label, missing label has empty value
+func (l *labels) get(name string) string {
+	if i := l.index(name); i >= 0 && i < len(l.vals) {
+		return l.vals[i]
+	}
+	return ""
+}
+
+// set sets value of label, empty value removes the label
+func (l *labels) set(name string, value string) {
+	i := l.index(name)
+	switch {
+	case i < 0 && value != "":
+		l.keys = append(l.keys, name)
+		l.vals = append(l.vals, value)
+	case i >= 0 && (value != "" || name == nameLabel):
+		l.vals[i] = value
+	case i >= 0:
+		l.del(i)
+	}
+}
+
+func (l *labels) del(i int) {
+	l.keys = append(l.keys[:i], l.keys[i+1:]...)
+	l.vals = append(l.vals[:i], l.vals[i+1:]...)
+}
@@ -0,0 +1,203 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/infrawatch/sg-core/pkg/data"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func ceilometerMetric() data.Metric {
+	return data.Metric{
+		Name:      "ceilometer_cpu",
+		Type:      data.GAUGE,
+		Value:     42,
+		LabelKeys: []string{"publisher", "project", "user", "resource", "type", "counter", "unit"},
+		LabelVals: []string{"compute-0", "admin", "c6a2b1", "vm-1", "cpu", "cpu", "ns"},
+	}
+}
+
+func TestRelabel(t *testing.T) {
+	tests := []struct {
+		name     string
+		config   string
+		expected []data.Metric
+	}{
+		{
+			name:     "no relabel configs",
+			config:   `relabel_configs: []`,
+			expected: []data.Metric{ceilometerMetric()},
+		},
+		{
+			name: "replace with defaults",
+			config: `
+relabel_configs:
+  - source_labels: [publisher]
+    target_label: host
+`,
+			expected: []data.Metric{{
+				Name: "ceilometer_cpu", Type: data.GAUGE, Value: 42,
+				LabelKeys: []string{"publisher", "project", "user", "resource", "type", "counter", "unit", "host"},
+				LabelVals: []string{"compute-0", "admin", "c6a2b1", "vm-1", "cpu", "cpu", "ns", "compute-0"},
+			}},
+		},
+		{
+			name: "replace metric name from multiple labels",
+			config: `
+relabel_configs:
+  - source_labels: [__name__, unit]
+    separator: "_"
+    regex: "ceilometer_(.+)"
+    target_label: __name__
+    replacement: "openstack_${1}"
+`,
+			expected: []data.Metric{{
+				Name: "openstack_cpu_ns", Type: data.GAUGE, Value: 42,
+				LabelKeys: []string{"publisher", "project", "user", "resource", "type", "counter", "unit"},
+				LabelVals: []string{"compute-0", "admin", "c6a2b1", "vm-1", "cpu", "cpu", "ns"},
+			}},
+		},
+		{
+			name: "replace with empty value removes label",
+			config: `
+relabel_configs:
+  - target_label: user
+    replacement: ""
+`,
+			expected: []data.Metric{{
+				Name: "ceilometer_cpu", Type: data.GAUGE, Value: 42,
+				LabelKeys: []string{"publisher", "project", "resource", "type", "counter", "unit"},
+				LabelVals: []string{"compute-0", "admin", "vm-1", "cpu", "cpu", "ns"},
+			}},
+		},
+		{
+			name: "replace is skipped when regex does not match",
+			config: `
+relabel_configs:
+  - source_labels: [publisher]
+    regex: "controller-.*"
+    target_label: role
+    replacement: controller
+`,
+			expected: []data.Metric{ceilometerMetric()},
+		},
+		{
+			name: "keep",
+			config: `
+relabel_configs:
+  - source_labels: [type]
+    regex: "memory|disk"
+    action: keep
+`,
+			expected: []data.Metric{},
+		},
+		{
+			name: "drop",
+			config: `
+relabel_configs:
+  - source_labels: [__name__]
+    regex: "ceilometer_.*"
+    action: drop
+`,
+			expected: []data.Metric{},
+		},
+		{
+			name: "labeldrop",
+			config: `
+relabel_configs:
+  - regex: "user|project|resource"
+    action: labeldrop
+`,
+			expected: []data.Metric{{
+				Name: "ceilometer_cpu", Type: data.GAUGE, Value: 42,
+				LabelKeys: []string{"publisher", "type", "counter", "unit"},
+				LabelVals: []string{"compute-0", "cpu", "cpu", "ns"},
+			}},
+		},
+		{
+			name: "labelkeep",
+			config: `
+relabel_configs:
+  - regex: "publisher|type"
+    action: labelkeep
+`,
+			expected: []data.Metric{{
+				Name: "ceilometer_cpu", Type: data.GAUGE, Value: 42,
+				LabelKeys: []string{"publisher", "type"},
+				LabelVals: []string{"compute-0", "cpu"},
+			}},
+		},
+		{
+			name: "labelmap",
+			config: `
+relabel_configs:
+  - regex: "(project|user)"
+    replacement: "os_${1}"
+    action: labelmap
+  - regex: "project|user|resource|counter|unit"
+    action: labeldrop
+`,
+			expected: []data.Metric{{
+				Name: "ceilometer_cpu", Type: data.GAUGE, Value: 42,
+				LabelKeys: []string{"publisher", "type", "os_project", "os_user"},
+				LabelVals: []string{"compute-0", "cpu", "admin", "c6a2b1"},
+			}},
+		},
+		{
+			name: "hashmod",
+			config: `
+relabel_configs:
+  - source_labels: [resource]
+    modulus: 4
+    target_label: shard
+    action: hashmod
+  - source_labels: [shard]
+    regex: "1"
+    action: keep
+`,
+			expected: []data.Metric{},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			r := New(nil).(*Relabel)
+			require.NoError(t, r.Config([]byte(test.config)))
+			original := ceilometerMetric()
+			shared := original
+			res := r.ProcessMetrics([]data.Metric{original})
+			assert.Equal(t, test.expected, res)
+			assert.Equal(t, ceilometerMetric(), shared, "shared label slices must not be changed")
+		})
+	}
+}
+
+func TestHashMod(t *testing.T) {
+	// Prometheus uses lower 8 bytes of md5 sum of the source value
+	r := New(nil).(*Relabel)
+	require.NoError(t, r.Config([]byte(`
+relabel_configs:
+  - source_labels: [resource]
+    modulus: 8
+    target_label: shard
+    action: hashmod
+`)))
+	res := r.ProcessMetrics([]data.Metric{{Name: "m", LabelKeys: []string{"resource"}, LabelVals: []string{"vm-1"}}})
+	require.Len(t, res, 1)
+	assert.Equal(t, []string{"resource", "shard"}, res[0].LabelKeys)
+	assert.Equal(t, []string{"vm-1", "4"}, res[0].LabelVals)
+}
+
+func TestInvalidConfig(t *testing.T) {
+	for name, c := range map[string]string{
+		"unknown action":          "relabel_configs: [{action: rename}]",
+		"invalid regex":           "relabel_configs: [{regex: '(', target_label: x}]",
+		"replace without target":  "relabel_configs: [{source_labels: [a]}]",
+		"hashmod without modulus": "relabel_configs: [{source_labels: [a], target_label: b, action: hashmod}]",
+		"labeldrop with target":   "relabel_configs: [{regex: a, target_label: b, action: labeldrop}]",
+	} {
+		r := New(nil)
+		assert.Error(t, r.Config([]byte(c)), name)
+	}
+}
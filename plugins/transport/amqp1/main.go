@@ -1,11 +1,9 @@
 package main
 
 import (
-	"bufio"
 	"bytes"
 	"context"
 	"fmt"
-	"os"
 	"strings"
 	"time"
 
@@ -14,7 +12,13 @@ import (
 	"github.com/infrawatch/apputils/logging"
 	"github.com/infrawatch/sg-core/pkg/config"
 	"github.com/infrawatch/sg-core/pkg/data"
+	"github.com/infrawatch/sg-core/pkg/dump"
+	"github.com/infrawatch/sg-core/pkg/filter"
+	"github.com/infrawatch/sg-core/pkg/forward"
+	"github.com/infrawatch/sg-core/pkg/pluginlog"
+	"github.com/infrawatch/sg-core/pkg/registry"
 	"github.com/infrawatch/sg-core/pkg/transport"
+	"github.com/pkg/errors"
 )
 
 var (
@@ -29,14 +33,35 @@ func rate() int64 {
 	return rate
 }
 
+var (
+	durabilities = map[string]amqp.Durability{
+		"none":           amqp.DurabilityNone,
+		"configuration":  amqp.DurabilityConfiguration,
+		"unsettledState": amqp.DurabilityUnsettledState,
+	}
+	settleModes = map[string]amqp.SenderSettleMode{
+		"unsettled": amqp.ModeUnsettled,
+		"settled":   amqp.ModeSettled,
+		"mixed":     amqp.ModeMixed,
+	}
+)
+
+type senderConfigT struct {
+	Durability  string        `yaml:"durability" validate:"oneof=none configuration unsettledState"`
+	Settlement  string        `yaml:"settlement" validate:"oneof=unsettled settled mixed"`
+	BatchSize   int           `yaml:"batchSize" validate:"min=1"` // messages sent in one AMQP message as a list
+	BatchMaxAge time.Duration `yaml:"batchMaxAge"`                // incomplete batch is sent when its first message is this old
+	Timeout     time.Duration `yaml:"timeout" validate:"gt=0"`    // how long a task waits for the sender link
+}
+
 type configT struct {
-	URI          string `validate:"required"`
-	Channel      string `validate:"required"`
-	LinkCredit   uint32 `yaml:"linkCredit"`
-	DumpMessages struct {
-		Enabled bool
-		Path    string
-	} `yaml:"dumpMessages"` // only use for debug as this is very slow
+	URI          string                  `validate:"required"`
+	Channel      string                  `validate:"required"` // source address in read mode, target address in write mode
+	Mode         string                  `yaml:"mode" validate:"oneof=read write"`
+	LinkCredit   uint32                  `yaml:"linkCredit"`
+	Sender       senderConfigT           `yaml:"sender"`
+	Forward      transport.ForwardConfig `yaml:"forward"`      // data of internal buses sent in write mode
+	DumpMessages dump.Config             `yaml:"dumpMessages"` // only use for debug as this is very slow
 }
 
 // AMQP1 basic struct
@@ -45,29 +70,39 @@ type AMQP1 struct {
 	sess     *amqp.Session
 	receiver *amqp.Receiver
 	conf     configT
-	logger   *logging.Logger
-	dumpBuf  *bufio.Writer
-	dumpFile *os.File
+	mode     transport.Mode
+	outgoing chan string
+	logger   *pluginlog.Logger
+	dump     *dump.Writer
 }
 
-func sendMessage(msg interface{}, w transport.WriteFn, logger *logging.Logger) {
+func sendMessage(msg interface{}, w transport.WriteFn, logger *pluginlog.Logger) {
 	if tmsg, ok := msg.(string); ok {
 		w([]byte(tmsg))
 		msgCount++
 	} else {
-		logger.Metadata(logging.Metadata{"plugin": appname, "type": fmt.Sprintf("%T", msg)})
-		logger.Error("unknown type of received message")
+		logger.Error("unknown type of received message", logging.Metadata{"type": fmt.Sprintf("%T", msg)})
 	}
 }
 
 // Run implements type Transport
 func (at *AMQP1) Run(ctx context.Context, w transport.WriteFn, done chan bool) {
 	var err error
+	if at.conf.DumpMessages.Enabled {
+		if err = at.openDump(); err != nil {
+			at.logger.Error("failed to open dump file", logging.Metadata{"error": err})
+			done <- true
+			return
+		}
+		defer at.closeDump()
+		w = at.dumping(w)
+	}
+
 	// connect
 	at.conn, err = amqp.Dial(at.conf.URI)
 	if err != nil {
-		at.logger.Metadata(logging.Metadata{"plugin": appname, "error": err})
-		at.logger.Error("failed to connect")
+		at.logger.Error("failed to connect", logging.Metadata{"error": err})
+		done <- true
 		return
 	}
 	defer at.conn.Close()
@@ -75,19 +110,30 @@ func (at *AMQP1) Run(ctx context.Context, w transport.WriteFn, done chan bool) {
 	// open session
 	at.sess, err = at.conn.NewSession()
 	if err != nil {
-		at.logger.Metadata(logging.Metadata{"plugin": appname, "error": err})
-		at.logger.Error("failed to create session")
+		at.logger.Error("failed to create session", logging.Metadata{"error": err})
+		done <- true
 		return
 	}
 
-	// create receiver
+	if at.mode == transport.WRITE {
+		at.runSender(ctx, done)
+	} else {
+		at.runReceiver(ctx, w, done)
+	}
+
+	at.logger.Info("exited")
+}
+
+// runReceiver passes messages received on the channel to handlers until context is done
+func (at *AMQP1) runReceiver(ctx context.Context, w transport.WriteFn, done chan bool) {
+	var err error
 	at.receiver, err = at.sess.NewReceiver(
 		amqp.LinkSourceAddress(at.conf.Channel),
 		amqp.LinkCredit(at.conf.LinkCredit),
 	)
 	if err != nil {
-		at.logger.Metadata(logging.Metadata{"plugin": appname, "error": err})
-		at.logger.Error("failed to create receiver")
+		at.logger.Error("failed to create receiver", logging.Metadata{"error": err})
+		done <- true
 		return
 	}
 	defer func(rcv *amqp.Receiver) {
@@ -96,30 +142,16 @@ func (at *AMQP1) Run(ctx context.Context, w transport.WriteFn, done chan bool) {
 		cancel()
 	}(at.receiver)
 
-	at.logger.Metadata(logging.Metadata{
-		"plugin":     appname,
+	at.logger.Info("listening", logging.Metadata{
 		"connection": fmt.Sprintf("%s/%s", at.conf.URI, at.receiver.Address()),
 	})
-	at.logger.Info("listening")
 
 	for {
 		at.logger.Debug(fmt.Sprintf("receiving %d msg/s", rate()))
 		err := at.receiver.HandleMessage(ctx, func(msg *amqp.Message) error {
 			// accept message
-			if errr := msg.Accept(context.Background()); err != nil {
-				return errr
-			}
-			// dump message
-			if at.conf.DumpMessages.Enabled {
-				_, errr := at.dumpBuf.Write(msg.GetData())
-				if errr != nil {
-					return errr
-				}
-				_, errr = at.dumpBuf.WriteString("\n")
-				if errr != nil {
-					return errr
-				}
-				at.dumpBuf.Flush()
+			if err := msg.Accept(context.Background()); err != nil {
+				return err
 			}
 			// send message
 			switch val := msg.Value.(type) {
@@ -130,65 +162,213 @@ func (at *AMQP1) Run(ctx context.Context, w transport.WriteFn, done chan bool) {
 			case interface{}:
 				sendMessage(val, w, at.logger)
 			default:
-				at.logger.Metadata(logging.Metadata{"plugin": appname, "type": val})
-				at.logger.Warn("unknown message format - skipping")
+				at.logger.Warn("unknown message format - skipping", logging.Metadata{"type": val})
 			}
 			return nil
 		})
 
+		if ctx.Err() != nil {
+			break
+		}
 		if err != nil && !strings.Contains(err.Error(), "context canceled") {
-			at.logger.Metadata(logging.Metadata{"plugin": appname, "error": err})
-			at.logger.Error("failed to handle message")
+			at.logger.Error("failed to handle message", logging.Metadata{"error": err})
+			done <- true
 			break
 		}
 	}
+}
 
-	at.dumpFile.Close()
-	at.logger.Metadata(logging.Metadata{"plugin": appname})
-	at.logger.Info("exited")
+// runSender sends messages of tasks passed to Send to the channel until context is done. Batched
+// messages are sent as a list in one AMQP message, which is split again by transport in read mode
+func (at *AMQP1) runSender(ctx context.Context, done chan bool) {
+	sender, err := at.sess.NewSender(
+		amqp.LinkTargetAddress(at.conf.Channel),
+		amqp.LinkTargetDurability(durabilities[at.conf.Sender.Durability]),
+		amqp.LinkSenderSettle(settleModes[at.conf.Sender.Settlement]),
+	)
+	if err != nil {
+		at.logger.Error("failed to create sender", logging.Metadata{"error": err})
+		done <- true
+		return
+	}
+	defer func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 1*time.Second)
+		sender.Close(ctx)
+		cancel()
+	}()
+
+	at.logger.Info("sending", logging.Metadata{
+		"connection": fmt.Sprintf("%s/%s", at.conf.URI, sender.Address()),
+	})
+
+	batch := make([]interface{}, 0, at.conf.Sender.BatchSize)
+	send := func(ctx context.Context) bool {
+		msg := &amqp.Message{Value: batch}
+		if at.conf.Sender.BatchSize == 1 {
+			msg.Value = batch[0]
+		}
+		err := sender.Send(ctx, msg)
+		if err != nil {
+			at.logger.Error("failed to send messages", logging.Metadata{"error": err, "messages": len(batch)})
+		}
+		batch = batch[:0]
+		return err == nil
+	}
+
+	var batchAge <-chan time.Time
+	for {
+		select {
+		case <-ctx.Done():
+			// remaining messages are sent on shutdown
+			if len(batch) > 0 {
+				ctx, cancel := context.WithTimeout(context.Background(), at.conf.Sender.Timeout)
+				send(ctx)
+				cancel()
+			}
+			return
+		case msg := <-at.outgoing:
+			batch = append(batch, msg)
+			if len(batch) == 1 {
+				batchAge = time.After(at.conf.Sender.BatchMaxAge)
+			}
+			if len(batch) < at.conf.Sender.BatchSize {
+				continue
+			}
+		case <-batchAge:
+		}
+		batchAge = nil
+		if len(batch) > 0 && !send(ctx) {
+			done <- true
+			return
+		}
+	}
+}
+
+// openDump opens dump file unless it is already open, the file is closed when Run exits
+func (at *AMQP1) openDump() error {
+	if at.dump != nil {
+		return nil
+	}
+	var err error
+	at.dump, err = dump.NewWriter(at.conf.DumpMessages)
+	return err
+}
+
+func (at *AMQP1) closeDump() {
+	at.dump.Close()
+	at.dump = nil
+}
+
+// dumping wraps write function, so that messages are dumped as they are passed to handlers. Dump writer
+// open when Run starts is used, the field is cleared when Run exits
+func (at *AMQP1) dumping(w transport.WriteFn) transport.WriteFn {
+	d := at.dump
+	return func(msg []byte) {
+		if err := d.Write(msg); err != nil {
+			at.logger.Error("failed to dump message", logging.Metadata{"error": err})
+		}
+		w(msg)
+	}
+}
+
+// Send implements transport.Sender, message of the task is passed to the sender link of running transport
+func (at *AMQP1) Send(e data.Event) error {
+	return at.enqueue(e.Message)
+}
+
+// Forward implements transport.Forwarder
+func (at *AMQP1) Forward() transport.ForwardConfig {
+	if at.mode != transport.WRITE {
+		return transport.ForwardConfig{}
+	}
+	return at.conf.Forward
+}
+
+// ForwardEvent implements transport.Forwarder, event is sent encoded as JSON object
+func (at *AMQP1) ForwardEvent(e data.Event) error {
+	msg, err := forward.EncodeEvent(e)
+	if err != nil {
+		return err
+	}
+	return at.enqueue(string(msg))
+}
+
+// ForwardMetrics implements transport.Forwarder, each metric is sent encoded as JSON object
+func (at *AMQP1) ForwardMetrics(metrics []data.Metric) error {
+	for _, m := range metrics {
+		msg, err := forward.EncodeMetric(m)
+		if err != nil {
+			return err
+		}
+		if err = at.enqueue(string(msg)); err != nil {
+			return err
+		}
+	}
+	return nil
 }
 
-// Listen ...
-func (at *AMQP1) Listen(e data.Event) {
-	at.logger.Metadata(logging.Metadata{"plugin": appname, "event": e})
-	at.logger.Debug("received event")
+// enqueue passes message to the sender link of running transport
+func (at *AMQP1) enqueue(msg string) error {
+	if at.mode != transport.WRITE {
+		return errors.New("transport is not in write mode")
+	}
+	timer := time.NewTimer(at.conf.Sender.Timeout)
+	defer timer.Stop()
+	select {
+	case at.outgoing <- msg:
+		return nil
+	case <-timer.C:
+		return errors.New("timed out waiting for sender link")
+	}
 }
 
 // Config load configurations
 func (at *AMQP1) Config(c []byte) error {
 	at.conf = configT{
-		DumpMessages: struct {
-			Enabled bool
-			Path    string
-		}{
-			false,
-			"",
+		DumpMessages: dump.Config{
+			Enabled: false,
+			Path:    "",
 		},
 		URI:        "amqp://127.0.0.1:5672",
 		Channel:    "rsyslog/logs",
+		Mode:       "read",
 		LinkCredit: 1024,
+		Sender: senderConfigT{
+			Durability:  "none",
+			Settlement:  "unsettled",
+			BatchSize:   1,
+			BatchMaxAge: time.Second,
+			Timeout:     5 * time.Second,
+		},
 	}
 
 	err := config.ParseConfig(bytes.NewReader(c), &at.conf)
 	if err != nil {
 		return err
 	}
+	at.mode.FromString(at.conf.Mode)
+	if _, err = filter.New(at.conf.Forward.Filter); err != nil {
+		return errors.Wrap(err, "failed parsing forward filter")
+	}
 
 	if at.conf.DumpMessages.Enabled {
-		at.dumpFile, err = os.OpenFile(at.conf.DumpMessages.Path, os.O_RDWR|os.O_CREATE|os.O_APPEND, 0666)
+		err = at.openDump()
 		if err != nil {
 			return err
 		}
-
-		at.dumpBuf = bufio.NewWriter(at.dumpFile)
 	}
 
 	return nil
 }
 
+func init() {
+	registry.RegisterTransport("amqp1", New)
+}
+
 // New create new amqp1 transport
 func New(l *logging.Logger) transport.Transport {
 	return &AMQP1{
-		logger: l,
+		logger:   pluginlog.New(l, "transport", appname),
+		outgoing: make(chan string),
 	}
 }
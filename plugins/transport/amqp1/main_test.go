@@ -0,0 +1,174 @@
+package main
+
+import (
+	"context"
+	"net"
+	"os"
+	"path"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/infrawatch/apputils/logging"
+	"github.com/infrawatch/sg-core/pkg/data"
+	"github.com/infrawatch/sg-core/pkg/forward"
+	"github.com/infrawatch/sg-core/pkg/transport"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// qdrouterd from ci/service_configs/qdr
+const (
+	qdrAddress = "127.0.0.1:5666"
+	qdrURI     = "amqp://" + qdrAddress
+)
+
+func newTestTransport(t *testing.T, conf string) *AMQP1 {
+	tmpdir, err := os.MkdirTemp(".", "amqp1_test_tmp")
+	require.NoError(t, err)
+	t.Cleanup(func() { os.RemoveAll(tmpdir) })
+	logger, err := logging.NewLogger(logging.DEBUG, path.Join(tmpdir, "test.log"))
+	require.NoError(t, err)
+
+	at := New(logger).(*AMQP1)
+	require.NoError(t, at.Config([]byte(conf)))
+	return at
+}
+
+func TestConfig(t *testing.T) {
+	t.Run("read mode by default", func(t *testing.T) {
+		at := newTestTransport(t, "")
+		assert.Equal(t, transport.Mode(transport.READ), at.mode)
+		assert.Equal(t, 1, at.conf.Sender.BatchSize)
+	})
+
+	t.Run("write mode", func(t *testing.T) {
+		at := newTestTransport(t, "mode: write\nsender:\n  settlement: settled\n  batchSize: 10\n")
+		assert.Equal(t, transport.Mode(transport.WRITE), at.mode)
+		assert.Equal(t, "settled", at.conf.Sender.Settlement)
+		assert.Equal(t, "none", at.conf.Sender.Durability)
+		assert.Equal(t, 10, at.conf.Sender.BatchSize)
+	})
+
+	for _, conf := range []string{
+		"mode: duplex",
+		"sender:\n  durability: forever",
+		"sender:\n  settlement: first",
+		"sender:\n  batchSize: 0",
+		"sender:\n  timeout: 0s",
+		"sender:\n  timeout: -1s",
+		"forward:\n  filter:\n    metrics:\n      name:\n        allow: [\"(\"]",
+	} {
+		at := New(nil)
+		assert.Error(t, at.Config([]byte(conf)), conf)
+	}
+}
+
+func TestSendWithoutSender(t *testing.T) {
+	at := newTestTransport(t, "")
+	assert.EqualError(t, at.Send(data.Event{Type: data.TASK, Message: "test"}), "transport is not in write mode")
+
+	at = newTestTransport(t, "mode: write\nsender:\n  timeout: 10ms\n")
+	assert.EqualError(t, at.Send(data.Event{Type: data.TASK, Message: "test"}), "timed out waiting for sender link")
+}
+
+func TestForward(t *testing.T) {
+	at := newTestTransport(t, "forward:\n  events: true\n")
+	assert.Equal(t, transport.ForwardConfig{}, at.Forward(), "nothing is forwarded in read mode")
+
+	at = newTestTransport(t, "mode: write\nforward:\n  events: true\n  metrics: true\n")
+	assert.True(t, at.Forward().Events)
+	assert.True(t, at.Forward().Metrics)
+
+	received := make(chan string, 3)
+	go func() {
+		for i := 0; i < 3; i++ {
+			received <- <-at.outgoing
+		}
+	}()
+	require.NoError(t, at.ForwardEvent(data.Event{
+		Index:    "collectd",
+		Type:     data.EVENT,
+		Severity: data.WARNING,
+		Labels:   map[string]interface{}{"host": "edge"},
+		Message:  "line\nbreak",
+	}))
+	assert.JSONEq(t, `{"index":"collectd","time":0,"type":"event","publisher":"","severity":"warning",
+		"labels":{"host":"edge"},"annotations":null,"message":"line\nbreak"}`, <-received)
+
+	require.NoError(t, at.ForwardMetrics([]data.Metric{
+		{Name: "first", Type: data.GAUGE, Interval: 10 * time.Second, Value: 1, LabelKeys: []string{"host"}, LabelVals: []string{"edge"}},
+		{Name: "second", Type: data.COUNTER, Value: 2},
+	}))
+	assert.JSONEq(t, `{"name":"first","time":0,"type":"gauge","interval":10,"value":1,"labels":{"host":"edge"}}`, <-received)
+	assert.JSONEq(t, `{"name":"second","time":0,"type":"counter","interval":0,"value":2,"labels":{}}`, <-received)
+
+	t.Run("forwarded data are decoded on receiving side", func(t *testing.T) {
+		received := make(chan string, 2)
+		go func() {
+			for i := 0; i < 2; i++ {
+				received <- <-at.outgoing
+			}
+		}()
+		event := data.Event{Index: "collectd", Type: data.LOG, Severity: data.CRITICAL, Labels: map[string]interface{}{"host": "edge"}, Message: "line\nbreak"}
+		require.NoError(t, at.ForwardEvent(event))
+		e, m, err := forward.Decode([]byte(<-received))
+		require.NoError(t, err)
+		assert.Nil(t, m)
+		assert.Equal(t, &event, e)
+
+		metric := data.Metric{Name: "first", Type: data.GAUGE, Interval: 10 * time.Second, Value: 1, LabelKeys: []string{"host"}, LabelVals: []string{"edge"}}
+		require.NoError(t, at.ForwardMetrics([]data.Metric{metric}))
+		e, m, err = forward.Decode([]byte(<-received))
+		require.NoError(t, err)
+		assert.Nil(t, e)
+		assert.Equal(t, &metric, m)
+	})
+}
+
+func TestSendReceive(t *testing.T) {
+	conn, err := net.DialTimeout("tcp", qdrAddress, time.Second)
+	if err != nil {
+		t.Skipf("qdrouterd is not reachable: %s", err)
+	}
+	conn.Close()
+
+	channel := "sg-core/amqp1-test"
+	receiver := newTestTransport(t, "uri: "+qdrURI+"\nchannel: "+channel+"\n")
+	sender := newTestTransport(t, "uri: "+qdrURI+"\nchannel: "+channel+
+		"\nmode: write\nsender:\n  batchSize: 2\n  batchMaxAge: 100ms\n")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	wg := &sync.WaitGroup{}
+	defer func() {
+		cancel()
+		wg.Wait()
+	}()
+
+	received := make(chan string, 3)
+	done := make(chan bool, 2)
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		receiver.Run(ctx, func(msg []byte) { received <- string(msg) }, done)
+	}()
+	go func() {
+		defer wg.Done()
+		sender.Run(ctx, nil, done)
+	}()
+
+	// the third message is sent when the incomplete batch gets old enough
+	for _, msg := range []string{"first", "second", "third"} {
+		require.NoError(t, sender.Send(data.Event{Type: data.TASK, Message: msg}))
+	}
+	for _, expected := range []string{"first", "second", "third"} {
+		select {
+		case msg := <-received:
+			assert.Equal(t, expected, msg)
+		case <-done:
+			t.Fatal("transport failed")
+		case <-time.After(5 * time.Second):
+			t.Fatalf("message %q was not received", expected)
+		}
+	}
+}
@@ -12,6 +12,8 @@ import (
 	"github.com/infrawatch/apputils/logging"
 	"github.com/infrawatch/sg-core/pkg/config"
 	"github.com/infrawatch/sg-core/pkg/data"
+	"github.com/infrawatch/sg-core/pkg/pluginlog"
+	"github.com/infrawatch/sg-core/pkg/registry"
 	"github.com/infrawatch/sg-core/pkg/transport"
 )
 
@@ -27,7 +29,7 @@ type configT struct {
 // DummyAM listens on given port and prints all HTTP requests
 type DummyAM struct {
 	conf   configT
-	logger *logging.Logger
+	logger *pluginlog.Logger
 }
 
 // Run implements type Transport
@@ -38,15 +40,13 @@ func (dam *DummyAM) Run(ctx context.Context, w transport.WriteFn, done chan bool
 		dam.logger.Debug("received HTTP request")
 		out, err := os.OpenFile(dam.conf.Output, os.O_RDWR|os.O_CREATE|os.O_APPEND, 0666)
 		if err != nil {
-			dam.logger.Metadata(logging.Metadata{"plugin": "dummy-alertmanager", "error": err})
-			dam.logger.Error("failed to open output file")
+			dam.logger.Error("failed to open output file", logging.Metadata{"error": err})
 		} else {
 			defer out.Close()
 		}
 		msg, err := io.ReadAll(req.Body)
 		if err != nil {
-			dam.logger.Metadata(logging.Metadata{"plugin": "dummy-alertmanager", "error": err})
-			dam.logger.Error("failed to read request")
+			dam.logger.Error("failed to read request", logging.Metadata{"error": err})
 		} else {
 			out.WriteString(fmt.Sprintf("%s\n", msg))
 		}
@@ -57,16 +57,14 @@ func (dam *DummyAM) Run(ctx context.Context, w transport.WriteFn, done chan bool
 	go func(server *http.Server, ctx context.Context) {
 		<-ctx.Done()
 		if err := srv.Shutdown(ctx); err != nil {
-			dam.logger.Metadata(logging.Metadata{"plugin": appname, "error": err})
-			dam.logger.Error("failed to shut down HTTP server")
+			dam.logger.Error("failed to shut down HTTP server", logging.Metadata{"error": err})
 		} else {
 			dam.logger.Info("shutting down HTTP server")
 		}
 	}(srv, ctx)
 
 	err := srv.ListenAndServe()
-	dam.logger.Metadata(logging.Metadata{"plugin": appname, "error": err})
-	dam.logger.Info("exited")
+	dam.logger.Info("exited", logging.Metadata{"error": err})
 }
 
 // Listen ...
@@ -82,6 +80,10 @@ func (dam *DummyAM) Config(c []byte) error {
 	return nil
 }
 
+func init() {
+	registry.RegisterTransport("dummy-alertmanager", New)
+}
+
 // New create new socket transport
 func New(l *logging.Logger) transport.Transport {
 	return &DummyAM{
@@ -89,6 +91,6 @@ func New(l *logging.Logger) transport.Transport {
 			Port:   16661,
 			Output: "/dev/stdout",
 		},
-		logger: l,
+		logger: pluginlog.New(l, "transport", appname),
 	}
 }
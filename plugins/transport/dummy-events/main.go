@@ -8,6 +8,7 @@ import (
 	"github.com/infrawatch/apputils/logging"
 	"github.com/infrawatch/sg-core/pkg/config"
 	"github.com/infrawatch/sg-core/pkg/data"
+	"github.com/infrawatch/sg-core/pkg/registry"
 	"github.com/infrawatch/sg-core/pkg/transport"
 )
 
@@ -114,6 +115,10 @@ func (de *DummyEvents) Config(c []byte) error {
 	return nil
 }
 
+func init() {
+	registry.RegisterTransport("dummy-events", New)
+}
+
 // New create new socket transport
 func New(l *logging.Logger) transport.Transport {
 	return &DummyEvents{}
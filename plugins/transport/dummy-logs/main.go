@@ -6,6 +6,8 @@ import (
 
 	"github.com/infrawatch/apputils/logging"
 	"github.com/infrawatch/sg-core/pkg/data"
+	"github.com/infrawatch/sg-core/pkg/pluginlog"
+	"github.com/infrawatch/sg-core/pkg/registry"
 	"github.com/infrawatch/sg-core/pkg/transport"
 )
 
@@ -19,7 +21,7 @@ var logMessages = []string{
 
 // DummyLogs plugin struct
 type DummyLogs struct {
-	logger *logging.Logger
+	logger *pluginlog.Logger
 }
 
 // Run implements type Transport
@@ -30,7 +32,6 @@ func (dl *DummyLogs) Run(ctx context.Context, wrFn transport.WriteFn, done chan
 			t := time.Now()
 			timestamp, err := t.MarshalText()
 			if err != nil {
-				dl.logger.Metadata(logging.Metadata{"plugin": "dummy-logs"})
 				dl.logger.Warn("Failed to get current timestamp")
 				continue
 			}
@@ -59,9 +60,13 @@ func (dl *DummyLogs) Config(c []byte) error {
 	return nil
 }
 
+func init() {
+	registry.RegisterTransport("dummy-logs", New)
+}
+
 // New create new socket transport
 func New(l *logging.Logger) transport.Transport {
 	return &DummyLogs{
-		logger: l,
+		logger: pluginlog.New(l, "transport", "dummy-logs"),
 	}
 }
@@ -10,6 +10,7 @@ import (
 	"github.com/infrawatch/apputils/logging"
 	"github.com/infrawatch/sg-core/pkg/config"
 	"github.com/infrawatch/sg-core/pkg/data"
+	"github.com/infrawatch/sg-core/pkg/registry"
 	"github.com/infrawatch/sg-core/pkg/transport"
 )
 
@@ -128,6 +129,10 @@ func (dm *DummyMetrics) Config(c []byte) error {
 	return nil
 }
 
+func init() {
+	registry.RegisterTransport("dummy-metrics", New)
+}
+
 // New create new socket transport
 func New(l *logging.Logger) transport.Transport {
 	return &DummyMetrics{}
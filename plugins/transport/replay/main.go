@@ -0,0 +1,162 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/infrawatch/apputils/logging"
+	"github.com/infrawatch/sg-core/pkg/config"
+	"github.com/infrawatch/sg-core/pkg/dump"
+	"github.com/infrawatch/sg-core/pkg/pluginlog"
+	"github.com/infrawatch/sg-core/pkg/registry"
+	"github.com/infrawatch/sg-core/pkg/transport"
+)
+
+const (
+	pacingFast     = "fast"
+	pacingOriginal = "original"
+)
+
+type configT struct {
+	Files  []string `yaml:"files" validate:"required,min=1"`       // dump files or glob patterns, replayed in given order
+	Pacing string   `yaml:"pacing" validate:"oneof=fast original"` // original pacing needs dumps written with timestamps
+	Speed  float64  `yaml:"speed" validate:"gt=0"`                 // speed-up factor of original pacing
+}
+
+// Replay feeds messages dumped by other transports to handlers
+type Replay struct {
+	conf   configT
+	files  []string
+	logger *pluginlog.Logger
+}
+
+func init() {
+	registry.RegisterTransport("replay", New)
+}
+
+// New constructor
+func New(l *logging.Logger) transport.Transport {
+	return &Replay{
+		logger: pluginlog.New(l, "transport", "replay"),
+	}
+}
+
+// Config implements transport.Transport
+func (r *Replay) Config(c []byte) error {
+	r.conf = configT{
+		Pacing: pacingFast,
+		Speed:  1,
+	}
+	err := config.ParseConfig(bytes.NewReader(c), &r.conf)
+	if err != nil {
+		return err
+	}
+
+	r.files = []string{}
+	for _, pattern := range r.conf.Files {
+		matches, err := filepath.Glob(pattern)
+		if err != nil {
+			return fmt.Errorf("invalid file pattern '%s': %w", pattern, err)
+		}
+		if len(matches) == 0 {
+			return fmt.Errorf("no dump file matches '%s'", pattern)
+		}
+		r.files = append(r.files, matches...)
+	}
+	return nil
+}
+
+// Run implements transport.Transport. All files are replayed once, then the transport idles until it is stopped
+func (r *Replay) Run(ctx context.Context, w transport.WriteFn, done chan bool) {
+	start := time.Now()
+	p := &pacer{original: r.conf.Pacing == pacingOriginal, speed: r.conf.Speed}
+	count := 0
+	for _, file := range r.files {
+		n, err := r.replayFile(ctx, file, p, w)
+		count += n
+		if ctx.Err() != nil {
+			return
+		}
+		if err != nil {
+			r.logger.Error("failed replaying dump file", logging.Metadata{"file": file, "error": err})
+		}
+	}
+	if p.untimed > 0 && p.original {
+		r.logger.Warn("messages without timestamp were replayed without delay", logging.Metadata{"count": p.untimed})
+	}
+
+	elapsed := time.Since(start)
+	r.logger.Info("replay finished", logging.Metadata{
+		"messages": count,
+		"duration": elapsed.String(),
+		"rate":     fmt.Sprintf("%.0f msg/s", float64(count)/elapsed.Seconds()),
+	})
+	<-ctx.Done()
+}
+
+func (r *Replay) replayFile(ctx context.Context, file string, p *pacer, w transport.WriteFn) (int, error) {
+	f, err := os.Open(file)
+	if err != nil {
+		return 0, err
+	}
+	defer f.Close()
+	r.logger.Debug("replaying dump file", logging.Metadata{"file": file})
+
+	reader := dump.NewReader(f)
+	count := 0
+	for {
+		rec, err := reader.Next()
+		if err == io.EOF {
+			return count, nil
+		}
+		if err != nil {
+			return count, err
+		}
+		if !p.wait(ctx, rec.Time) {
+			return count, nil
+		}
+		w(rec.Message)
+		count++
+	}
+}
+
+// pacer delays messages so that they are replayed with the same gaps as they were received
+type pacer struct {
+	original bool
+	speed    float64
+	first    time.Time // reception time of the first timestamped message
+	base     time.Time // wall-clock time when the first timestamped message was replayed
+	untimed  int
+}
+
+// wait blocks until message received at given time should be replayed, returns false when context is done
+func (p *pacer) wait(ctx context.Context, received time.Time) bool {
+	if !p.original {
+		return ctx.Err() == nil
+	}
+	if received.IsZero() {
+		p.untimed++
+		return ctx.Err() == nil
+	}
+	if p.first.IsZero() {
+		p.first = received
+		p.base = time.Now()
+	}
+	delay := time.Until(p.base.Add(time.Duration(float64(received.Sub(p.first)) / p.speed)))
+	if delay <= 0 {
+		return ctx.Err() == nil
+	}
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return false
+	case <-timer.C:
+		return true
+	}
+}
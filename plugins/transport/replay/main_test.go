@@ -0,0 +1,110 @@
+package main
+
+import (
+	"context"
+	"os"
+	"path"
+	"testing"
+	"time"
+
+	"github.com/infrawatch/apputils/logging"
+	"github.com/infrawatch/sg-core/pkg/dump"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type replayed struct {
+	msg string
+	at  time.Time
+}
+
+func runReplay(t *testing.T, config string, count int) []replayed {
+	logger, err := logging.NewLogger(logging.DEBUG, path.Join(t.TempDir(), "test.log"))
+	require.NoError(t, err)
+	r := New(logger)
+	require.NoError(t, r.Config([]byte(config)))
+
+	msgs := make(chan replayed, count+1)
+	ctx, cancel := context.WithCancel(context.Background())
+	finished := make(chan struct{})
+	go func() {
+		r.Run(ctx, func(blob []byte) { msgs <- replayed{string(blob), time.Now()} }, make(chan bool))
+		close(finished)
+	}()
+	defer func() {
+		cancel()
+		<-finished
+	}()
+
+	res := []replayed{}
+	for i := 0; i < count; i++ {
+		select {
+		case m := <-msgs:
+			res = append(res, m)
+		case <-time.After(5 * time.Second):
+			t.Fatal("messages were not replayed")
+		}
+	}
+	select {
+	case m := <-msgs:
+		t.Fatalf("unexpected message %s", m.msg)
+	case <-time.After(50 * time.Millisecond):
+	}
+	return res
+}
+
+func messages(rs []replayed) []string {
+	res := []string{}
+	for _, r := range rs {
+		res = append(res, r.msg)
+	}
+	return res
+}
+
+func TestReplay(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(path.Join(dir, "a.dump"), []byte(
+		"2026-10-17T10:00:00Z\tfirst\n"+
+			"2026-10-17T10:00:00.2Z\tsecond\n"+
+			"2026-10-17T10:00:00.4Z\tthird\n"), 0600))
+	require.NoError(t, os.WriteFile(path.Join(dir, "b.dump"), []byte("untimed\n"), 0600))
+
+	t.Run("fast", func(t *testing.T) {
+		start := time.Now()
+		res := runReplay(t, "files: ["+path.Join(dir, "*.dump")+"]", 4)
+		assert.Equal(t, []string{"first", "second", "third", "untimed"}, messages(res))
+		assert.Less(t, int64(res[3].at.Sub(start)), int64(200*time.Millisecond))
+	})
+
+	t.Run("original pacing", func(t *testing.T) {
+		res := runReplay(t, "files: ["+path.Join(dir, "a.dump")+"]\npacing: original\nspeed: 2", 3)
+		assert.Equal(t, []string{"first", "second", "third"}, messages(res))
+		assert.InDelta(t, 200*time.Millisecond, res[2].at.Sub(res[0].at), float64(80*time.Millisecond))
+	})
+}
+
+func TestReplayMultiLineMessage(t *testing.T) {
+	file := path.Join(t.TempDir(), "socket.dump")
+	w, err := dump.NewWriter(dump.Config{Enabled: true, Path: file, Timestamps: true})
+	require.NoError(t, err)
+	pretty := "{\n  \"host\": \"edge\",\n  \"values\": [1]\n}"
+	require.NoError(t, w.Write([]byte(pretty)))
+	require.NoError(t, w.Write([]byte("next")))
+	require.NoError(t, w.Close())
+
+	res := runReplay(t, "files: ["+file+"]", 2)
+	assert.Equal(t, []string{pretty, "next"}, messages(res))
+}
+
+func TestInvalidConfig(t *testing.T) {
+	dir := t.TempDir()
+	for name, c := range map[string]string{
+		"missing files":   "pacing: fast",
+		"no match":        "files: [" + path.Join(dir, "*.dump") + "]",
+		"unknown pacing":  "files: [/dev/null]\npacing: slow",
+		"negative speed":  "files: [/dev/null]\nspeed: -1",
+		"invalid pattern": "files: ['[']",
+	} {
+		assert.Error(t, New(nil).Config([]byte(c)), name)
+	}
+}
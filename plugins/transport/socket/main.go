@@ -1,7 +1,6 @@
 package main
 
 import (
-	"bufio"
 	"bytes"
 	"context"
 	"encoding/binary"
@@ -11,11 +10,15 @@ import (
 	"os"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/infrawatch/apputils/logging"
 	"github.com/infrawatch/sg-core/pkg/config"
 	"github.com/infrawatch/sg-core/pkg/data"
+	"github.com/infrawatch/sg-core/pkg/dump"
+	"github.com/infrawatch/sg-core/pkg/pluginlog"
+	"github.com/infrawatch/sg-core/pkg/registry"
 	"github.com/infrawatch/sg-core/pkg/transport"
 )
 
@@ -30,56 +33,52 @@ const (
 )
 
 var (
-	msgCount int64
-	lastVal  int64
+	msgCount atomic.Int64 // messages are counted concurrently by connections
+	lastVal  atomic.Int64
 )
 
 func rate() int64 {
-	rate := msgCount - lastVal
-	lastVal = msgCount
-	return rate
+	count := msgCount.Load()
+	return count - lastVal.Swap(count)
 }
 
 type configT struct {
 	Path         string `validate:"required_without=Socketaddr"`
 	Type         string
-	Socketaddr   string `validate:"required_without=Path"`
-	DumpMessages struct {
-		Enabled bool
-		Path    string
-	} `yaml:"dumpMessages"` // only use for debug as this is very slow
+	Socketaddr   string      `validate:"required_without=Path"`
+	DumpMessages dump.Config `yaml:"dumpMessages"` // only use for debug as this is very slow
 }
 type logWrapper struct {
-	l *logging.Logger
+	l *pluginlog.Logger
+}
+
+func newLogWrapper(l *logging.Logger) *logWrapper {
+	return &logWrapper{l: pluginlog.New(l, "transport", "socket")}
 }
 
 func (lw *logWrapper) Errorf(err error, format string, a ...interface{}) {
-	lw.l.Metadata(logging.Metadata{"plugin": "socket", "error": err})
-	lw.l.Error(fmt.Sprintf(format, a...))
+	lw.l.Error(fmt.Sprintf(format, a...), logging.Metadata{"error": err})
 }
 
 func (lw *logWrapper) Infof(format string, a ...interface{}) {
-	lw.l.Metadata(logging.Metadata{"plugin": "socket"})
 	lw.l.Info(fmt.Sprintf(format, a...))
 }
 
 func (lw *logWrapper) Debugf(format string, a ...interface{}) {
-	lw.l.Metadata(logging.Metadata{"plugin": "socket"})
 	lw.l.Debug(fmt.Sprintf(format, a...))
 }
 
 func (lw *logWrapper) Warnf(format string, a ...interface{}) {
-	lw.l.Metadata(logging.Metadata{"plugin": "socket"})
 	lw.l.Warn(fmt.Sprintf(format, a...))
 }
 
 // Socket basic struct
 type Socket struct {
-	conf     configT
-	logger   *logWrapper
-	dumpBuf  *bufio.Writer
-	dumpFile *os.File
-	mutex    sync.Mutex
+	conf    configT
+	logger  *logWrapper
+	dump    *dump.Writer
+	conns   sync.Map    // open connections, closed when transport stops
+	stopped atomic.Bool // set when transport stops, so that closed connections are not reported as failures
 }
 
 func (s *Socket) initUnixSocket() *net.UnixConn {
@@ -169,16 +168,16 @@ func (s *Socket) WriteTCPMsg(w transport.WriteFn, msgBuffer []byte, n int) (int6
 			pos+msgLengthSize+length < 0 {
 			break
 		}
-		s.mutex.Lock()
 		w(msgBuffer[pos+msgLengthSize : pos+msgLengthSize+length])
-		msgCount++
-		s.mutex.Unlock()
+		msgCount.Add(1)
 		pos += msgLengthSize + length
 	}
 	return pos, nil
 }
 
 func (s *Socket) ReceiveData(initialBuffSize int64, done chan bool, pc net.Conn, w transport.WriteFn) {
+	s.conns.Store(pc, struct{}{})
+	defer s.conns.Delete(pc)
 	defer pc.Close()
 	currentBuffSize := initialBuffSize
 	maxBuffSize := s.getMaxBufferSize()
@@ -188,6 +187,9 @@ func (s *Socket) ReceiveData(initialBuffSize int64, done chan bool, pc net.Conn,
 	for {
 		n, err := pc.Read(msgBuffer)
 		if err != nil || n < 1 {
+			if s.stopped.Load() {
+				return
+			}
 			if err != nil {
 				s.logger.Errorf(err, "reading from socket failed")
 			}
@@ -228,18 +230,6 @@ func (s *Socket) ReceiveData(initialBuffSize int64, done chan bool, pc net.Conn,
 			}
 		}
 
-		if s.conf.DumpMessages.Enabled {
-			_, err := s.dumpBuf.Write(data)
-			if err != nil {
-				s.logger.Errorf(err, "writing to dump buffer")
-			}
-			_, err = s.dumpBuf.WriteString("\n")
-			if err != nil {
-				s.logger.Errorf(err, "writing to dump buffer")
-			}
-			s.dumpBuf.Flush()
-		}
-
 		if s.conf.Type == tcp {
 			parsed, err := s.WriteTCPMsg(w, data, totalSize)
 			if err != nil {
@@ -250,15 +240,43 @@ func (s *Socket) ReceiveData(initialBuffSize int64, done chan bool, pc net.Conn,
 			copy(remainingMsg, data[parsed:totalSize])
 		} else {
 			w(data)
-			msgCount++
+			msgCount.Add(1)
 			remainingMsg = nil
 		}
 	}
 }
 
+// receive reads connection in goroutine tracked by receivers. Connection is tracked as open before
+// the goroutine starts, connection accepted while the transport stops is closed right away
+func (s *Socket) receive(receivers *sync.WaitGroup, done chan bool, pc net.Conn, w transport.WriteFn) {
+	s.conns.Store(pc, struct{}{})
+	if s.stopped.Load() {
+		pc.Close()
+	}
+	receivers.Add(1)
+	go func() {
+		defer receivers.Done()
+		s.ReceiveData(maxBufferSize, done, pc, w)
+	}()
+}
+
 // Run implements type Transport
 func (s *Socket) Run(ctx context.Context, w transport.WriteFn, done chan bool) {
+	// transport might be run again after failure
+	s.stopped.Store(false)
+	if s.conf.DumpMessages.Enabled {
+		if err := s.openDump(); err != nil {
+			s.logger.Errorf(err, "failed to open dump file %s", s.conf.DumpMessages.Path)
+			done <- true
+			return
+		}
+		w = s.dumping(w)
+	}
+	// connections are read by goroutines which have to exit before Run returns, the write
+	// function must not be called once the transport is stopped
+	receivers := &sync.WaitGroup{}
 	var pc net.Conn
+	var TCPSocket *net.TCPListener
 	switch s.conf.Type {
 	case udp:
 		pc = s.initUDPSocket()
@@ -266,27 +284,29 @@ func (s *Socket) Run(ctx context.Context, w transport.WriteFn, done chan bool) {
 			s.logger.Errorf(nil, "Failed to initialize socket transport plugin with type: %s", s.conf.Type)
 			return
 		}
-		go s.ReceiveData(maxBufferSize, done, pc, w)
+		s.receive(receivers, done, pc, w)
 
 	case tcp:
-		TCPSocket := s.initTCPSocket()
+		TCPSocket = s.initTCPSocket()
 		if TCPSocket == nil {
 			s.logger.Errorf(nil, "Failed to initialize socket transport plugin with type: %s", s.conf.Type)
 			return
 		}
+		receivers.Add(1)
 		go func() {
+			defer receivers.Done()
 			for {
 				pc, err := TCPSocket.AcceptTCP()
 				if err != nil {
 					select {
 					case <-ctx.Done():
-						break
+						return
 					default:
 						s.logger.Errorf(err, "failed to accept TCP connection")
 						continue
 					}
 				}
-				go s.ReceiveData(maxBufferSize, done, pc, w)
+				s.receive(receivers, done, pc, w)
 			}
 		}()
 	case unix:
@@ -297,7 +317,7 @@ func (s *Socket) Run(ctx context.Context, w transport.WriteFn, done chan bool) {
 			s.logger.Errorf(nil, "Failed to initialize socket transport plugin with type: %s", s.conf.Type)
 			return
 		}
-		go s.ReceiveData(maxBufferSize, done, pc, w)
+		s.receive(receivers, done, pc, w)
 	}
 
 	for {
@@ -310,13 +330,49 @@ func (s *Socket) Run(ctx context.Context, w transport.WriteFn, done chan bool) {
 		}
 	}
 Done:
+	// close listener and connections, so that the socket can be bound again
+	// in case the transport is restarted
+	s.stopped.Store(true)
+	if TCPSocket != nil {
+		TCPSocket.Close()
+	}
+	s.conns.Range(func(conn interface{}, _ interface{}) bool {
+		conn.(net.Conn).Close()
+		return true
+	})
+	receivers.Wait()
 	if s.conf.Type == unix {
 		os.Remove(s.conf.Path)
 	}
-	s.dumpFile.Close()
+	if s.dump != nil {
+		s.dump.Close()
+		s.dump = nil
+	}
 	s.logger.Infof("exited")
 }
 
+// openDump opens dump file unless it is already open, the file is closed when Run exits
+func (s *Socket) openDump() error {
+	if s.dump != nil {
+		return nil
+	}
+	var err error
+	s.dump, err = dump.NewWriter(s.conf.DumpMessages)
+	return err
+}
+
+// dumping wraps write function, so that messages are dumped as they are passed to handlers. Dump writer
+// open when Run starts is used, the field is cleared when Run exits
+func (s *Socket) dumping(w transport.WriteFn) transport.WriteFn {
+	d := s.dump
+	return func(msg []byte) {
+		if err := d.Write(msg); err != nil {
+			s.logger.Errorf(err, "writing to dump file")
+		}
+		w(msg)
+	}
+}
+
 // Listen ...
 func (s *Socket) Listen(e data.Event) {
 	fmt.Printf("received event: %v\n", e)
@@ -325,10 +381,7 @@ func (s *Socket) Listen(e data.Event) {
 // Config load configurations
 func (s *Socket) Config(c []byte) error {
 	s.conf = configT{
-		DumpMessages: struct {
-			Enabled bool
-			Path    string
-		}{
+		DumpMessages: dump.Config{
 			Path: "/dev/stdout",
 		},
 		Type: unix,
@@ -340,12 +393,10 @@ func (s *Socket) Config(c []byte) error {
 	}
 
 	if s.conf.DumpMessages.Enabled {
-		s.dumpFile, err = os.OpenFile(s.conf.DumpMessages.Path, os.O_RDWR|os.O_CREATE|os.O_APPEND, 0666)
+		err = s.openDump()
 		if err != nil {
 			return err
 		}
-
-		s.dumpBuf = bufio.NewWriter(s.dumpFile)
 	}
 
 	s.conf.Type = strings.ToLower(s.conf.Type)
@@ -365,11 +416,13 @@ func (s *Socket) Config(c []byte) error {
 	return nil
 }
 
+func init() {
+	registry.RegisterTransport("socket", New)
+}
+
 // New create new socket transport
 func New(l *logging.Logger) transport.Transport {
 	return &Socket{
-		logger: &logWrapper{
-			l: l,
-		},
+		logger: newLogWrapper(l),
 	}
 }
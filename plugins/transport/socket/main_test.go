@@ -1,7 +1,6 @@
 package main
 
 import (
-	"bufio"
 	"bytes"
 	"context"
 	"encoding/binary"
@@ -9,11 +8,13 @@ import (
 	"os"
 	"path"
 	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
 
 	"github.com/infrawatch/apputils/logging"
 	"github.com/infrawatch/sg-core/pkg/data"
+	"github.com/infrawatch/sg-core/pkg/dump"
 	"github.com/stretchr/testify/require"
 	"gopkg.in/go-playground/assert.v1"
 )
@@ -48,9 +49,7 @@ func TestUnixSocketTransport(t *testing.T) {
 			conf: configT{
 				Path: sktpath,
 			},
-			logger: &logWrapper{
-				l: logger,
-			},
+			logger: newLogWrapper(logger),
 		}
 
 		ctx, cancel := context.WithCancel(context.Background())
@@ -109,9 +108,7 @@ func TestUnixSocketTransport(t *testing.T) {
 			conf: configT{
 				Path: sktpath,
 			},
-			logger: &logWrapper{
-				l: logger,
-			},
+			logger: newLogWrapper(logger),
 		}
 
 		ctx, cancel := context.WithCancel(context.Background())
@@ -181,9 +178,7 @@ func sendUDPSocketMessage(t *testing.T, logger *logging.Logger, addr string, msg
 			Socketaddr: addr,
 			Type:       "udp",
 		},
-		logger: &logWrapper{
-			l: logger,
-		},
+		logger: newLogWrapper(logger),
 	}
 
 	ctx, cancel := context.WithCancel(context.Background())
@@ -290,9 +285,7 @@ func sendTCPSocketMessage(t *testing.T, logger *logging.Logger, addr string, msg
 			Socketaddr: addr,
 			Type:       "tcp",
 		},
-		logger: &logWrapper{
-			l: logger,
-		},
+		logger: newLogWrapper(logger),
 	}
 
 	msgContent := make([]byte, msgSize)
@@ -350,9 +343,7 @@ func TestTcpSocketTransport(t *testing.T) {
 				Socketaddr: "127.0.0.1:8663",
 				Type:       "tcp",
 			},
-			logger: &logWrapper{
-				l: logger,
-			},
+			logger: newLogWrapper(logger),
 		}
 
 		numMessages := 3
@@ -428,9 +419,7 @@ func TestTcpSocketTransport(t *testing.T) {
 				Socketaddr: "127.0.0.1:8665",
 				Type:       "tcp",
 			},
-			logger: &logWrapper{
-				l: logger,
-			},
+			logger: newLogWrapper(logger),
 		}
 
 		msgContent := make([]byte, regularBuffSize)
@@ -473,6 +462,66 @@ func TestTcpSocketTransport(t *testing.T) {
 		wskt1.Close()
 		wskt2.Close()
 	})
+
+	t.Run("test no writes after Run returns", func(t *testing.T) {
+		trans := Socket{
+			conf: configT{
+				Socketaddr: "127.0.0.1:8666",
+				Type:       "tcp",
+			},
+			logger: newLogWrapper(logger),
+		}
+
+		var received, late atomic.Int64
+		var returned atomic.Bool
+		ctx, cancel := context.WithCancel(context.Background())
+		exited := make(chan struct{})
+		go func() {
+			defer close(exited)
+			// slow handling keeps receivers busy with already read data when the transport stops
+			trans.Run(ctx, func([]byte) {
+				time.Sleep(100 * time.Microsecond)
+				if returned.Load() {
+					late.Add(1)
+				}
+				received.Add(1)
+			}, make(chan bool))
+			returned.Store(true)
+		}()
+
+		// clients keep writing while the transport stops
+		msg := createTCPMessage(t, []byte("message"))
+		stop := make(chan struct{})
+		clients := sync.WaitGroup{}
+		for i := 0; i < 4; i++ {
+			conn := connectTCPWithRetry(t, "127.0.0.1:8666")
+			clients.Add(1)
+			go func() {
+				defer clients.Done()
+				defer conn.Close()
+				for {
+					select {
+					case <-stop:
+						return
+					default:
+					}
+					if _, err := conn.Write(msg); err != nil {
+						return
+					}
+				}
+			}()
+		}
+		for received.Load() < 100 {
+			time.Sleep(10 * time.Millisecond)
+		}
+
+		cancel()
+		<-exited
+		time.Sleep(100 * time.Millisecond)
+		close(stop)
+		clients.Wait()
+		assert.Equal(t, int64(0), late.Load())
+	})
 }
 
 func TestNew(t *testing.T) {
@@ -582,9 +631,8 @@ dumpMessages:
 		require.NoError(t, err)
 		assert.Equal(t, true, socket.conf.DumpMessages.Enabled)
 		assert.Equal(t, dumpPath, socket.conf.DumpMessages.Path)
-		require.NotNil(t, socket.dumpFile)
-		require.NotNil(t, socket.dumpBuf)
-		socket.dumpFile.Close()
+		require.NotNil(t, socket.dump)
+		socket.dump.Close()
 	})
 
 	t.Run("invalid socket type", func(t *testing.T) {
@@ -698,9 +746,7 @@ func TestInitializationErrors(t *testing.T) {
 				Path: invalidPath,
 				Type: unix,
 			},
-			logger: &logWrapper{
-				l: logger,
-			},
+			logger: newLogWrapper(logger),
 		}
 
 		result := trans.initUnixSocket()
@@ -713,9 +759,7 @@ func TestInitializationErrors(t *testing.T) {
 				Socketaddr: "not-a-valid-address:::::99999",
 				Type:       udp,
 			},
-			logger: &logWrapper{
-				l: logger,
-			},
+			logger: newLogWrapper(logger),
 		}
 
 		result := trans.initUDPSocket()
@@ -736,9 +780,7 @@ func TestInitializationErrors(t *testing.T) {
 				Socketaddr: "127.0.0.1:18680",
 				Type:       udp,
 			},
-			logger: &logWrapper{
-				l: logger,
-			},
+			logger: newLogWrapper(logger),
 		}
 
 		result := trans.initUDPSocket()
@@ -751,9 +793,7 @@ func TestInitializationErrors(t *testing.T) {
 				Socketaddr: "not-a-valid-address:::::99999",
 				Type:       tcp,
 			},
-			logger: &logWrapper{
-				l: logger,
-			},
+			logger: newLogWrapper(logger),
 		}
 
 		result := trans.initTCPSocket()
@@ -774,9 +814,7 @@ func TestInitializationErrors(t *testing.T) {
 				Socketaddr: "127.0.0.1:18681",
 				Type:       tcp,
 			},
-			logger: &logWrapper{
-				l: logger,
-			},
+			logger: newLogWrapper(logger),
 		}
 
 		result := trans.initTCPSocket()
@@ -804,25 +842,14 @@ func TestDumpMessagesFeature(t *testing.T) {
 			conf: configT{
 				Path: sktpath,
 				Type: unix,
-				DumpMessages: struct {
-					Enabled bool
-					Path    string
-				}{
+				DumpMessages: dump.Config{
 					Enabled: true,
 					Path:    dumpPath,
 				},
 			},
-			logger: &logWrapper{
-				l: logger,
-			},
+			logger: newLogWrapper(logger),
 		}
 
-		// Initialize dump file and buffer
-		trans.dumpFile, err = os.OpenFile(dumpPath, os.O_RDWR|os.O_CREATE|os.O_APPEND, 0666)
-		require.NoError(t, err)
-		defer trans.dumpFile.Close()
-		trans.dumpBuf = bufio.NewWriter(trans.dumpFile)
-
 		ctx, cancel := context.WithCancel(context.Background())
 		wg := sync.WaitGroup{}
 		wg.Add(1)
@@ -869,25 +896,14 @@ func TestDumpMessagesFeature(t *testing.T) {
 			conf: configT{
 				Socketaddr: "127.0.0.1:18690",
 				Type:       tcp,
-				DumpMessages: struct {
-					Enabled bool
-					Path    string
-				}{
+				DumpMessages: dump.Config{
 					Enabled: true,
 					Path:    dumpPath,
 				},
 			},
-			logger: &logWrapper{
-				l: logger,
-			},
+			logger: newLogWrapper(logger),
 		}
 
-		// Initialize dump file and buffer
-		trans.dumpFile, err = os.OpenFile(dumpPath, os.O_RDWR|os.O_CREATE|os.O_APPEND, 0666)
-		require.NoError(t, err)
-		defer trans.dumpFile.Close()
-		trans.dumpBuf = bufio.NewWriter(trans.dumpFile)
-
 		msgContent := []byte("tcp dump test message")
 		fullMsg := createTCPMessage(t, msgContent)
 
@@ -913,7 +929,7 @@ func TestDumpMessagesFeature(t *testing.T) {
 		// Verify message was dumped to file
 		dumpContent, err := os.ReadFile(dumpPath)
 		require.NoError(t, err)
-		require.Contains(t, string(dumpContent), "tcp dump test message")
+		assert.Equal(t, "21\ttcp dump test message\n", string(dumpContent))
 	})
 }
 
@@ -932,9 +948,7 @@ func TestWriteTCPMsgErrors(t *testing.T) {
 				Socketaddr: "127.0.0.1:8670",
 				Type:       "tcp",
 			},
-			logger: &logWrapper{
-				l: logger,
-			},
+			logger: newLogWrapper(logger),
 		}
 
 		// Create a buffer with a message that would cause overflow
@@ -959,9 +973,7 @@ func TestWriteTCPMsgErrors(t *testing.T) {
 				Socketaddr: "127.0.0.1:8671",
 				Type:       "tcp",
 			},
-			logger: &logWrapper{
-				l: logger,
-			},
+			logger: newLogWrapper(logger),
 		}
 
 		// Create a buffer with message length header indicating more data than available
@@ -987,9 +999,7 @@ func TestWriteTCPMsgErrors(t *testing.T) {
 				Socketaddr: "127.0.0.1:8672",
 				Type:       "tcp",
 			},
-			logger: &logWrapper{
-				l: logger,
-			},
+			logger: newLogWrapper(logger),
 		}
 
 		var msgBuffer bytes.Buffer